@@ -0,0 +1,50 @@
+package clickmeta
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "net/url"
+    "strings"
+)
+
+// HashIP pseudonymises a client IP: the same IP and salt always give the same
+// hash, so visitors can be told apart without storing their address.
+func HashIP(ip, salt string) string {
+    if ip == "" { return "" }
+    sum := sha256.Sum256([]byte(salt + "|" + ip))
+    return hex.EncodeToString(sum[:16])
+}
+
+// families are matched in order; Edge and Opera also claim to be Chrome, and
+// Chrome claims to be Safari.
+var families = []struct{ token, family string }{
+    {"bot", "Bot"},
+    {"spider", "Bot"},
+    {"crawl", "Bot"},
+    {"curl/", "curl"},
+    {"wget/", "Wget"},
+    {"edg/", "Edge"},
+    {"opr/", "Opera"},
+    {"firefox/", "Firefox"},
+    {"chrome/", "Chrome"},
+    {"chromium/", "Chrome"},
+    {"safari/", "Safari"},
+}
+
+// UAFamily reduces a User-Agent header to its browser family, e.g. "Firefox".
+func UAFamily(userAgent string) string {
+    ua := strings.ToLower(userAgent)
+    if ua == "" { return "Unknown" }
+    for _, f := range families {
+        if strings.Contains(ua, f.token) { return f.family }
+    }
+    return "Other"
+}
+
+// ReferrerHost keeps only the host of a Referer header; paths and queries can
+// carry private data.
+func ReferrerHost(referrer string) string {
+    u, err := url.Parse(strings.TrimSpace(referrer))
+    if err != nil { return "" }
+    return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,39 @@
+package clickmeta
+
+import "testing"
+
+func TestHashIP(t *testing.T) {
+    a := HashIP("203.0.113.7", "salt")
+    if a == "" || a == "203.0.113.7" || len(a) != 32 { t.Fatalf("unexpected hash %q", a) }
+    if HashIP("203.0.113.7", "salt") != a { t.Fatal("expected a stable hash") }
+    if HashIP("203.0.113.8", "salt") == a || HashIP("203.0.113.7", "pepper") == a { t.Fatal("expected different inputs to hash differently") }
+    if HashIP("", "salt") != "" { t.Fatal("expected no hash without an IP") }
+}
+
+func TestUAFamily(t *testing.T) {
+    cases := map[string]string{
+        "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36":         "Chrome",
+        "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36 Edg/120.0": "Edge",
+        "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0":                                              "Firefox",
+        "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15":    "Safari",
+        "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)":                                                           "Bot",
+        "curl/8.4.0": "curl",
+        "":           "Unknown",
+        "Lynx/2.9":   "Other",
+    }
+    for ua, want := range cases {
+        if got := UAFamily(ua); got != want { t.Errorf("UAFamily(%q) = %q, want %q", ua, got, want) }
+    }
+}
+
+func TestReferrerHost(t *testing.T) {
+    cases := map[string]string{
+        "https://Mail.Example.com/inbox?id=42": "mail.example.com",
+        "http://wiki.internal:8080/page":       "wiki.internal",
+        "":                                     "",
+        "not a url":                            "",
+    }
+    for ref, want := range cases {
+        if got := ReferrerHost(ref); got != want { t.Errorf("ReferrerHost(%q) = %q, want %q", ref, got, want) }
+    }
+}
@@ -0,0 +1,26 @@
+package linkstatus
+
+import "time"
+
+const (
+    Active    = "active"
+    Scheduled = "scheduled"
+    Expired   = "expired"
+)
+
+// Of derives a link's status from its optional activation window. Both ends
+// are optional: a nil activeFrom means "already active", a nil expiresAt means
+// "never expires".
+func Of(activeFrom, expiresAt *time.Time, now time.Time) string {
+    if expiresAt != nil && !now.Before(*expiresAt) {
+        return Expired
+    }
+    if activeFrom != nil && now.Before(*activeFrom) {
+        return Scheduled
+    }
+    return Active
+}
+
+func IsValid(status string) bool {
+    return status == Active || status == Scheduled || status == Expired
+}
@@ -0,0 +1,41 @@
+package linkstatus
+
+import (
+    "testing"
+    "time"
+)
+
+func TestOf(t *testing.T) {
+    now := time.Now()
+    past := now.Add(-time.Hour)
+    future := now.Add(time.Hour)
+    cases := []struct {
+        name       string
+        activeFrom *time.Time
+        expiresAt  *time.Time
+        want       string
+    }{
+        {"no window", nil, nil, Active},
+        {"started", &past, nil, Active},
+        {"not started", &future, nil, Scheduled},
+        {"open window", &past, &future, Active},
+        {"expired", nil, &past, Expired},
+        {"expires exactly now", nil, &now, Expired},
+    }
+    for _, c := range cases {
+        if got := Of(c.activeFrom, c.expiresAt, now); got != c.want {
+            t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+        }
+    }
+}
+
+func TestIsValid(t *testing.T) {
+    for _, s := range []string{Active, Scheduled, Expired} {
+        if !IsValid(s) {
+            t.Errorf("expected valid status %q", s)
+        }
+    }
+    if IsValid("") || IsValid("deleted") {
+        t.Errorf("expected unknown statuses to be invalid")
+    }
+}
@@ -0,0 +1,116 @@
+package linktemplate
+
+import (
+    "errors"
+    "net/url"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// A template link stores placeholders in its target URL that are filled from
+// the path segments following the alias, e.g. /jira/PROJ-123.
+//   {1}, {2}, ...  the n-th segment after the alias
+//   {*}            every segment after the highest {n}, joined with "/"
+//   %s             same as {*}, matching browser keyword-search syntax
+var placeholder = regexp.MustCompile(`\{(\d+|\*)\}|%s`)
+
+// MaxArgs is the highest positional placeholder a template may use.
+const MaxArgs = 32
+
+var (
+    ErrMissingArgument    = errors.New("missing path argument")
+    ErrInvalidPlaceholder = errors.New("placeholders are numbered from {1} to {32}")
+)
+
+func IsTemplate(target string) bool { return placeholder.MatchString(target) }
+
+// Validate rejects positional placeholders outside {1}..{MaxArgs}, such as
+// {0} or an index too large to parse.
+func Validate(target string) error {
+    for _, m := range placeholder.FindAllStringSubmatch(target, -1) {
+        if m[1] == "" || m[1] == "*" {
+            continue
+        }
+        if _, ok := position(m[1]); !ok {
+            return ErrInvalidPlaceholder
+        }
+    }
+    return nil
+}
+
+// position parses the index of a positional placeholder, reporting whether
+// it is within 1..MaxArgs.
+func position(digits string) (int, bool) {
+    n, err := strconv.Atoi(digits)
+    return n, err == nil && n >= 1 && n <= MaxArgs
+}
+
+// RequiredArgs returns how many segments must follow the alias, which is the
+// highest valid positional placeholder used. {*} and %s accept zero segments.
+func RequiredArgs(target string) int {
+    required := 0
+    for _, m := range placeholder.FindAllStringSubmatch(target, -1) {
+        if n, ok := position(m[1]); ok && n > required {
+            required = n
+        }
+    }
+    return required
+}
+
+// Expand substitutes args into target. Values placed after '?' or '#' are
+// query-escaped, others path-escaped, so an argument can never inject extra
+// path segments or query parameters. Placeholders Validate rejects fail with
+// ErrInvalidPlaceholder.
+func Expand(target string, args []string) (string, error) {
+    if len(args) < RequiredArgs(target) {
+        return "", ErrMissingArgument
+    }
+    rest := args[RequiredArgs(target):]
+    queryStart := strings.IndexAny(target, "?#")
+    var b strings.Builder
+    last := 0
+    for _, loc := range placeholder.FindAllStringSubmatchIndex(target, -1) {
+        b.WriteString(target[last:loc[0]])
+        last = loc[1]
+        escape := url.PathEscape
+        if queryStart >= 0 && loc[0] > queryStart {
+            escape = url.QueryEscape
+        }
+        if loc[2] >= 0 && target[loc[2]:loc[3]] != "*" {
+            n, ok := position(target[loc[2]:loc[3]])
+            if !ok {
+                return "", ErrInvalidPlaceholder
+            }
+            b.WriteString(escape(args[n-1]))
+            continue
+        }
+        b.WriteString(joinEscaped(rest, escape))
+    }
+    b.WriteString(target[last:])
+    return b.String(), nil
+}
+
+// Sample expands target with placeholder values so callers can validate the
+// shape of the URL a template will produce. It returns "" for a template
+// Validate rejects.
+func Sample(target string) string {
+    n := RequiredArgs(target)
+    if n == 0 {
+        n = 1
+    }
+    args := make([]string, n)
+    for i := range args {
+        args[i] = "x"
+    }
+    expanded, _ := Expand(target, args)
+    return expanded
+}
+
+func joinEscaped(args []string, escape func(string) string) string {
+    parts := make([]string, len(args))
+    for i, a := range args {
+        parts[i] = escape(a)
+    }
+    return strings.Join(parts, "/")
+}
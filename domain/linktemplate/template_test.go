@@ -0,0 +1,95 @@
+package linktemplate
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestIsTemplate(t *testing.T) {
+    templates := []string{"https://jira.example.com/browse/{1}", "https://github.com/org/{*}", "https://google.com/search?q=%s"}
+    for _, u := range templates {
+        if !IsTemplate(u) {
+            t.Errorf("expected template: %q", u)
+        }
+    }
+    plain := []string{"https://example.com", "https://example.com/{name}", "https://example.com/100%25"}
+    for _, u := range plain {
+        if IsTemplate(u) {
+            t.Errorf("expected plain url: %q", u)
+        }
+    }
+}
+
+func TestRequiredArgs(t *testing.T) {
+    cases := map[string]int{
+        "https://example.com":                 0,
+        "https://example.com/{*}":             0,
+        "https://example.com/{1}":             1,
+        "https://example.com/{2}/{1}?q=%s":    2,
+    }
+    for u, want := range cases {
+        if got := RequiredArgs(u); got != want {
+            t.Errorf("RequiredArgs(%q) = %d, want %d", u, got, want)
+        }
+    }
+}
+
+func TestExpand(t *testing.T) {
+    cases := []struct {
+        target string
+        args   []string
+        want   string
+    }{
+        {"https://jira.example.com/browse/{1}", []string{"PROJ-123"}, "https://jira.example.com/browse/PROJ-123"},
+        {"https://github.com/{2}/{1}", []string{"quickr", "org"}, "https://github.com/org/quickr"},
+        {"https://github.com/org/{*}", []string{"quickr", "pulls"}, "https://github.com/org/quickr/pulls"},
+        {"https://github.com/org/{*}", nil, "https://github.com/org/"},
+        {"https://google.com/search?q=%s", []string{"a b&c"}, "https://google.com/search?q=a+b%26c"},
+        {"https://example.com/{1}", []string{"a/b?c"}, "https://example.com/a%2Fb%3Fc"},
+        {"https://example.com/{1}#{1}", []string{"x y"}, "https://example.com/x%20y#x+y"},
+        {"https://x.example.com/{1}/{*}", []string{"a", "b", "c"}, "https://x.example.com/a/b/c"},
+        {"https://x.example.com/{1}/{*}", []string{"a"}, "https://x.example.com/a/"},
+        {"https://google.com/search?site={1}&q=%s", []string{"go.dev", "x", "y"}, "https://google.com/search?site=go.dev&q=x/y"},
+    }
+    for _, c := range cases {
+        got, err := Expand(c.target, c.args)
+        if err != nil {
+            t.Fatalf("Expand(%q, %v) unexpected error: %v", c.target, c.args, err)
+        }
+        if got != c.want {
+            t.Errorf("Expand(%q, %v) = %q, want %q", c.target, c.args, got, c.want)
+        }
+    }
+}
+
+func TestExpand_MissingArgument(t *testing.T) {
+    if _, err := Expand("https://example.com/{1}/{2}", []string{"a"}); !errors.Is(err, ErrMissingArgument) {
+        t.Fatalf("expected ErrMissingArgument, got %v", err)
+    }
+}
+
+func TestSample(t *testing.T) {
+    if got := Sample("https://{1}.example.com/{2}"); got != "https://x.example.com/x" {
+        t.Fatalf("unexpected sample: %q", got)
+    }
+    if got := Sample("https://example.com/search?q=%s"); got != "https://example.com/search?q=x" {
+        t.Fatalf("unexpected sample: %q", got)
+    }
+}
+
+func TestInvalidPlaceholders(t *testing.T) {
+    for _, u := range []string{"https://x.com/{0}", "https://x.com/{1}/{00}", "https://x.com/{33}", "https://x.com/{99999999999999999999}"} {
+        if err := Validate(u); !errors.Is(err, ErrInvalidPlaceholder) {
+            t.Errorf("Validate(%q) = %v, want ErrInvalidPlaceholder", u, err)
+        }
+        if _, err := Expand(u, []string{"a", "b"}); !errors.Is(err, ErrInvalidPlaceholder) {
+            t.Errorf("Expand(%q) = %v, want ErrInvalidPlaceholder", u, err)
+        }
+        if got := Sample(u); got != "" {
+            t.Errorf("Sample(%q) = %q, want none", u, got)
+        }
+    }
+    if err := Validate("https://x.com/{1}/{32}/{*}?q=%s"); err != nil {
+        t.Errorf("expected {1}..{32}, {*} and %%s to be valid, got %v", err)
+    }
+}
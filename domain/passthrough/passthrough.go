@@ -0,0 +1,99 @@
+package passthrough
+
+import (
+    "net/url"
+    "strings"
+)
+
+// Merge strategies decide which value wins when the incoming request and the
+// link target both carry the same query key.
+const (
+    MergeKeepTarget    = "target"  // link target values win, request duplicates are dropped
+    MergePreferRequest = "request" // request values replace the target's
+    MergeAppend        = "append"  // keep both, target first
+)
+
+func IsValidMerge(strategy string) bool {
+    switch strategy {
+    case MergeKeepTarget, MergePreferRequest, MergeAppend:
+        return true
+    }
+    return false
+}
+
+// Apply appends the extra path segments to target and merges rawQuery into the
+// target's query string. Query pairs are copied verbatim, so encodings chosen
+// by the target or the caller survive the round trip; only path segments are
+// escaped because they arrive decoded from the router.
+func Apply(target string, extraPath []string, rawQuery string, strategy string) (string, error) {
+    u, err := url.Parse(target)
+    if err != nil {
+        return "", err
+    }
+    if len(extraPath) > 0 {
+        escaped := make([]string, len(extraPath))
+        for i, seg := range extraPath {
+            escaped[i] = url.PathEscape(seg)
+        }
+        joined := strings.TrimRight(u.EscapedPath(), "/") + "/" + strings.Join(escaped, "/")
+        path, err := url.PathUnescape(joined)
+        if err != nil {
+            return "", err
+        }
+        u.Path, u.RawPath = path, joined
+    }
+    u.RawQuery = mergeQuery(u.RawQuery, rawQuery, strategy)
+    return u.String(), nil
+}
+
+func mergeQuery(targetQuery, requestQuery, strategy string) string {
+    targetPairs := splitPairs(targetQuery)
+    requestPairs := splitPairs(requestQuery)
+    if len(requestPairs) == 0 {
+        return targetQuery
+    }
+    switch strategy {
+    case MergePreferRequest:
+        targetPairs = withoutKeys(targetPairs, keysOf(requestPairs))
+    case MergeAppend:
+    default:
+        requestPairs = withoutKeys(requestPairs, keysOf(targetPairs))
+    }
+    return strings.Join(append(targetPairs, requestPairs...), "&")
+}
+
+func splitPairs(rawQuery string) []string {
+    var pairs []string
+    for _, p := range strings.Split(rawQuery, "&") {
+        if p != "" {
+            pairs = append(pairs, p)
+        }
+    }
+    return pairs
+}
+
+func pairKey(pair string) string {
+    key, _, _ := strings.Cut(pair, "=")
+    if k, err := url.QueryUnescape(key); err == nil {
+        return k
+    }
+    return key
+}
+
+func keysOf(pairs []string) map[string]struct{} {
+    keys := make(map[string]struct{}, len(pairs))
+    for _, p := range pairs {
+        keys[pairKey(p)] = struct{}{}
+    }
+    return keys
+}
+
+func withoutKeys(pairs []string, keys map[string]struct{}) []string {
+    kept := pairs[:0:0]
+    for _, p := range pairs {
+        if _, drop := keys[pairKey(p)]; !drop {
+            kept = append(kept, p)
+        }
+    }
+    return kept
+}
@@ -0,0 +1,48 @@
+package passthrough
+
+import "testing"
+
+func TestApply(t *testing.T) {
+    cases := []struct {
+        name     string
+        target   string
+        path     []string
+        query    string
+        strategy string
+        want     string
+    }{
+        {"no extras", "https://docs.example.com/base?x=1", nil, "", MergeKeepTarget, "https://docs.example.com/base?x=1"},
+        {"path appended", "https://docs.example.com", []string{"guides", "setup"}, "", MergeKeepTarget, "https://docs.example.com/guides/setup"},
+        {"trailing slash", "https://docs.example.com/v2/", []string{"setup"}, "", MergeKeepTarget, "https://docs.example.com/v2/setup"},
+        {"query merged", "https://docs.example.com/base?lang=en", []string{"guides"}, "tab=2", MergeKeepTarget, "https://docs.example.com/base/guides?lang=en&tab=2"},
+        {"target wins", "https://example.com/?tab=1", nil, "tab=2&x=y", MergeKeepTarget, "https://example.com/?tab=1&x=y"},
+        {"request wins", "https://example.com/?tab=1&lang=en", nil, "tab=2", MergePreferRequest, "https://example.com/?lang=en&tab=2"},
+        {"append keeps both", "https://example.com/?tab=1", nil, "tab=2", MergeAppend, "https://example.com/?tab=1&tab=2"},
+        {"segment escaping", "https://example.com", []string{"a b", "c?d", "e%f"}, "", MergeKeepTarget, "https://example.com/a%20b/c%3Fd/e%25f"},
+        {"target encoded path kept", "https://example.com/with%2Fslash", []string{"x"}, "", MergeKeepTarget, "https://example.com/with%2Fslash/x"},
+        {"query encoding kept", "https://example.com/?q=a%20b", nil, "r=c+d&s=%26", MergeKeepTarget, "https://example.com/?q=a%20b&r=c+d&s=%26"},
+        {"encoded key conflict", "https://example.com/?a%20b=1", nil, "a+b=2", MergeKeepTarget, "https://example.com/?a%20b=1"},
+        {"fragment kept", "https://example.com/page#top", []string{"sub"}, "k=v", MergeKeepTarget, "https://example.com/page/sub?k=v#top"},
+        {"empty pairs ignored", "https://example.com/", nil, "&&k=v&", MergeKeepTarget, "https://example.com/?k=v"},
+    }
+    for _, c := range cases {
+        got, err := Apply(c.target, c.path, c.query, c.strategy)
+        if err != nil {
+            t.Fatalf("%s: unexpected error: %v", c.name, err)
+        }
+        if got != c.want {
+            t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+        }
+    }
+}
+
+func TestIsValidMerge(t *testing.T) {
+    for _, s := range []string{MergeKeepTarget, MergePreferRequest, MergeAppend} {
+        if !IsValidMerge(s) {
+            t.Errorf("expected valid strategy: %q", s)
+        }
+    }
+    if IsValidMerge("") || IsValidMerge("other") {
+        t.Errorf("expected invalid strategies to be rejected")
+    }
+}
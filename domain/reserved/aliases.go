@@ -10,6 +10,9 @@ var aliases = map[string]struct{}{
     "admin":       {},
     "stats":       {},
     "hot":         {},
+    "trash":       {},
+    "links":       {},
+    "settings":    {},
     "api":         {},
     "static":      {},
     "favicon.ico": {},
@@ -3,7 +3,7 @@ package reserved
 import "testing"
 
 func TestIsReservedAlias(t *testing.T) {
-    reservedCases := []string{"admin", "stats", "LOGIN", "/magic/", "robots.txt", "favicon.ico"}
+    reservedCases := []string{"admin", "stats", "trash", "links", "LOGIN", "/magic/", "robots.txt", "favicon.ico"}
     for _, a := range reservedCases {
         if !IsReservedAlias(a) {
             t.Errorf("expected reserved: %q", a)
@@ -0,0 +1,86 @@
+package suggest
+
+import (
+    "math"
+    "sort"
+    "strings"
+)
+
+// Candidate is an existing alias that may be offered for a mistyped one.
+type Candidate struct {
+    Alias  string
+    Clicks uint
+}
+
+// minPrefix keeps one- and two-letter aliases from prefix-matching everything.
+const minPrefix = 3
+
+// Closest returns up to limit candidates resembling query, best first. A
+// candidate qualifies when it is within a third of the query's length in
+// edits (at least one) or when one alias is a prefix of the other. Fewer
+// edits, a shared prefix and more clicks rank higher; clicks only count
+// logarithmically so a popular link cannot bury a near-exact match.
+func Closest(query string, candidates []Candidate, limit int) []Candidate {
+    q := strings.ToLower(strings.TrimSpace(query))
+    if q == "" || limit <= 0 { return nil }
+    maxEdits := len([]rune(q)) / 3
+    if maxEdits < 1 { maxEdits = 1 }
+
+    type scored struct {
+        Candidate
+        score float64
+    }
+    var matches []scored
+    for _, c := range candidates {
+        a := strings.ToLower(c.Alias)
+        prefix := isPrefix(q, a)
+        // the length gap alone already rules out most aliases
+        gap := len([]rune(q)) - len([]rune(a))
+        if (gap > maxEdits || -gap > maxEdits) && !prefix { continue }
+        d := Distance(q, a)
+        if d > maxEdits && !prefix { continue }
+        longest := math.Max(float64(len([]rune(q))), float64(len([]rune(a))))
+        score := 1 - float64(d)/longest
+        if prefix { score += 0.5 }
+        score += 0.1 * math.Log10(1+float64(c.Clicks))
+        matches = append(matches, scored{c, score})
+    }
+    sort.SliceStable(matches, func(i, j int) bool {
+        if matches[i].score != matches[j].score { return matches[i].score > matches[j].score }
+        return matches[i].Alias < matches[j].Alias
+    })
+    if len(matches) > limit { matches = matches[:limit] }
+    out := make([]Candidate, len(matches))
+    for i, m := range matches { out[i] = m.Candidate }
+    return out
+}
+
+// isPrefix reports whether the shorter of a and b starts the longer one.
+func isPrefix(a, b string) bool {
+    if len(a) > len(b) { a, b = b, a }
+    return len([]rune(a)) >= minPrefix && strings.HasPrefix(b, a)
+}
+
+// Distance is the edit distance between a and b in runes, counting an
+// insertion, deletion, substitution or swap of two adjacent runes as one edit
+// (optimal string alignment), so "vnp" is one typo away from "vpn".
+func Distance(a, b string) int {
+    ra, rb := []rune(a), []rune(b)
+    d := make([][]int, len(ra)+1)
+    for i := range d {
+        d[i] = make([]int, len(rb)+1)
+        d[i][0] = i
+    }
+    for j := range d[0] { d[0][j] = j }
+    for i := 1; i <= len(ra); i++ {
+        for j := 1; j <= len(rb); j++ {
+            cost := 1
+            if ra[i-1] == rb[j-1] { cost = 0 }
+            d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+            if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+                d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+            }
+        }
+    }
+    return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,67 @@
+package suggest
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestDistance(t *testing.T) {
+    cases := []struct {
+        a, b string
+        want int
+    }{
+        {"", "", 0},
+        {"vpn", "vpn", 0},
+        {"vpn", "vnp", 1},
+        {"ab", "ba", 1},
+        {"kitten", "sitting", 3},
+        {"wiki", "", 4},
+        {"café", "cafe", 1},
+    }
+    for _, c := range cases {
+        if got := Distance(c.a, c.b); got != c.want {
+            t.Errorf("Distance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+        }
+    }
+}
+
+func aliases(cs []Candidate) []string {
+    out := []string{}
+    for _, c := range cs { out = append(out, c.Alias) }
+    return out
+}
+
+func TestClosest(t *testing.T) {
+    links := []Candidate{
+        {Alias: "vpn", Clicks: 10},
+        {Alias: "vpn-setup", Clicks: 2},
+        {Alias: "wiki", Clicks: 500},
+        {Alias: "jira", Clicks: 50},
+        {Alias: "standup", Clicks: 0},
+    }
+    cases := []struct {
+        query string
+        want  []string
+    }{
+        {"vnp", []string{"vpn"}},
+        {"VPN-", []string{"vpn", "vpn-setup"}},
+        {"vpn-set", []string{"vpn-setup", "vpn"}},
+        {"stand", []string{"standup"}},
+        {"wikk", []string{"wiki"}},
+        {"confluence", []string{}},
+        {"", []string{}},
+    }
+    for _, c := range cases {
+        if got := aliases(Closest(c.query, links, 5)); !reflect.DeepEqual(got, c.want) {
+            t.Errorf("Closest(%q) = %v, want %v", c.query, got, c.want)
+        }
+    }
+}
+
+func TestClosest_ClicksBreakTies(t *testing.T) {
+    links := []Candidate{{Alias: "docs", Clicks: 1}, {Alias: "dogs", Clicks: 900}}
+    if got := aliases(Closest("dods", links, 5)); !reflect.DeepEqual(got, []string{"dogs", "docs"}) {
+        t.Fatalf("expected the busier alias first, got %v", got)
+    }
+    if got := Closest("dods", links, 1); len(got) != 1 { t.Fatalf("expected limit to apply, got %v", got) }
+}
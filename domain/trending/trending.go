@@ -0,0 +1,61 @@
+package trending
+
+import (
+    "math"
+    "sort"
+    "time"
+)
+
+// Bucket is the number of clicks in the hour starting at Start.
+type Bucket struct {
+    Start  time.Time
+    Clicks int64
+}
+
+// Score measures how far a link's recent clicks run ahead of its usual pace
+// over window. Recent activity weighs each hour's clicks by 0.5^(age/halfLife);
+// the usual pace is the same weighting applied to the link's average hourly
+// rate across the window. Steady links score about zero however busy they
+// are, a sudden burst scores high and fades as it ages; cooling links score 0.
+func Score(buckets []Bucket, now time.Time, window, halfLife time.Duration) float64 {
+    hours := int(window / time.Hour)
+    if hours <= 0 || halfLife <= 0 { return 0 }
+    from := now.Add(-window)
+    var recent float64
+    var total int64
+    for _, b := range buckets {
+        if b.Start.Before(from) || b.Start.After(now) { continue }
+        total += b.Clicks
+        recent += float64(b.Clicks) * decay(now.Sub(b.Start), halfLife)
+    }
+    if total == 0 { return 0 }
+    rate := float64(total) / float64(hours)
+    var expected float64
+    for h := 0; h < hours; h++ { expected += rate * decay(time.Duration(h)*time.Hour, halfLife) }
+    return math.Max(0, recent-expected)
+}
+
+func decay(age, halfLife time.Duration) float64 {
+    return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// Ranked is a link's trending score.
+type Ranked struct {
+    LinkID uint
+    Score  float64
+}
+
+// Rank scores each link's hourly history and returns the limit highest
+// scores of at least minScore, highest first.
+func Rank(histories map[uint][]Bucket, now time.Time, window, halfLife time.Duration, minScore float64, limit int) []Ranked {
+    ranked := []Ranked{}
+    for id, buckets := range histories {
+        if s := Score(buckets, now, window, halfLife); s >= minScore { ranked = append(ranked, Ranked{LinkID: id, Score: s}) }
+    }
+    sort.Slice(ranked, func(i, j int) bool {
+        if ranked[i].Score != ranked[j].Score { return ranked[i].Score > ranked[j].Score }
+        return ranked[i].LinkID < ranked[j].LinkID
+    })
+    if len(ranked) > limit { ranked = ranked[:limit] }
+    return ranked
+}
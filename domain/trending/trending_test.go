@@ -0,0 +1,66 @@
+package trending
+
+import (
+    "testing"
+    "time"
+)
+
+var (
+    now      = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+    week     = 7 * 24 * time.Hour
+    halfLife = 6 * time.Hour
+)
+
+// history builds hourly buckets for the week before now; clicks(h) gives the
+// clicks h hours ago.
+func history(clicks func(hoursAgo int) int64) []Bucket {
+    var buckets []Bucket
+    for h := 0; h < 7*24; h++ {
+        if c := clicks(h); c > 0 { buckets = append(buckets, Bucket{Start: now.Add(-time.Duration(h) * time.Hour), Clicks: c}) }
+    }
+    return buckets
+}
+
+func TestScore_SteadyTrafficIsNotTrending(t *testing.T) {
+    busy := history(func(int) int64 { return 100 })
+    if s := Score(busy, now, week, halfLife); s > 5 { t.Fatalf("expected a steady link to score about zero, got %.2f", s) }
+}
+
+func TestScore_SpikeBeatsSteadyTraffic(t *testing.T) {
+    steady := history(func(int) int64 { return 20 })
+    spike := history(func(h int) int64 {
+        if h < 3 { return 40 }
+        return 1
+    })
+    if Score(spike, now, week, halfLife) <= Score(steady, now, week, halfLife)+50 {
+        t.Fatalf("expected the spiking link to trend well above the steady one: spike=%.2f steady=%.2f",
+            Score(spike, now, week, halfLife), Score(steady, now, week, halfLife))
+    }
+}
+
+func TestScore_SpikeFades(t *testing.T) {
+    fresh := history(func(h int) int64 { if h < 3 { return 40 }; return 0 })
+    dayOld := history(func(h int) int64 { if h >= 24 && h < 27 { return 40 }; return 0 })
+    old := history(func(h int) int64 { if h >= 120 && h < 123 { return 40 }; return 0 })
+    f, d, o := Score(fresh, now, week, halfLife), Score(dayOld, now, week, halfLife), Score(old, now, week, halfLife)
+    if !(f > d && d > o) { t.Fatalf("expected the score to fade with age: fresh=%.2f day-old=%.2f old=%.2f", f, d, o) }
+    if o != 0 { t.Fatalf("expected a five-day-old burst to have stopped trending, got %.2f", o) }
+}
+
+func TestScore_Empty(t *testing.T) {
+    if s := Score(nil, now, week, halfLife); s != 0 { t.Fatalf("expected 0, got %.2f", s) }
+    outside := []Bucket{{Start: now.Add(-8 * 24 * time.Hour), Clicks: 1000}}
+    if s := Score(outside, now, week, halfLife); s != 0 { t.Fatalf("expected clicks before the window to be ignored, got %.2f", s) }
+}
+
+func TestRank(t *testing.T) {
+    histories := map[uint][]Bucket{
+        1: history(func(int) int64 { return 50 }),
+        2: history(func(h int) int64 { if h < 2 { return 30 }; return 0 }),
+        3: history(func(h int) int64 { if h < 2 { return 90 }; return 0 }),
+        4: nil,
+    }
+    got := Rank(histories, now, week, halfLife, 1, 10)
+    if len(got) != 2 || got[0].LinkID != 3 || got[1].LinkID != 2 { t.Fatalf("unexpected ranking %+v", got) }
+    if got := Rank(histories, now, week, halfLife, 1, 1); len(got) != 1 || got[0].LinkID != 3 { t.Fatalf("expected limit to apply, got %+v", got) }
+}
@@ -10,31 +10,71 @@ import (
 	"quickr/models"
 )
 
-// InviteRow is a view model for rendering an invitation row with user-level disabled flag
+// InviteRow is a view model for rendering an invitation row with the state of the account it opened;
+// UserID is 0 when no account exists for the email
 type InviteRow struct {
 	models.Invitation
+	UserID       uint
 	UserDisabled bool
 }
 
+// HistoryRow is one line of the invitations table: an invitation or a change to a user's access
+type HistoryRow struct {
+	Invite *InviteRow
+	Event  *models.UserEvent
+}
+
 // GET /admin renders a simple dashboard (list + create form)
 func (h *AppHandler) AdminDashboard() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		invites, _ := h.AuthService.ListInvitations(200)
-		annotated, _ := h.AuthService.AnnotateInvitesWithUserDisabled(invites)
-		rows := make([]InviteRow, 0, len(annotated))
-		for _, a := range annotated { rows = append(rows, InviteRow{Invitation: a.Inv, UserDisabled: a.UserDisabled}) }
 		emailVal, _ := c.Get("userEmail")
 		roleVal, _ := c.Get("userRole")
 		isAdmin := roleVal == "admin"
 		c.HTML(http.StatusOK, "admin.html", gin.H{
 			"active":    "admin",
-			"invites":   rows,
+			"invites":   h.invitationHistory(),
 			"userEmail": emailVal,
 			"isAdmin":   isAdmin,
 		})
 	}
 }
 
+// inviteRow annotates one invitation with the state of its account
+func (h *AppHandler) inviteRow(inv models.Invitation) InviteRow {
+	annotated, _ := h.AuthService.AnnotateInvites([]models.Invitation{inv})
+	if len(annotated) != 1 {
+		return InviteRow{Invitation: inv}
+	}
+	return InviteRow{Invitation: inv, UserID: annotated[0].UserID, UserDisabled: annotated[0].UserDisabled}
+}
+
+// invitationHistory interleaves the latest invitations with the latest user events, newest first
+func (h *AppHandler) invitationHistory() []HistoryRow {
+	invites, _ := h.AuthService.ListInvitations(200)
+	annotated, _ := h.AuthService.AnnotateInvites(invites)
+	var events []models.UserEvent
+	if h.UserService != nil {
+		events, _ = h.UserService.ListEvents(200)
+	}
+	rows := make([]HistoryRow, 0, len(annotated)+len(events))
+	for len(annotated) > 0 || len(events) > 0 {
+		if len(events) == 0 || (len(annotated) > 0 && !events[0].CreatedAt.After(annotated[0].Inv.CreatedAt)) {
+			a := annotated[0]
+			rows = append(rows, HistoryRow{Invite: &InviteRow{Invitation: a.Inv, UserID: a.UserID, UserDisabled: a.UserDisabled}})
+			annotated = annotated[1:]
+			continue
+		}
+		rows = append(rows, HistoryRow{Event: &events[0]})
+		events = events[1:]
+	}
+	return rows
+}
+
+// renderInvitesBody answers an HTMX action on the invitations table with the whole refreshed table body
+func (h *AppHandler) renderInvitesBody(c *gin.Context) {
+	c.HTML(http.StatusOK, "admin_invites_body.html", gin.H{"invites": h.invitationHistory()})
+}
+
 // POST /admin/invitations creates an invitation and sends it immediately
 func (h *AppHandler) CreateInvitation() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -54,9 +94,7 @@ func (h *AppHandler) CreateInvitation() gin.HandlerFunc {
 		var inv models.Invitation
 		if len(invites) > 0 { inv = invites[0] }
 		if c.GetHeader("HX-Request") == "true" {
-			annotated, _ := h.AuthService.AnnotateInvitesWithUserDisabled([]models.Invitation{inv})
-			row := InviteRow{Invitation: inv, UserDisabled: len(annotated) == 1 && annotated[0].UserDisabled}
-			c.HTML(http.StatusCreated, "admin_invite_row.html", row)
+			c.HTML(http.StatusCreated, "admin_invite_row.html", h.inviteRow(inv))
 			return
 		}
 		c.JSON(http.StatusCreated, inv)
@@ -73,16 +111,14 @@ func (h *AppHandler) RevokeInvitation() gin.HandlerFunc {
 			return
 		}
 		if c.GetHeader("HX-Request") == "true" {
-			annotated, _ := h.AuthService.AnnotateInvitesWithUserDisabled([]models.Invitation{*inv})
-			row := InviteRow{Invitation: *inv, UserDisabled: len(annotated) == 1 && annotated[0].UserDisabled}
-			c.HTML(http.StatusOK, "admin_invite_row.html", row)
+			c.HTML(http.StatusOK, "admin_invite_row.html", h.inviteRow(*inv))
 			return
 		}
 		c.JSON(http.StatusOK, inv)
 	}
 }
 
-// POST /admin/invitations/revoke-email disables the user and revokes all invites for that email
+// POST /admin/invitations/revoke-email disables the user, if there is one, and revokes all invites for that email
 func (h *AppHandler) RevokeInvitationsByEmail() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		email := strings.TrimSpace(strings.ToLower(c.PostForm("email")))
@@ -91,26 +127,12 @@ func (h *AppHandler) RevokeInvitationsByEmail() gin.HandlerFunc {
 			return
 		}
 		log.Printf("[ADMIN] RevokeInvitationsByEmail email=%s", email)
-		if err := h.AuthService.DisableUser(email); err != nil {
-			if err.Error() == "user already revoked" {
-				if c.GetHeader("HX-Request") == "true" {
-					c.Header("HX-Reswap", "none")
-					c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(`<div id="invite-error" hx-swap-oob="true">User already revoked</div>`))
-					return
-				}
-				c.JSON(http.StatusBadRequest, gin.H{"error": "user already revoked"})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable user"})
+		if err := h.UserService.RevokeEmail(email, c.GetString("userEmail")); err != nil {
+			writeUserError(c, err, "invite-error")
 			return
 		}
-		_ = h.AuthService.RevokeAllForEmail(email)
 		if c.GetHeader("HX-Request") == "true" {
-			invites, _ := h.AuthService.ListInvitations(200)
-			annotated, _ := h.AuthService.AnnotateInvitesWithUserDisabled(invites)
-			rows := make([]InviteRow, 0, len(annotated))
-			for _, a := range annotated { rows = append(rows, InviteRow{Invitation: a.Inv, UserDisabled: a.UserDisabled}) }
-			c.HTML(http.StatusOK, "admin_invites_body.html", gin.H{"invites": rows})
+			h.renderInvitesBody(c)
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"email": email, "revoked": true})
@@ -134,11 +156,22 @@ func (h *AppHandler) SendInvitation() gin.HandlerFunc {
 			return
 		}
 		if c.GetHeader("HX-Request") == "true" {
-			annotated, _ := h.AuthService.AnnotateInvitesWithUserDisabled([]models.Invitation{*inv})
-			row := InviteRow{Invitation: *inv, UserDisabled: len(annotated) == 1 && annotated[0].UserDisabled}
-			c.HTML(http.StatusOK, "admin_invite_row.html", row)
+			c.HTML(http.StatusOK, "admin_invite_row.html", h.inviteRow(*inv))
 			return
 		}
 		c.JSON(http.StatusOK, inv)
 	}
-}
\ No newline at end of file
+}
+// GET /admin/metrics reports whether click writes keep up with redirects and how well the alias cache hits
+func (h *AppHandler) AdminMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		metrics := gin.H{}
+		if h.ClickBuffer != nil {
+			metrics["click_buffer"] = h.ClickBuffer.Metrics()
+		}
+		if stats, ok := h.LinkService.AliasCacheStats(); ok {
+			metrics["alias_cache"] = stats
+		}
+		c.JSON(http.StatusOK, metrics)
+	}
+}
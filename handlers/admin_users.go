@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"quickr/models"
+	"quickr/services"
+)
+
+// UserRow is a view model for a row of the users page; Self marks the signed-in admin
+type UserRow struct {
+	models.User
+	Self bool
+}
+
+// GET /admin/users lists every account with its role, last login and disabled state
+func (h *AppHandler) AdminUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		users, err := h.UserService.ListUsers()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "failed to list users")
+			return
+		}
+		emailVal, _ := c.Get("userEmail")
+		rows := make([]UserRow, 0, len(users))
+		for _, u := range users { rows = append(rows, UserRow{User: u, Self: u.Email == emailVal}) }
+		c.HTML(http.StatusOK, "admin_users.html", gin.H{
+			"active":    "admin",
+			"section":   "users",
+			"users":     rows,
+			"userEmail": emailVal,
+			"isAdmin":   true,
+		})
+	}
+}
+
+// POST /admin/users/:id/role promotes (role=admin) or demotes (role=user) a user and returns the row
+func (h *AppHandler) SetUserRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			writeUserError(c, services.ErrUserNotFound, "user-error")
+			return
+		}
+		emailVal := c.GetString("userEmail")
+		u, err := h.UserService.SetRole(uint(id), c.PostForm("role"), emailVal)
+		if err != nil {
+			writeUserError(c, err, "user-error")
+			return
+		}
+		log.Printf("[ADMIN] %s set the role of %s to %s", emailVal, u.Email, u.Role)
+		if c.GetHeader("HX-Request") == "true" {
+			c.HTML(http.StatusOK, "admin_user_row.html", UserRow{User: *u, Self: u.Email == emailVal})
+			return
+		}
+		c.JSON(http.StatusOK, u)
+	}
+}
+
+// POST /admin/users/:id/logout ends every session of a user and returns the row
+func (h *AppHandler) SignOutUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			writeUserError(c, services.ErrUserNotFound, "user-error")
+			return
+		}
+		emailVal := c.GetString("userEmail")
+		u, err := h.UserService.SignOutEverywhere(uint(id), emailVal)
+		if err != nil {
+			writeUserError(c, err, "user-error")
+			return
+		}
+		log.Printf("[ADMIN] %s signed out %s everywhere", emailVal, u.Email)
+		if c.GetHeader("HX-Request") == "true" {
+			c.HTML(http.StatusOK, "admin_user_row.html", UserRow{User: *u, Self: u.Email == emailVal})
+			return
+		}
+		c.JSON(http.StatusOK, u)
+	}
+}
+
+// POST /admin/users/:id/disable blocks a user and returns the refreshed invitations table
+func (h *AppHandler) DisableUser() gin.HandlerFunc {
+	return h.changeUser("disabled", func(c *gin.Context, id uint, actor string) (any, error) {
+		return h.UserService.DisableUser(id, actor)
+	})
+}
+
+// POST /admin/users/:id/enable reinstates a disabled user and returns the refreshed invitations table
+func (h *AppHandler) EnableUser() gin.HandlerFunc {
+	return h.changeUser("enabled", func(c *gin.Context, id uint, actor string) (any, error) {
+		return h.UserService.EnableUser(id, actor)
+	})
+}
+
+// DELETE /admin/users/:id deletes a user and returns the refreshed invitations table. Their links go to
+// the user whose email is given as ?reassign_to= or answered to the button's HX-Prompt, or stay for admins.
+func (h *AppHandler) DeleteUser() gin.HandlerFunc {
+	return h.changeUser("deleted", func(c *gin.Context, id uint, actor string) (any, error) {
+		reassignTo := c.Query("reassign_to")
+		if reassignTo == "" {
+			reassignTo = c.GetHeader("HX-Prompt")
+		}
+		return gin.H{"deleted": true}, h.UserService.DeleteUser(id, reassignTo, actor)
+	})
+}
+
+// changeUser runs an account change from the invitations table on the user in the path
+func (h *AppHandler) changeUser(verb string, change func(c *gin.Context, id uint, actor string) (any, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			writeUserError(c, services.ErrUserNotFound, "invite-error")
+			return
+		}
+		actor := c.GetString("userEmail")
+		out, err := change(c, uint(id), actor)
+		if err != nil {
+			writeUserError(c, err, "invite-error")
+			return
+		}
+		log.Printf("[ADMIN] %s %s user id=%d", actor, verb, id)
+		if c.GetHeader("HX-Request") == "true" {
+			h.renderInvitesBody(c)
+			return
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// writeUserError answers a failed account change: JSON clients get the status, HTMX pages the
+// message in the element with id target, leaving the rest of the page as it was
+func writeUserError(c *gin.Context, err error, target string) {
+	status, message := http.StatusInternalServerError, "Failed to change the user"
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		status, message = http.StatusNotFound, "User not found"
+	case errors.Is(err, services.ErrInvalidRole):
+		status, message = http.StatusBadRequest, "Role must be admin or user"
+	case errors.Is(err, services.ErrLastAdmin):
+		status, message = http.StatusConflict, "At least one admin must remain; promote someone else first"
+	case errors.Is(err, services.ErrUserAlreadyDisabled):
+		status, message = http.StatusBadRequest, "User already revoked"
+	case errors.Is(err, services.ErrUserNotDisabled):
+		status, message = http.StatusBadRequest, "User is not disabled"
+	case errors.Is(err, services.ErrReassignToSelf):
+		status, message = http.StatusBadRequest, "Links cannot be reassigned to the user being deleted"
+	}
+	if status == http.StatusInternalServerError {
+		log.Printf("[ADMIN] user change failed: %v", err)
+	}
+	if c.GetHeader("HX-Request") == "true" {
+		c.Header("HX-Reswap", "none")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(`<div id="`+target+`" class="text-sm text-red-600 dark:text-red-400 mb-3" hx-swap-oob="true">`+message+`</div>`))
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
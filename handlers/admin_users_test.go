@@ -0,0 +1,193 @@
+package handlers
+
+import (
+    "html/template"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "quickr/models"
+    "quickr/repositories"
+    "quickr/services"
+)
+
+func TestAdminUsers_PromoteAndDemote(t *testing.T) {
+    a := newAuthClient(t)
+    for _, u := range []models.User{{Email: "root@example.com", Role: "admin"}, {Email: "bob@example.com", Role: "user"}} {
+        if err := a.db.Create(&u).Error; err != nil { t.Fatalf("seed: %v", err) }
+    }
+    a.h.UserService = services.NewUserService(repositories.NewGormUserRepository(a.db), repositories.NewGormInvitationRepository(a.db), repositories.NewGormUserEventRepository(a.db))
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    r.Use(func(c *gin.Context) { c.Set("userEmail", "root@example.com"); c.Set("userRole", "admin"); c.Next() })
+    r.GET("/admin/users", a.h.AdminUsers())
+    r.POST("/admin/users/:id/role", a.h.SetUserRole())
+    r.POST("/admin/users/:id/logout", a.h.SignOutUser())
+    setRole := func(id, role string, htmx bool) *httptest.ResponseRecorder {
+        req := httptest.NewRequest("POST", "/admin/users/"+id+"/role", strings.NewReader(url.Values{"role": {role}}.Encode()))
+        req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+        if htmx { req.Header.Set("HX-Request", "true") }
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        return w
+    }
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/users", nil))
+    if body := w.Body.String(); w.Code != http.StatusOK || !strings.Contains(body, "root@example.com <span") || !strings.Contains(body, "bob@example.com") || !strings.Contains(body, "never") {
+        t.Fatalf("expected both users listed, got %d %s", w.Code, body)
+    }
+
+    if w = setRole("1", "user", true); w.Header().Get("HX-Reswap") != "none" || !strings.Contains(w.Body.String(), "At least one admin must remain") {
+        t.Fatalf("expected the last admin to be kept, got %d %s", w.Code, w.Body.String())
+    }
+    if w = setRole("2", "admin", true); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `id="user-2"`) || !strings.Contains(w.Body.String(), "Demote") {
+        t.Fatalf("expected bob's row promoted, got %d %s", w.Code, w.Body.String())
+    }
+    if w = setRole("1", "user", false); w.Code != http.StatusOK { t.Fatalf("expected root to step down once bob is admin, got %d %s", w.Code, w.Body.String()) }
+    if w = setRole("2", "user", false); w.Code != http.StatusConflict { t.Fatalf("expected 409 demoting the last admin, got %d", w.Code) }
+    if w = setRole("2", "owner", false); w.Code != http.StatusBadRequest { t.Fatalf("expected 400 for an unknown role, got %d", w.Code) }
+    if w = setRole("9", "admin", false); w.Code != http.StatusNotFound { t.Fatalf("expected 404 for an unknown user, got %d", w.Code) }
+
+    w = httptest.NewRecorder()
+    req := httptest.NewRequest("POST", "/admin/users/2/logout", nil)
+    req.Header.Set("HX-Request", "true")
+    r.ServeHTTP(w, req)
+    var bob models.User
+    a.db.First(&bob, 2)
+    if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Sign out everywhere") || bob.SessionGeneration != 1 {
+        t.Fatalf("expected bob signed out everywhere, got %d %d %s", w.Code, bob.SessionGeneration, w.Body.String())
+    }
+
+    // ADMIN_EMAIL only seeds the first admin: root, now a user, gets no admin rights back from it
+    if w = a.login("root@example.com"); w.Code != http.StatusOK { t.Fatalf("expected root's account to get a link, got %d", w.Code) }
+    u, _ := url.Parse(a.mail.links["root@example.com"])
+    cookie := sessionCookie(a.get(u.RequestURI()))
+    if cookie == nil { t.Fatalf("expected the link to sign root in") }
+    if w = a.get("/admin", cookie); w.Code != http.StatusForbidden { t.Fatalf("expected root to sign in as a user, got %d", w.Code) }
+}
+
+func TestAdminUsers_Lifecycle(t *testing.T) {
+    a := newAuthClient(t)
+    if err := a.db.AutoMigrate(&models.Link{}, &models.LinkRevision{}, &models.LinkCoOwner{}, &models.AliasHistory{}, &models.APIToken{}); err != nil { t.Fatalf("migrate: %v", err) }
+    for _, u := range []models.User{{Email: "root@example.com", Role: "admin"}, {Email: "bob@example.com", Role: "user"}} {
+        if err := a.db.Create(&u).Error; err != nil { t.Fatalf("seed: %v", err) }
+    }
+    for _, email := range []string{"bob@example.com", "ghost@example.com"} {
+        if _, err := a.h.AuthService.CreateMagicLinkInvite(email, "http://quickr.test"); err != nil { t.Fatalf("invite: %v", err) }
+    }
+    a.h.UserService = services.NewUserService(repositories.NewGormUserRepository(a.db), repositories.NewGormInvitationRepository(a.db), repositories.NewGormUserEventRepository(a.db))
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    r.Use(func(c *gin.Context) { c.Set("userEmail", "root@example.com"); c.Set("userRole", "admin"); c.Next() })
+    r.GET("/admin", a.h.AdminDashboard())
+    r.POST("/admin/invitations/revoke-email", a.h.RevokeInvitationsByEmail())
+    r.POST("/admin/users/:id/disable", a.h.DisableUser())
+    r.POST("/admin/users/:id/enable", a.h.EnableUser())
+    r.DELETE("/admin/users/:id", a.h.DeleteUser())
+    htmx := func(method, path, form string, headers ...string) string {
+        req := httptest.NewRequest(method, path, strings.NewReader(form))
+        req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+        req.Header.Set("HX-Request", "true")
+        for i := 0; i+1 < len(headers); i += 2 { req.Header.Set(headers[i], headers[i+1]) }
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        if w.Code != http.StatusOK { t.Fatalf("%s %s: expected 200, got %d %s", method, path, w.Code, w.Body.String()) }
+        return w.Body.String()
+    }
+    signIn := func(email string) int {
+        delete(a.mail.links, email)
+        if w := a.login(email); w.Code != http.StatusOK { return w.Code }
+        u, _ := url.Parse(a.mail.links[email])
+        return a.get(u.RequestURI()).Code
+    }
+
+    // revoking an email without an account creates none
+    body := htmx("POST", "/admin/invitations/revoke-email", "email=ghost@example.com")
+    var n int64
+    a.db.Model(&models.User{}).Where("email = ?", "ghost@example.com").Count(&n)
+    if n != 0 || !strings.Contains(body, "User disabled by root@example.com") { t.Fatalf("expected an event row and no placeholder user, got %d users and %s", n, body) }
+
+    body = htmx("POST", "/admin/users/2/disable", "")
+    if !strings.Contains(body, "/admin/users/2/enable") { t.Fatalf("expected bob's invite row to offer Enable, got %s", body) }
+    if code := signIn("bob@example.com"); code != http.StatusUnauthorized { t.Fatalf("expected a disabled user not to sign in, got %d", code) }
+    if body = htmx("POST", "/admin/users/2/disable", ""); !strings.Contains(body, "User already revoked") { t.Fatalf("expected disabling twice to be refused, got %s", body) }
+
+    body = htmx("POST", "/admin/users/2/enable", "")
+    if !strings.Contains(body, "User enabled by root@example.com") { t.Fatalf("expected the enable in the history, got %s", body) }
+    if code := signIn("bob@example.com"); code != http.StatusFound { t.Fatalf("expected bob to sign in again, got %d", code) }
+
+    if body = htmx("POST", "/admin/users/1/disable", ""); !strings.Contains(body, "At least one admin must remain") { t.Fatalf("expected the last admin kept, got %s", body) }
+
+    body = htmx("DELETE", "/admin/users/2", "", "HX-Prompt", "root@example.com")
+    if !strings.Contains(body, "User deleted by root@example.com") || !strings.Contains(body, "links reassigned to root@example.com") { t.Fatalf("expected the delete in the history, got %s", body) }
+    if code := signIn("bob@example.com"); code != http.StatusForbidden { t.Fatalf("expected a deleted user's invitations revoked, got %d", code) }
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+    if strings.Index(w.Body.String(), "User deleted") > strings.Index(w.Body.String(), "User disabled by root@example.com on") { t.Fatalf("expected the history newest first, got %s", w.Body.String()) }
+}
+
+func TestSessions_FollowAccountChanges(t *testing.T) {
+    a := newAuthClient(t)
+    if err := a.db.AutoMigrate(&models.Link{}, &models.LinkRevision{}, &models.LinkCoOwner{}, &models.AliasHistory{}, &models.APIToken{}); err != nil { t.Fatalf("migrate: %v", err) }
+    for _, u := range []models.User{{Email: "root@example.com", Role: "admin"}, {Email: "bob@example.com", Role: "user"}} {
+        if err := a.db.Create(&u).Error; err != nil { t.Fatalf("seed: %v", err) }
+    }
+    users := services.NewUserService(repositories.NewGormUserRepository(a.db), repositories.NewGormInvitationRepository(a.db), repositories.NewGormUserEventRepository(a.db))
+    a.r.GET("/me", a.h.RequireAuth(), func(c *gin.Context) { c.String(http.StatusOK, c.GetString("userRole")) })
+    signIn := func() *http.Cookie {
+        delete(a.mail.links, "bob@example.com")
+        if w := a.login("bob@example.com"); w.Code != http.StatusOK { t.Fatalf("expected bob to get a link, got %d", w.Code) }
+        u, _ := url.Parse(a.mail.links["bob@example.com"])
+        cookie := sessionCookie(a.get(u.RequestURI()))
+        if cookie == nil { t.Fatalf("expected the link to sign bob in") }
+        return cookie
+    }
+
+    // role changes apply to the session bob already has
+    cookie := signIn()
+    if _, err := users.SetRole(2, "admin", "root@example.com"); err != nil { t.Fatalf("promote: %v", err) }
+    if w := a.get("/admin", cookie); w.Code != http.StatusOK { t.Fatalf("expected bob's session promoted, got %d", w.Code) }
+    if _, err := users.SetRole(2, "user", "root@example.com"); err != nil { t.Fatalf("demote: %v", err) }
+    if w := a.get("/admin", cookie); w.Code != http.StatusForbidden { t.Fatalf("expected bob's session demoted, got %d", w.Code) }
+
+    // signing out everywhere ends the old session but not the next one
+    if _, err := users.SignOutEverywhere(2, "root@example.com"); err != nil { t.Fatalf("sign out: %v", err) }
+    if w := a.get("/me", cookie); w.Code != http.StatusFound || w.Header().Get("Location") != "/login" { t.Fatalf("expected the old session sent to sign in, got %d", w.Code) }
+    cookie = signIn()
+    if w := a.get("/me", cookie); w.Code != http.StatusOK || w.Body.String() != "user" { t.Fatalf("expected a fresh session to work, got %d %s", w.Code, w.Body.String()) }
+
+    if _, err := users.DisableUser(2, "root@example.com"); err != nil { t.Fatalf("disable: %v", err) }
+    if w := a.get("/me", cookie); w.Code != http.StatusFound { t.Fatalf("expected a disabled user's session ended, got %d %s", w.Code, w.Body.String()) }
+    if _, err := users.EnableUser(2, "root@example.com"); err != nil { t.Fatalf("enable: %v", err) }
+    cookie = signIn()
+    if err := users.DeleteUser(2, "", "root@example.com"); err != nil { t.Fatalf("delete: %v", err) }
+    if w := a.get("/me", cookie); w.Code != http.StatusFound { t.Fatalf("expected a deleted user's session ended, got %d", w.Code) }
+}
+
+func TestAdminRoutes_RefuseAPITokens(t *testing.T) {
+    a := newAuthClient(t)
+    if err := a.db.AutoMigrate(&models.APIToken{}); err != nil { t.Fatalf("migrate: %v", err) }
+    for _, u := range []models.User{{Email: "root@example.com", Role: "admin"}, {Email: "bob@example.com", Role: "user"}} {
+        if err := a.db.Create(&u).Error; err != nil { t.Fatalf("seed: %v", err) }
+    }
+    a.h.UserService = services.NewUserService(repositories.NewGormUserRepository(a.db), repositories.NewGormInvitationRepository(a.db), repositories.NewGormUserEventRepository(a.db))
+    a.h.TokenService = services.NewTokenService(repositories.NewGormAPITokenRepository(a.db))
+    token, _, err := a.h.TokenService.CreateToken(1, "ci", []string{"read", "write"}, nil)
+    if err != nil { t.Fatalf("token: %v", err) }
+    admin := a.r.Group("/admin", a.h.RequireAuth(), a.h.RequireSession(), a.h.RequireAdmin())
+    admin.POST("/users/:id/role", a.h.SetUserRole())
+
+    req := httptest.NewRequest("POST", "/admin/users/2/role", strings.NewReader(url.Values{"role": {"admin"}}.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("Authorization", "Bearer "+token)
+    w := httptest.NewRecorder()
+    a.r.ServeHTTP(w, req)
+    var bob models.User
+    a.db.First(&bob, 2)
+    if w.Code != http.StatusForbidden || bob.Role != "user" { t.Fatalf("expected an admin's token refused, got %d with bob %s", w.Code, bob.Role) }
+}
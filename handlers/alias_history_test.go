@@ -0,0 +1,94 @@
+package handlers
+
+import (
+    "errors"
+    "html/template"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "quickr/models"
+    "quickr/services"
+)
+
+// handlerFakeAliasHistoryRepo forwards a fixed set of retired aliases.
+type handlerFakeAliasHistoryRepo struct{ entries []models.AliasHistory }
+
+func (f *handlerFakeAliasHistoryRepo) Create(entry *models.AliasHistory) error { f.entries = append(f.entries, *entry); return nil }
+func (f *handlerFakeAliasHistoryRepo) FindActive(alias string, now time.Time) (*models.AliasHistory, error) {
+    for i := range f.entries {
+        if f.entries[i].Alias == alias { return &f.entries[i], nil }
+    }
+    return nil, errors.New("not found")
+}
+func (f *handlerFakeAliasHistoryRepo) DeleteByAlias(alias string) error {
+    kept := f.entries[:0]
+    for _, e := range f.entries {
+        if e.Alias != alias { kept = append(kept, e) }
+    }
+    f.entries = kept
+    return nil
+}
+
+func TestCreateLink_RetiredAliasNeedsConfirmation(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    created := 0
+    repo := &fakeRepo{
+        FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 7, Alias: "daily"}, nil },
+        CreateFunc:   func(link *models.Link) error { created++; return nil },
+    }
+    history := &handlerFakeAliasHistoryRepo{entries: []models.AliasHistory{{LinkID: 7, Alias: "standup"}}}
+    h := &AppHandler{ LinkService: services.NewLinkService(repo, services.WithAliasHistory(history, 0)) }
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    r.POST("/api/links", func(c *gin.Context) { c.Set("userEmail", "alice@example.com") }, h.CreateLink())
+
+    post := func(form url.Values) *httptest.ResponseRecorder {
+        req := httptest.NewRequest("POST", "/api/links", strings.NewReader(form.Encode()))
+        req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+        req.Header.Set("HX-Request", "true")
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        return w
+    }
+
+    form := url.Values{"alias": {"standup"}, "url": {"https://example.com"}}
+    w := post(form)
+    if w.Code != http.StatusOK || w.Header().Get("HX-Retarget") != "#create-link-warning" || !strings.Contains(w.Body.String(), "daily") || created != 0 {
+        t.Fatalf("expected a warning naming the renamed link, got %d %v - %s", w.Code, w.Header(), w.Body.String())
+    }
+
+    form.Set("confirm_retired_alias", "1")
+    w2 := post(form)
+    if w2.Code != http.StatusCreated || created != 1 || len(history.entries) != 0 {
+        t.Fatalf("expected confirmed create to release the alias, got %d created=%d history=%+v", w2.Code, created, history.entries)
+    }
+
+    // JSON clients get a conflict unless they confirm
+    history.entries = []models.AliasHistory{{LinkID: 7, Alias: "standup"}}
+    req := httptest.NewRequest("POST", "/api/links", strings.NewReader(`{"alias":"standup","url":"https://example.com"}`))
+    req.Header.Set("Content-Type", "application/json")
+    w3 := httptest.NewRecorder()
+    r.ServeHTTP(w3, req)
+    if w3.Code != http.StatusConflict || !strings.Contains(w3.Body.String(), `"forwards_to":"daily"`) { t.Fatalf("expected 409, got %d - %s", w3.Code, w3.Body.String()) }
+}
+
+func TestHandleRedirect_RetiredAlias(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    // the alias no longer names a link; the renamed link is only found by ID
+    repo := &fakeRepo{ FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 7, Alias: "daily", URL: "https://meet.example.com/daily"}, nil } }
+    history := &handlerFakeAliasHistoryRepo{entries: []models.AliasHistory{{LinkID: 7, Alias: "standup"}}}
+    h := &AppHandler{ LinkService: services.NewLinkService(repo, services.WithAliasHistory(history, 0)) }
+    r := gin.New()
+    r.GET("/:alias", h.HandleRedirect())
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/standup", nil))
+    if w.Code != http.StatusFound || w.Header().Get("Location") != "https://meet.example.com/daily" {
+        t.Fatalf("expected retired alias to redirect, got %d %q", w.Code, w.Header().Get("Location"))
+    }
+}
@@ -3,24 +3,41 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	webview "quickr/interfaces/presenters/web"
 	"quickr/services"
 )
 
 type CreateLinkRequest struct {
-	Alias string `json:"alias" binding:"required"`
-	URL   string `json:"url" binding:"required"`
+	Alias       string     `json:"alias" binding:"required"`
+	URL         string     `json:"url" binding:"required"`
+	Passthrough bool       `json:"passthrough"`
+	QueryMerge  string     `json:"query_merge"`
+	ActiveFrom  *time.Time `json:"active_from"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	// ConfirmRetiredAlias takes over an alias that still forwards to a renamed link
+	ConfirmRetiredAlias bool `json:"confirm_retired_alias"`
 }
 
-type UpdateLinkRequest struct {
-	URL string `json:"url" binding:"required"`
+func (r CreateLinkRequest) options() services.LinkOptions {
+	o := services.LinkOptions{Passthrough: &r.Passthrough, QueryMerge: r.QueryMerge, TakeRetiredAlias: r.ConfirmRetiredAlias}
+	if r.ActiveFrom != nil || r.ExpiresAt != nil {
+		o.Schedule = &services.Schedule{ActiveFrom: r.ActiveFrom, ExpiresAt: r.ExpiresAt}
+	}
+	return o
 }
 
-// GET /api/links
+// GET /api/links (admins may filter with ?status=active|scheduled|expired)
 func (h *AppHandler) ListLinks() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		links, err := h.LinkService.ListLinks()
+		links, err := h.LinkService.ListLinksByStatus(statusFilter(c))
+		if errors.Is(err, services.ErrInvalidStatus) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch links"})
 			return
@@ -32,15 +49,7 @@ func (h *AppHandler) ListLinks() gin.HandlerFunc {
 // POST /api/links
 func (h *AppHandler) CreateLink() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get current user email from context
-		emailVal, _ := c.Get("userEmail")
-		creatorEmail, _ := emailVal.(string)
-		roleVal, _ := c.Get("userRole")
-		role, _ := roleVal.(string)
-		creatorDisplay := creatorEmail
-		if role == "admin" {
-			creatorDisplay = getAdminName()
-		}
+		creator := currentActor(c)
 
 		// Check if it's an HTMX request
 		if c.GetHeader("HX-Request") == "true" {
@@ -52,10 +61,25 @@ func (h *AppHandler) CreateLink() gin.HandlerFunc {
 				c.String(http.StatusBadRequest, "All fields are required")
 				return
 			}
+			opts, err := linkOptionsFromForm(c)
+			if err != nil {
+				c.String(http.StatusBadRequest, optionErrorMessage(err))
+				return
+			}
 
-			link, err := h.LinkService.CreateLink(alias, url, creatorDisplay)
+			opts.TakeRetiredAlias = c.PostForm("confirm_retired_alias") != ""
+			link, err := h.LinkService.CreateLinkWithOptions(alias, url, creator, opts)
+			if errors.Is(err, services.ErrAliasRetired) {
+				// Ask for confirmation inside the modal instead of closing it
+				c.Header("HX-Retarget", "#create-link-warning")
+				c.Header("HX-Reswap", "innerHTML")
+				c.HTML(http.StatusOK, "alias_retired_warning.html", gin.H{"alias": alias, "forwardsTo": h.retiredAliasTarget(alias)})
+				return
+			}
 			if err != nil {
 				switch {
+				case isOptionError(err):
+					c.String(http.StatusBadRequest, optionErrorMessage(err))
 				case errors.Is(err, services.ErrAliasReserved):
 					c.String(http.StatusBadRequest, "Alias is reserved")
 				case errors.Is(err, services.ErrInvalidURL):
@@ -79,9 +103,16 @@ func (h *AppHandler) CreateLink() gin.HandlerFunc {
 			return
 		}
 
-		link, err := h.LinkService.CreateLink(req.Alias, req.URL, creatorDisplay)
+		link, err := h.LinkService.CreateLinkWithOptions(req.Alias, req.URL, creator, req.options())
 		if err != nil {
 			switch {
+			case errors.Is(err, services.ErrAliasRetired):
+				c.JSON(http.StatusConflict, gin.H{
+					"error":       "Alias still forwards to /" + h.retiredAliasTarget(req.Alias) + "; resend with confirm_retired_alias to take it over",
+					"forwards_to": h.retiredAliasTarget(req.Alias),
+				})
+			case isOptionError(err):
+				c.JSON(http.StatusBadRequest, gin.H{"error": optionErrorMessage(err)})
 			case errors.Is(err, services.ErrAliasReserved):
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Alias is reserved"})
 			case errors.Is(err, services.ErrInvalidURL):
@@ -93,15 +124,23 @@ func (h *AppHandler) CreateLink() gin.HandlerFunc {
 			}
 			return
 		}
-
 		c.JSON(http.StatusCreated, link)
 	}
 }
 
-// GET /api/links/modal/create
+// retiredAliasTarget is the current alias of the link a retired alias forwards to.
+func (h *AppHandler) retiredAliasTarget(alias string) string {
+	link, err := h.LinkService.FindRetiredAlias(strings.TrimSpace(alias))
+	if err != nil {
+		return ""
+	}
+	return link.Alias
+}
+
+// GET /api/links/modal/create?alias=
 func (h *AppHandler) GetCreateLinkModal() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.HTML(http.StatusOK, "modal_create_link.html", nil)
+		c.HTML(http.StatusOK, "modal_create_link.html", gin.H{"alias": c.Query("alias")})
 	}
 }
 
@@ -114,6 +153,7 @@ func (h *AppHandler) GetLinkEditModal() gin.HandlerFunc {
 			c.String(http.StatusNotFound, "Link not found")
 			return
 		}
+		c.Header("ETag", linkETag(link))
 		c.HTML(http.StatusOK, "modal_edit_link.html", link)
 	}
 }
@@ -156,9 +196,10 @@ func (h *AppHandler) GetLinkEditField() gin.HandlerFunc {
 		}
 
 		c.HTML(http.StatusOK, "link_edit.html", gin.H{
-			"id":    link.ID,
-			"field": field,
-			"value": value,
+			"id":      link.ID,
+			"field":   field,
+			"value":   value,
+			"version": link.Version,
 		})
 	}
 }
@@ -168,21 +209,28 @@ func (h *AppHandler) UpdateLink() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
-		// Determine editor
-		emailVal, _ := c.Get("userEmail")
-		editorEmail, _ := emailVal.(string)
-		roleVal, _ := c.Get("userRole")
-		role, _ := roleVal.(string)
-		editorDisplay := editorEmail
-		if role == "admin" {
-			editorDisplay = getAdminName()
-		}
-
 		newAlias := c.PostForm("alias")
 		newURL := c.PostForm("url")
-		updated, err := h.LinkService.UpdateLink(id, newAlias, newURL, editorDisplay)
+		opts, err := linkOptionsFromForm(c)
+		if err != nil {
+			c.String(http.StatusBadRequest, optionErrorMessage(err))
+			return
+		}
+		version, ok := requestedVersion(c, c.PostForm("version"))
+		if !ok {
+			h.writeLinkConflict(c, id)
+			return
+		}
+		opts.IfVersion = version
+		updated, err := h.LinkService.EditLink(id, newAlias, newURL, opts, currentActor(c))
+		if errors.Is(err, services.ErrVersionConflict) {
+			h.writeLinkConflict(c, id)
+			return
+		}
 		if err != nil {
 			switch {
+			case isOptionError(err):
+				c.String(http.StatusBadRequest, optionErrorMessage(err))
 			case errors.Is(err, services.ErrAliasReserved):
 				c.String(http.StatusBadRequest, "Alias is reserved")
 			case errors.Is(err, services.ErrInvalidURL):
@@ -191,6 +239,8 @@ func (h *AppHandler) UpdateLink() gin.HandlerFunc {
 				c.String(http.StatusConflict, "Alias already exists")
 			case errors.Is(err, services.ErrLinkNotFound):
 				c.String(http.StatusNotFound, "Link not found")
+			case errors.Is(err, services.ErrForbidden):
+				c.String(http.StatusForbidden, forbiddenMessage)
 			default:
 				c.String(http.StatusInternalServerError, "Failed to update link")
 			}
@@ -224,25 +274,50 @@ func (h *AppHandler) UpdateLink() gin.HandlerFunc {
 	}
 }
 
+// forbiddenMessage explains a 403 on a link mutation
+const forbiddenMessage = "Only the link's owner, its co-owners or an admin can change this link"
+
+func isOptionError(err error) bool {
+	return errors.Is(err, services.ErrInvalidMerge) || errors.Is(err, services.ErrInvalidSchedule)
+}
+
+func optionErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrInvalidMerge):
+		return "Invalid query merge strategy"
+	case errors.Is(err, services.ErrInvalidSchedule):
+		return "Expiry must be after activation"
+	default:
+		return "Invalid date"
+	}
+}
+
+// statusFilter returns the ?status= filter, honoured for admins only.
+func statusFilter(c *gin.Context) string {
+	if role, _ := c.Get("userRole"); role != "admin" {
+		return ""
+	}
+	return c.Query("status")
+}
+
 // DELETE /api/links/:id
 func (h *AppHandler) DeleteLink() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		_, err := h.LinkService.DeleteLink(c.Param("id"))
+		_, err := h.LinkService.DeleteLink(c.Param("id"), currentActor(c))
 		if err != nil {
 			if errors.Is(err, services.ErrLinkNotFound) {
 				c.String(http.StatusNotFound, "Link not found")
+			} else if errors.Is(err, services.ErrForbidden) {
+				c.String(http.StatusForbidden, forbiddenMessage)
 			} else {
 				c.String(http.StatusInternalServerError, "Failed to delete link")
 			}
 			return
 		}
 
-		// If it's an HTMX request, return the updated list
+		// HTMX swaps the deleted row out with nothing
 		if c.GetHeader("HX-Request") == "true" {
-			links, _ := h.LinkService.ListLinks()
-			c.HTML(http.StatusOK, "link_rows.html", gin.H{
-				"links": links,
-			})
+			c.String(http.StatusOK, "")
 			return
 		}
 
@@ -250,28 +325,82 @@ func (h *AppHandler) DeleteLink() gin.HandlerFunc {
 	}
 }
 
-// GET /api/search
-func (h *AppHandler) SearchLinks() gin.HandlerFunc {
+// POST /api/links/:id/restore takes a link out of the trash
+func (h *AppHandler) RestoreLink() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		query := c.Query("q")
-		if query == "" {
-			ls, e := h.LinkService.ListLinks()
-			if e != nil {
-				c.Status(http.StatusInternalServerError)
-				return
+		link, err := h.LinkService.RestoreLink(c.Param("id"), currentActor(c))
+		if err != nil {
+			status, msg := http.StatusInternalServerError, "Failed to restore link"
+			switch {
+			case errors.Is(err, services.ErrLinkNotFound):
+				status, msg = http.StatusNotFound, "Link not found in trash"
+			case errors.Is(err, services.ErrAliasExists):
+				status, msg = http.StatusConflict, "Another link already uses this alias; rename or delete it first"
+			case errors.Is(err, services.ErrForbidden):
+				status, msg = http.StatusForbidden, forbiddenMessage
 			}
-			for i := range ls {
-				c.HTML(http.StatusOK, "link_row.html", ls[i])
+			if c.GetHeader("HX-Request") == "true" {
+				c.String(status, msg)
+			} else {
+				c.JSON(status, gin.H{"error": msg})
 			}
 			return
 		}
-		ls, e := h.LinkService.SearchLinks(query)
-		if e != nil {
-			c.Status(http.StatusInternalServerError)
+		// HTMX swaps the trash row out with nothing
+		if c.GetHeader("HX-Request") == "true" {
+			c.String(http.StatusOK, "")
 			return
 		}
-		for i := range ls {
-			c.HTML(http.StatusOK, "link_row.html", ls[i])
+		c.JSON(http.StatusOK, link)
+	}
+}
+
+// GET /api/search renders one page of table rows for the listing parameters
+// (see linkListOptions). The first page also updates the browser's URL so a
+// reload keeps the filters.
+func (h *AppHandler) SearchLinks() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filters := linkFilters(c)
+		opts, err := linkListOptions(c, filters)
+		if err != nil {
+			c.String(http.StatusBadRequest, listingErrorMessage(err))
+			return
+		}
+		page, err := h.LinkService.ListLinksPage(opts)
+		if err != nil {
+			if isListingError(err) {
+				c.String(http.StatusBadRequest, listingErrorMessage(err))
+			} else {
+				c.Status(http.StatusInternalServerError)
+			}
+			return
 		}
+		if opts.Cursor == "" {
+			home := "/"
+			if q := filters.Query(""); q != "" {
+				home += "?" + q
+			}
+			c.Header("HX-Replace-Url", home)
+		}
+		c.HTML(http.StatusOK, "link_rows.html", webview.LinkRowsView(page.Links, filters, opts.Cursor, page.NextCursor))
 	}
-}
\ No newline at end of file
+}
+
+func isListingError(err error) bool {
+	return errors.Is(err, services.ErrInvalidStatus) || errors.Is(err, services.ErrInvalidSort) || errors.Is(err, services.ErrInvalidCursor) ||
+		errors.Is(err, services.ErrInvalidDateRange) || errors.Is(err, errInvalidDate) || errors.Is(err, errInvalidLimit)
+}
+
+func listingErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrInvalidStatus):
+		return "Invalid status filter"
+	case errors.Is(err, services.ErrInvalidSort):
+		return "Sort by alias, clicks, created or updated"
+	case errors.Is(err, services.ErrInvalidCursor):
+		return "This page is out of date; reload the list"
+	case errors.Is(err, errInvalidLimit):
+		return "Limit must be a positive number"
+	}
+	return "Invalid date range"
+}
@@ -1,34 +1,18 @@
 package handlers
 
 import (
-    "errors"
+    "html/template"
     "net/http"
     "net/http/httptest"
     "strings"
     "testing"
+    "time"
 
     "github.com/gin-gonic/gin"
     "quickr/models"
     "quickr/services"
 )
 
-// in-memory fake repo for API handler tests
-type apiFakeRepo struct {
-    CreateFunc func(link *models.Link) error
-    ExistsByAliasFunc func(alias string) (bool, error)
-}
-
-func (f *apiFakeRepo) Create(link *models.Link) error { if f.CreateFunc == nil { return nil }; return f.CreateFunc(link) }
-func (f *apiFakeRepo) FindByAlias(alias string) (*models.Link, error) { return nil, errors.New("unused") }
-func (f *apiFakeRepo) FindByID(id string) (*models.Link, error) { return nil, errors.New("unused") }
-func (f *apiFakeRepo) ExistsByAlias(alias string) (bool, error) { if f.ExistsByAliasFunc == nil { return false, nil }; return f.ExistsByAliasFunc(alias) }
-func (f *apiFakeRepo) ExistsByAliasExceptID(alias string, id string) (bool, error) { return false, nil }
-func (f *apiFakeRepo) Delete(link *models.Link) error { return nil }
-func (f *apiFakeRepo) Save(link *models.Link) error { return nil }
-func (f *apiFakeRepo) ListAll() ([]models.Link, error) { return nil, nil }
-func (f *apiFakeRepo) Search(query string) ([]models.Link, error) { return nil, nil }
-func (f *apiFakeRepo) IncrementClicks(id uint) error { return nil }
-
 func setupRouter(h *AppHandler) *gin.Engine {
     gin.SetMode(gin.TestMode)
     r := gin.New()
@@ -37,7 +21,7 @@ func setupRouter(h *AppHandler) *gin.Engine {
 }
 
 func TestCreateLink_JSON_Success(t *testing.T) {
-    repo := &apiFakeRepo{ ExistsByAliasFunc: func(alias string) (bool, error) { return false, nil }, CreateFunc: func(link *models.Link) error { return nil } }
+    repo := &fakeRepo{ ExistsByAliasFunc: func(alias string) (bool, error) { return false, nil }, CreateFunc: func(link *models.Link) error { return nil } }
     svc := services.NewLinkService(repo)
     h := &AppHandler{ LinkService: svc }
     r := setupRouter(h)
@@ -52,7 +36,7 @@ func TestCreateLink_JSON_Success(t *testing.T) {
 
 func TestCreateLink_JSON_ValidationErrors(t *testing.T) {
     // invalid body
-    svc := services.NewLinkService(&apiFakeRepo{})
+    svc := services.NewLinkService(&fakeRepo{})
     h := &AppHandler{ LinkService: svc }
     r := setupRouter(h)
     req := httptest.NewRequest("POST", "/api/links", strings.NewReader("{"))
@@ -62,7 +46,7 @@ func TestCreateLink_JSON_ValidationErrors(t *testing.T) {
     if w.Code != http.StatusBadRequest { t.Fatalf("expected 400 for bad body, got %d", w.Code) }
 
     // alias exists
-    repo := &apiFakeRepo{ ExistsByAliasFunc: func(alias string) (bool, error) { return true, nil } }
+    repo := &fakeRepo{ ExistsByAliasFunc: func(alias string) (bool, error) { return true, nil } }
     svc2 := services.NewLinkService(repo)
     h2 := &AppHandler{ LinkService: svc2 }
     r2 := setupRouter(h2)
@@ -74,4 +58,44 @@ func TestCreateLink_JSON_ValidationErrors(t *testing.T) {
     if w2.Code != http.StatusConflict { t.Fatalf("expected 409 for alias exists, got %d", w2.Code) }
 }
 
+func TestListLinks_StatusFilterAdminOnly(t *testing.T) {
+    past := time.Now().Add(-time.Hour)
+    repo := &fakeRepo{ ListAllFunc: func() ([]models.Link, error) {
+        return []models.Link{{ID: 1, Alias: "live"}, {ID: 2, Alias: "gone", ExpiresAt: &past}}, nil
+    } }
+    h := &AppHandler{ LinkService: services.NewLinkService(repo) }
+    gin.SetMode(gin.TestMode)
+    newRouter := func(role string) *gin.Engine {
+        r := gin.New()
+        r.GET("/api/links", func(c *gin.Context) { c.Set("userRole", role) }, h.ListLinks())
+        return r
+    }
 
+    w := httptest.NewRecorder()
+    newRouter("admin").ServeHTTP(w, httptest.NewRequest("GET", "/api/links?status=expired", nil))
+    if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"gone"`) || strings.Contains(w.Body.String(), `"live"`) {
+        t.Fatalf("expected only expired link for admin, got %d - %s", w.Code, w.Body.String())
+    }
+    w2 := httptest.NewRecorder()
+    newRouter("user").ServeHTTP(w2, httptest.NewRequest("GET", "/api/links?status=expired", nil))
+    if !strings.Contains(w2.Body.String(), `"live"`) {
+        t.Fatalf("expected filter to be ignored for non-admins, got %s", w2.Body.String())
+    }
+    w3 := httptest.NewRecorder()
+    newRouter("admin").ServeHTTP(w3, httptest.NewRequest("GET", "/api/links?status=bogus", nil))
+    if w3.Code != http.StatusBadRequest { t.Fatalf("expected 400 for unknown status, got %d", w3.Code) }
+}
+
+func TestGetCreateLinkModal_PrefillsAlias(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    h := &AppHandler{ LinkService: services.NewLinkService(&fakeRepo{}) }
+    r.GET("/api/links/modal/create", h.GetCreateLinkModal())
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/api/links/modal/create?alias=vpn-setup", nil))
+    if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `value="vpn-setup"`) {
+        t.Fatalf("expected the alias to be pre-filled, got %d - %s", w.Code, w.Body.String())
+    }
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apiview "quickr/interfaces/presenters/api"
+	webview "quickr/interfaces/presenters/web"
+	"quickr/services"
+)
+
+// apiTokenKey holds the token a request authenticated with, if any
+const apiTokenKey = "apiToken"
+
+// bearerToken extracts the token of an "Authorization: Bearer" header.
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// authenticateToken signs the request in as the token's owner. Reads need
+// the read scope, anything else the write scope.
+func (h *AppHandler) authenticateToken(c *gin.Context, plain string) *authFailure {
+	if h.TokenService == nil {
+		return &authFailure{status: http.StatusUnauthorized, code: apiview.CodeUnauthenticated, message: "API tokens are not enabled"}
+	}
+	token, err := h.TokenService.Authenticate(plain)
+	if err != nil {
+		return &authFailure{status: http.StatusUnauthorized, code: apiview.CodeUnauthenticated, message: err.Error()}
+	}
+	scope := services.ScopeWrite
+	if m := c.Request.Method; m == http.MethodGet || m == http.MethodHead || m == http.MethodOptions {
+		scope = services.ScopeRead
+	}
+	if !services.HasScope(token, scope) {
+		return &authFailure{status: http.StatusForbidden, code: apiview.CodeInsufficientScope, message: "token lacks the " + scope + " scope"}
+	}
+	c.Set("userID", token.UserID)
+	c.Set("userEmail", token.User.Email)
+	c.Set("userRole", token.User.Role)
+	c.Set(apiTokenKey, token)
+	return nil
+}
+
+// requireSession refuses token-authenticated requests: a token must not mint or revoke tokens.
+func requireSession(c *gin.Context) bool {
+	if _, viaToken := c.Get(apiTokenKey); viaToken {
+		c.String(http.StatusForbidden, "API tokens can only be managed from the settings page")
+		return false
+	}
+	return true
+}
+
+// RequireSession refuses token-authenticated requests for a whole route
+// group: personal tokens carry the owner's role, so without it an admin's
+// token could manage users.
+func (h *AppHandler) RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, viaToken := c.Get(apiTokenKey); viaToken {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API tokens cannot be used for admin pages; sign in instead"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GET /settings lists the user's API tokens
+func (h *AppHandler) HandleSettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireSession(c) {
+			return
+		}
+		tokens, err := h.TokenService.ListTokens(c.GetUint("userID"))
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Service error")
+			return
+		}
+		emailVal, _ := c.Get("userEmail")
+		roleVal, _ := c.Get("userRole")
+		view := webview.SettingsView(tokens, time.Now(), emailVal.(string), roleVal == "admin")
+		if h.SessionService != nil {
+			sessions, err := h.SessionService.ListSessions(c.GetUint("userID"))
+			if err != nil {
+				c.String(http.StatusInternalServerError, "Service error")
+				return
+			}
+			view["sessions"] = webview.SessionRows(sessions, c.GetString(sessionIDKey))
+		}
+		c.HTML(http.StatusOK, "settings.html", view)
+	}
+}
+
+// POST /settings/tokens creates a token and shows it once
+func (h *AppHandler) CreateAPIToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireSession(c) {
+			return
+		}
+		var expiresAt *time.Time
+		if days, _ := strconv.Atoi(c.PostForm("expires_days")); days > 0 {
+			t := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+			expiresAt = &t
+		}
+		plain, _, err := h.TokenService.CreateToken(c.GetUint("userID"), c.PostForm("name"), c.PostFormArray("scopes"), expiresAt)
+		if err != nil {
+			if errors.Is(err, services.ErrTokenNameRequired) || errors.Is(err, services.ErrInvalidScope) || errors.Is(err, services.ErrInvalidExpiry) {
+				c.String(http.StatusBadRequest, err.Error())
+				return
+			}
+			c.String(http.StatusInternalServerError, "Failed to create token")
+			return
+		}
+		h.renderAPITokens(c, http.StatusCreated, plain)
+	}
+}
+
+// DELETE /settings/tokens/:id revokes a token
+func (h *AppHandler) RevokeAPIToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireSession(c) {
+			return
+		}
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err == nil {
+			err = h.TokenService.RevokeToken(c.GetUint("userID"), uint(id))
+		}
+		if err != nil {
+			c.String(http.StatusNotFound, services.ErrTokenNotFound.Error())
+			return
+		}
+		h.renderAPITokens(c, http.StatusOK, "")
+	}
+}
+
+func (h *AppHandler) renderAPITokens(c *gin.Context, status int, newToken string) {
+	tokens, err := h.TokenService.ListTokens(c.GetUint("userID"))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Service error")
+		return
+	}
+	view := webview.APITokensView(tokens, time.Now())
+	view["newToken"] = newToken
+	c.HTML(status, "api_tokens.html", view)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+    "html/template"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "regexp"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "quickr/models"
+    "quickr/repositories"
+    "quickr/services"
+)
+
+// handlerFakeTokenRepo keeps tokens in memory; every token belongs to user 4, alice.
+type handlerFakeTokenRepo struct{ tokens []models.APIToken }
+
+func (f *handlerFakeTokenRepo) Create(token *models.APIToken) error {
+    token.ID = uint(len(f.tokens) + 1)
+    f.tokens = append(f.tokens, *token)
+    return nil
+}
+func (f *handlerFakeTokenRepo) ListByUser(userID uint) ([]models.APIToken, error) { return f.tokens, nil }
+func (f *handlerFakeTokenRepo) FindByHash(hash string) (*models.APIToken, error) {
+    for _, t := range f.tokens {
+        if t.TokenHash == hash {
+            t.User = models.User{ID: 4, Email: "alice@example.com", Role: "user"}
+            return &t, nil
+        }
+    }
+    return nil, repositories.ErrNotFound
+}
+func (f *handlerFakeTokenRepo) Delete(userID, id uint) error               { return nil }
+func (f *handlerFakeTokenRepo) TouchLastUsed(id uint, at time.Time) error { return nil }
+
+func TestRequireAuth_BearerTokens(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    tokens := services.NewTokenService(&handlerFakeTokenRepo{})
+    readToken, _, _ := tokens.CreateToken(4, "read", []string{"read"}, nil)
+    writeOnly, _, _ := tokens.CreateToken(4, "write", []string{"write"}, nil)
+    both, _, _ := tokens.CreateToken(4, "both", []string{"read", "write"}, nil)
+
+    var seen string
+    repo := &fakeRepo{ ListAllFunc: func() ([]models.Link, error) { return []models.Link{{ID: 1, Alias: "vpn"}}, nil } }
+    h := &AppHandler{
+        LinkService:  services.NewLinkService(repo),
+        AuthService:  services.NewAuthService(handlerFakeUserRepo{}, nil, nil, "", nil),
+        Session:      &fakeSession{},
+        TokenService: tokens,
+    }
+    r := gin.New()
+    r.GET("/api/links", h.RequireAuth(), func(c *gin.Context) { seen = c.GetString("userEmail") }, h.ListLinks())
+    r.POST("/api/links", h.RequireAuth(), h.CreateLink())
+    r.POST("/settings/tokens", h.RequireAuth(), h.CreateAPIToken())
+
+    cases := []struct {
+        name, method, path, token string
+        want                      int
+    }{
+        {"read token lists links", "GET", "/api/links", readToken, http.StatusOK},
+        {"read token cannot write", "POST", "/api/links", readToken, http.StatusForbidden},
+        {"write-only token cannot read", "GET", "/api/links", writeOnly, http.StatusForbidden},
+        {"unknown token", "GET", "/api/links", "qk_unknown", http.StatusUnauthorized},
+        {"tokens cannot mint tokens", "POST", "/settings/tokens", both, http.StatusForbidden},
+    }
+    for _, tc := range cases {
+        req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"alias":"x","url":"https://example.com"}`))
+        req.Header.Set("Authorization", "Bearer "+tc.token)
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        if w.Code != tc.want { t.Errorf("%s: expected %d, got %d - %s", tc.name, tc.want, w.Code, w.Body.String()) }
+    }
+    if seen != "alice@example.com" { t.Fatalf("expected the request to run as the token's owner, got %q", seen) }
+}
+
+func TestAPITokenSettings(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    tokens := services.NewTokenService(&handlerFakeTokenRepo{})
+    h := &AppHandler{ TokenService: tokens }
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    r.Use(func(c *gin.Context) { c.Set("userEmail", "alice@example.com"); c.Set("userRole", "user"); c.Set("userID", uint(4)) })
+    r.GET("/settings", h.HandleSettings())
+    r.POST("/settings/tokens", h.CreateAPIToken())
+
+    form := url.Values{"name": {"quickr-jump"}, "scopes": {"read", "write"}, "expires_days": {"30"}}.Encode()
+    req := httptest.NewRequest("POST", "/settings/tokens", strings.NewReader(form))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    plain := regexp.MustCompile(`qk_[A-Za-z0-9_-]{40,}`).FindString(w.Body.String())
+    if w.Code != http.StatusCreated || plain == "" { t.Fatalf("expected the new token to be shown, got %d - %s", w.Code, w.Body.String()) }
+    if _, err := tokens.Authenticate(plain); err != nil { t.Fatalf("expected the shown token to work, got %v", err) }
+
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/settings", nil))
+    body := w.Body.String()
+    if w.Code != http.StatusOK || !strings.Contains(body, "quickr-jump") || !strings.Contains(body, "read,write") || strings.Contains(body, plain) {
+        t.Fatalf("expected the token listed without its secret, got %d - %s", w.Code, body)
+    }
+
+    req = httptest.NewRequest("POST", "/settings/tokens", strings.NewReader("name=ci"))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusBadRequest { t.Fatalf("expected 400 without scopes, got %d", w.Code) }
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"quickr/models"
+	apiview "quickr/interfaces/presenters/api"
+	"quickr/services"
+)
+
+// The /api/v1 handlers only ever speak JSON: bodies are read as JSON whatever
+// the headers say, and every failure is an apiview.ErrorResponse.
+
+// PatchLinkRequest changes only the fields it carries. Sending null for
+// active_from or expires_at clears that end of the schedule.
+type PatchLinkRequest struct {
+	Alias       *string      `json:"alias"`
+	URL         *string      `json:"url"`
+	Passthrough *bool        `json:"passthrough"`
+	QueryMerge  *string      `json:"query_merge"`
+	ActiveFrom  optionalTime `json:"active_from"`
+	ExpiresAt   optionalTime `json:"expires_at"`
+	// Version, like an If-Match header, makes the change fail with a 412 if
+	// the link was edited since that version
+	Version *uint `json:"version"`
+}
+
+// optionalTime tells a field sent as null apart from one left out.
+type optionalTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (t *optionalTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if bytes.Equal(data, []byte("null")) {
+		t.Time = nil
+		return nil
+	}
+	return json.Unmarshal(data, &t.Time)
+}
+
+// options turns the request into service options, keeping link's current
+// passthrough and schedule values for whatever was left out.
+func (r PatchLinkRequest) options(link *models.Link) services.LinkOptions {
+	var o services.LinkOptions
+	if r.Passthrough != nil || r.QueryMerge != nil {
+		enabled, merge := link.Passthrough, link.QueryMerge
+		if r.Passthrough != nil {
+			enabled = *r.Passthrough
+		}
+		if r.QueryMerge != nil {
+			merge = *r.QueryMerge
+		}
+		o.Passthrough, o.QueryMerge = &enabled, merge
+	}
+	if r.ActiveFrom.Set || r.ExpiresAt.Set {
+		sched := services.Schedule{ActiveFrom: link.ActiveFrom, ExpiresAt: link.ExpiresAt}
+		if r.ActiveFrom.Set {
+			sched.ActiveFrom = r.ActiveFrom.Time
+		}
+		if r.ExpiresAt.Set {
+			sched.ExpiresAt = r.ExpiresAt.Time
+		}
+		o.Schedule = &sched
+	}
+	return o
+}
+
+// GET /api/v1/links pages through links; see linkListOptions for the parameters
+func (h *AppHandler) ListLinksV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts, err := linkListOptions(c, linkFilters(c))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		page, err := h.LinkService.ListLinksPage(opts)
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		list := apiview.NewLinks(page.Links)
+		list.NextCursor = page.NextCursor
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+// GET /api/v1/links/:id
+func (h *AppHandler) GetLinkV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := h.LinkService.GetLinkByID(c.Param("id"))
+		if err != nil {
+			h.writeV1Error(c, services.ErrLinkNotFound)
+			return
+		}
+		writeV1Link(c, http.StatusOK, link)
+	}
+}
+
+// POST /api/v1/links
+func (h *AppHandler) CreateLinkV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Alias) == "" || strings.TrimSpace(req.URL) == "" {
+			abortV1(c, http.StatusBadRequest, apiview.CodeInvalidRequest, "Body must be a JSON object with alias and url")
+			return
+		}
+		link, err := h.LinkService.CreateLinkWithOptions(req.Alias, req.URL, currentActor(c), req.options())
+		if errors.Is(err, services.ErrAliasRetired) {
+			abortV1(c, http.StatusConflict, apiview.CodeAliasRetired, "Alias still forwards to /"+h.retiredAliasTarget(req.Alias)+"; resend with confirm_retired_alias to take it over")
+			return
+		}
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		writeV1Link(c, http.StatusCreated, link)
+	}
+}
+
+// PATCH /api/v1/links/:id
+func (h *AppHandler) UpdateLinkV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req PatchLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abortV1(c, http.StatusBadRequest, apiview.CodeInvalidRequest, "Body must be a JSON object")
+			return
+		}
+		if (req.Alias != nil && strings.TrimSpace(*req.Alias) == "") || (req.URL != nil && strings.TrimSpace(*req.URL) == "") {
+			abortV1(c, http.StatusBadRequest, apiview.CodeInvalidRequest, "alias and url cannot be empty")
+			return
+		}
+		link, err := h.LinkService.GetLinkByID(c.Param("id"))
+		if err != nil {
+			h.writeV1Error(c, services.ErrLinkNotFound)
+			return
+		}
+		var sent string
+		if req.Version != nil {
+			sent = strconv.FormatUint(uint64(*req.Version), 10)
+		}
+		version, ok := requestedVersion(c, sent)
+		if !ok || (version != 0 && version != link.Version) {
+			h.writeV1LinkConflict(c, c.Param("id"))
+			return
+		}
+		var alias, url string
+		if req.Alias != nil {
+			alias = strings.TrimSpace(*req.Alias)
+		}
+		if req.URL != nil {
+			url = strings.TrimSpace(*req.URL)
+		}
+		opts := req.options(link)
+		opts.IfVersion = version
+		updated, err := h.LinkService.EditLink(c.Param("id"), alias, url, opts, currentActor(c))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		writeV1Link(c, http.StatusOK, updated)
+	}
+}
+
+// DELETE /api/v1/links/:id moves the link to the trash
+func (h *AppHandler) DeleteLinkV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := h.LinkService.DeleteLink(c.Param("id"), currentActor(c)); err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// POST /api/v1/links/:id/restore
+func (h *AppHandler) RestoreLinkV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := h.LinkService.RestoreLink(c.Param("id"), currentActor(c))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		writeV1Link(c, http.StatusOK, link)
+	}
+}
+
+// GET /api/v1/links/:id/revisions
+func (h *AppHandler) ListLinkRevisionsV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revs, err := h.LinkService.ListRevisions(c.Param("id"))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiview.NewRevisions(v1Revisions(revs)))
+	}
+}
+
+// v1Revisions maps the link service's decoded history onto the /api/v1 shape.
+func v1Revisions(revs []services.Revision) []apiview.Revision {
+	items := make([]apiview.Revision, 0, len(revs))
+	for _, r := range revs {
+		item := apiview.Revision{
+			ID:        r.ID,
+			LinkID:    r.LinkID,
+			Action:    r.Action,
+			Actor:     r.Actor,
+			ActorID:   r.ActorID,
+			CreatedAt: r.CreatedAt,
+			Before:    v1Snapshot(r.Before),
+			After:     v1Snapshot(r.After),
+		}
+		for _, ch := range r.Changes {
+			item.Changes = append(item.Changes, apiview.FieldChange{Field: ch.Field, From: ch.From, To: ch.To})
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func v1Snapshot(s *services.LinkSnapshot) *apiview.LinkSnapshot {
+	if s == nil {
+		return nil
+	}
+	return &apiview.LinkSnapshot{
+		Alias:       s.Alias,
+		URL:         s.URL,
+		Passthrough: s.Passthrough,
+		QueryMerge:  s.QueryMerge,
+		ActiveFrom:  s.ActiveFrom,
+		ExpiresAt:   s.ExpiresAt,
+		Aliases:     s.Aliases,
+	}
+}
+
+// POST /api/v1/links/:id/revisions/:revisionID/restore
+func (h *AppHandler) RestoreLinkRevisionV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := h.LinkService.RestoreRevision(c.Param("id"), c.Param("revisionID"), currentActor(c))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		writeV1Link(c, http.StatusOK, link)
+	}
+}
+
+// GET /api/v1/links/:id/aliases
+func (h *AppHandler) ListLinkAliasesV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		aliases, err := h.LinkService.ListAliases(c.Param("id"))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiview.NewLinkAliases(aliases))
+	}
+}
+
+// POST /api/v1/links/:id/aliases
+func (h *AppHandler) AddLinkAliasV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req LinkAliasRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abortV1(c, http.StatusBadRequest, apiview.CodeInvalidRequest, "Body must be a JSON object with alias")
+			return
+		}
+		aliases, err := h.LinkService.AddAlias(c.Param("id"), req.Alias, currentActor(c))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, apiview.NewLinkAliases(aliases))
+	}
+}
+
+// DELETE /api/v1/links/:id/aliases/:alias
+func (h *AppHandler) RemoveLinkAliasV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		aliases, err := h.LinkService.RemoveAlias(c.Param("id"), c.Param("alias"), currentActor(c))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiview.NewLinkAliases(aliases))
+	}
+}
+
+// GET /api/v1/links/:id/co-owners
+func (h *AppHandler) ListCoOwnersV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		users, err := h.LinkService.ListCoOwners(c.Param("id"))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiview.NewUsers(users))
+	}
+}
+
+// POST /api/v1/links/:id/co-owners
+func (h *AppHandler) AddCoOwnerV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CoOwnerRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abortV1(c, http.StatusBadRequest, apiview.CodeInvalidRequest, "Body must be a JSON object with email")
+			return
+		}
+		users, err := h.LinkService.AddCoOwner(c.Param("id"), req.Email, currentActor(c))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, apiview.NewUsers(users))
+	}
+}
+
+// DELETE /api/v1/links/:id/co-owners/:userID
+func (h *AppHandler) RemoveCoOwnerV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseUint(c.Param("userID"), 10, 64)
+		if err != nil {
+			h.writeV1Error(c, services.ErrUserNotFound)
+			return
+		}
+		users, err := h.LinkService.RemoveCoOwner(c.Param("id"), uint(userID), currentActor(c))
+		if err != nil {
+			h.writeV1Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiview.NewUsers(users))
+	}
+}
+
+// NotFoundV1 answers unknown /api/v1 routes.
+func NotFoundV1() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		abortV1(c, http.StatusNotFound, apiview.CodeNotFound, "No such endpoint")
+	}
+}
+
+// writeV1Error answers with the status and code a service error maps to. A
+// version conflict on a link route gets the 412 with the current link that a
+// stale If-Match gets, whichever write lost the race.
+func (h *AppHandler) writeV1Error(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrVersionConflict) && c.Param("id") != "" {
+		h.writeV1LinkConflict(c, c.Param("id"))
+		return
+	}
+	status, code, msg := v1Error(err)
+	abortV1(c, status, code, msg)
+}
+
+func v1Error(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, services.ErrAliasExists):
+		return http.StatusConflict, apiview.CodeAliasExists, "Alias already exists"
+	case errors.Is(err, services.ErrAliasReserved):
+		return http.StatusBadRequest, apiview.CodeAliasReserved, "Alias is reserved"
+	case errors.Is(err, services.ErrAliasRetired):
+		return http.StatusConflict, apiview.CodeAliasRetired, "Alias still forwards to a renamed link"
+	case errors.Is(err, services.ErrInvalidURL):
+		return http.StatusBadRequest, apiview.CodeInvalidURL, "Invalid URL format"
+	case errors.Is(err, services.ErrInvalidMerge):
+		return http.StatusBadRequest, apiview.CodeInvalidQueryMerge, optionErrorMessage(err)
+	case errors.Is(err, services.ErrInvalidSchedule):
+		return http.StatusBadRequest, apiview.CodeInvalidSchedule, optionErrorMessage(err)
+	case errors.Is(err, services.ErrInvalidStatus):
+		return http.StatusBadRequest, apiview.CodeInvalidStatus, "Status must be active, scheduled or expired"
+	case errors.Is(err, services.ErrInvalidSort):
+		return http.StatusBadRequest, apiview.CodeInvalidSort, "Sort must be alias, clicks, created or updated and order asc or desc"
+	case errors.Is(err, services.ErrInvalidCursor):
+		return http.StatusBadRequest, apiview.CodeInvalidCursor, "Cursor is invalid or was issued for another sort order"
+	case errors.Is(err, services.ErrInvalidDateRange), errors.Is(err, errInvalidDate):
+		return http.StatusBadRequest, apiview.CodeInvalidDateRange, "Dates must be YYYY-MM-DD or RFC 3339, created_from before created_to"
+	case errors.Is(err, errInvalidLimit):
+		return http.StatusBadRequest, apiview.CodeInvalidRequest, "Limit must be a positive number"
+	case errors.Is(err, services.ErrLinkNotFound):
+		return http.StatusNotFound, apiview.CodeNotFound, "Link not found"
+	case errors.Is(err, services.ErrAliasNotFound):
+		return http.StatusNotFound, apiview.CodeNotFound, "This link has no such alias"
+	case errors.Is(err, services.ErrRevisionNotFound):
+		return http.StatusNotFound, apiview.CodeNotFound, "Revision not found"
+	case errors.Is(err, services.ErrUserNotFound):
+		return http.StatusBadRequest, apiview.CodeUserNotFound, "No user with that email"
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden, apiview.CodeForbidden, forbiddenMessage
+	case errors.Is(err, services.ErrVersionConflict):
+		return http.StatusPreconditionFailed, apiview.CodeVersionConflict, conflictMessage
+	}
+	return http.StatusInternalServerError, apiview.CodeInternal, "Internal error"
+}
+
+func abortV1(c *gin.Context, status int, code, msg string) {
+	c.AbortWithStatusJSON(status, apiview.ErrorResponse{Error: apiview.Error{Code: code, Message: msg}})
+}
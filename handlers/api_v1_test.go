@@ -0,0 +1,157 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "reflect"
+    "sort"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "quickr/models"
+    "quickr/repositories"
+    "quickr/services"
+)
+
+// v1Client drives /api/v1 against a real SQLite database, signed in as
+// whoever sess holds.
+type v1Client struct {
+    t    *testing.T
+    r    *gin.Engine
+    h    *AppHandler
+    sess *fakeSession
+    db   *gorm.DB
+}
+
+func newV1Client(t *testing.T) *v1Client {
+    gin.SetMode(gin.TestMode)
+    db, err := gorm.Open(sqlite.Open(repositories.SQLiteDSN(filepath.Join(t.TempDir(), "api.db"))), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.Link{}, &models.LinkRevision{}, &models.LinkCoOwner{}, &models.LinkAlias{}, &models.AliasHistory{}, &models.User{}); err != nil { t.Fatalf("migrate: %v", err) }
+    for _, email := range []string{"alice@example.com", "bob@example.com"} {
+        if err := db.Create(&models.User{Email: email, Role: "user"}).Error; err != nil { t.Fatalf("seed: %v", err) }
+    }
+    users := repositories.NewGormUserRepository(db)
+    links := services.NewLinkService(repositories.NewGormLinkRepository(db),
+        services.WithRevisions(repositories.NewGormLinkRevisionRepository(db)),
+        services.WithCoOwners(repositories.NewGormLinkCoOwnerRepository(db)),
+        services.WithUsers(users),
+        services.WithLinkAliases(repositories.NewGormLinkAliasRepository(db)),
+        services.WithAliasHistory(repositories.NewGormAliasHistoryRepository(db), 0),
+        services.WithTransactions(repositories.NewGormLinkTransactor(db)),
+    )
+    sess := &fakeSession{email: "alice@example.com"}
+    h := &AppHandler{LinkService: links, AuthService: services.NewAuthService(users, nil, nil, "", nil), Session: sess}
+    r := gin.New()
+    v1 := r.Group("/api/v1", h.RequireAPIAuth())
+    v1.GET("/links", h.ListLinksV1())
+    v1.POST("/links", h.CreateLinkV1())
+    v1.GET("/links/:id", h.GetLinkV1())
+    v1.PATCH("/links/:id", h.UpdateLinkV1())
+    v1.DELETE("/links/:id", h.DeleteLinkV1())
+    v1.POST("/links/:id/restore", h.RestoreLinkV1())
+    v1.GET("/links/:id/revisions", h.ListLinkRevisionsV1())
+    v1.POST("/links/:id/aliases", h.AddLinkAliasV1())
+    v1.POST("/links/:id/co-owners", h.AddCoOwnerV1())
+    return &v1Client{t: t, r: r, h: h, sess: sess, db: db}
+}
+
+// do sends body as JSON (whatever HTMX headers a browser might add) and decodes the reply.
+func (v *v1Client) do(method, path, body string) (int, map[string]any) {
+    v.t.Helper()
+    req := httptest.NewRequest(method, path, strings.NewReader(body))
+    req.Header.Set("HX-Request", "true")
+    w := httptest.NewRecorder()
+    v.r.ServeHTTP(w, req)
+    if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && w.Code != http.StatusNoContent {
+        v.t.Fatalf("%s %s: expected JSON, got %q - %s", method, path, w.Header().Get("Content-Type"), w.Body.String())
+    }
+    var out map[string]any
+    if w.Body.Len() > 0 {
+        if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil { v.t.Fatalf("%s %s: invalid JSON %q", method, path, w.Body.String()) }
+    }
+    return w.Code, out
+}
+
+func keys(m map[string]any) []string {
+    out := make([]string, 0, len(m))
+    for k := range m { out = append(out, k) }
+    sort.Strings(out)
+    return out
+}
+
+var v1LinkKeys = []string{"active_from", "alias", "aliases", "clicks", "created_at", "created_by", "expires_at", "id", "passthrough", "query_merge", "status", "updated_at", "url", "version"}
+
+func TestAPIv1_LinkLifecycle(t *testing.T) {
+    v := newV1Client(t)
+
+    status, link := v.do("POST", "/api/v1/links", `{"alias":"vpn","url":"https://vpn.example.com","passthrough":true}`)
+    if status != http.StatusCreated || !reflect.DeepEqual(keys(link), v1LinkKeys) {
+        t.Fatalf("expected a link with exactly %v, got %d %v", v1LinkKeys, status, link)
+    }
+    if link["created_by"] != "alice@example.com" || link["status"] != "active" || link["passthrough"] != true { t.Fatalf("unexpected link %v", link) }
+
+    status, link = v.do("PATCH", "/api/v1/links/1", `{"url":"https://wireguard.example.com","expires_at":"2099-01-01T00:00:00Z"}`)
+    if status != http.StatusOK || link["url"] != "https://wireguard.example.com" || link["alias"] != "vpn" || link["expires_at"] != "2099-01-01T00:00:00Z" || link["passthrough"] != true {
+        t.Fatalf("expected only url and expiry to change, got %d %v", status, link)
+    }
+    if status, link = v.do("PATCH", "/api/v1/links/1", `{"expires_at":null,"alias":"wg"}`); status != http.StatusOK || link["expires_at"] != nil || link["alias"] != "wg" {
+        t.Fatalf("expected a null expiry to clear it, got %d %v", status, link)
+    }
+
+    if status, body := v.do("POST", "/api/v1/links/1/aliases", `{"alias":"vpn-setup"}`); status != http.StatusCreated || len(body["items"].([]any)) != 1 { t.Fatalf("add alias: %d %v", status, body) }
+    if _, link = v.do("GET", "/api/v1/links/1", ""); !reflect.DeepEqual(link["aliases"], []any{"vpn-setup"}) { t.Fatalf("expected the alias listed, got %v", link) }
+    if status, body := v.do("GET", "/api/v1/links?q=wg", ""); status != http.StatusOK || len(body["items"].([]any)) != 1 { t.Fatalf("search: %d %v", status, body) }
+    _, body := v.do("GET", "/api/v1/links/1/revisions", "")
+    if len(body["items"].([]any)) != 4 { t.Fatalf("expected 4 revisions, got %v", body) }
+    if rev := body["items"].([]any)[0].(map[string]any); rev["action"] == nil || rev["after"].(map[string]any)["alias"] != "wg" || rev["changes"] == nil {
+        t.Fatalf("expected the latest revision with its snapshot and changes, got %v", rev)
+    }
+
+    if status, body := v.do("DELETE", "/api/v1/links/1", ""); status != http.StatusNoContent || body != nil { t.Fatalf("delete: %d %v", status, body) }
+    if _, body := v.do("GET", "/api/v1/links", ""); len(body["items"].([]any)) != 0 { t.Fatalf("expected an empty list, got %v", body) }
+    status, link = v.do("POST", "/api/v1/links/1/restore", "")
+    if status != http.StatusOK || link["alias"] != "wg" || link["deleted_at"] != nil { t.Fatalf("restore: %d %v", status, link) }
+}
+
+func TestAPIv1_ErrorEnvelope(t *testing.T) {
+    v := newV1Client(t)
+    v.do("POST", "/api/v1/links", `{"alias":"vpn","url":"https://vpn.example.com"}`)
+    v.do("POST", "/api/v1/links", `{"alias":"wiki","url":"https://wiki.example.com"}`)
+
+    cases := []struct {
+        name, method, path, body, as string
+        status                       int
+        code                         string
+    }{
+        {"duplicate alias", "POST", "/api/v1/links", `{"alias":"vpn","url":"https://x.example.com"}`, "", http.StatusConflict, "alias_exists"},
+        {"reserved alias", "POST", "/api/v1/links", `{"alias":"admin","url":"https://x.example.com"}`, "", http.StatusBadRequest, "alias_reserved"},
+        {"invalid url", "POST", "/api/v1/links", `{"alias":"x","url":"ftp:/nope"}`, "", http.StatusBadRequest, "invalid_url"},
+        {"missing field", "POST", "/api/v1/links", `{"alias":"x"}`, "", http.StatusBadRequest, "invalid_request"},
+        {"malformed body", "PATCH", "/api/v1/links/1", `url=https://x.example.com`, "", http.StatusBadRequest, "invalid_request"},
+        {"empty alias", "PATCH", "/api/v1/links/1", `{"alias":" "}`, "", http.StatusBadRequest, "invalid_request"},
+        {"renamed onto a used alias", "PATCH", "/api/v1/links/2", `{"alias":"vpn"}`, "", http.StatusConflict, "alias_exists"},
+        {"invalid query merge", "PATCH", "/api/v1/links/1", `{"passthrough":true,"query_merge":"both"}`, "", http.StatusBadRequest, "invalid_query_merge"},
+        {"invalid schedule", "PATCH", "/api/v1/links/1", `{"active_from":"2099-01-02T00:00:00Z","expires_at":"2099-01-01T00:00:00Z"}`, "", http.StatusBadRequest, "invalid_schedule"},
+        {"unknown co-owner", "POST", "/api/v1/links/1/co-owners", `{"email":"eve@example.com"}`, "", http.StatusBadRequest, "user_not_found"},
+        {"not the owner", "DELETE", "/api/v1/links/1", "", "bob@example.com", http.StatusForbidden, "forbidden"},
+        {"unknown link", "GET", "/api/v1/links/99", "", "", http.StatusNotFound, "not_found"},
+        {"not in the trash", "POST", "/api/v1/links/1/restore", "", "", http.StatusNotFound, "not_found"},
+        {"signed out", "GET", "/api/v1/links", "", "-", http.StatusUnauthorized, "unauthenticated"},
+    }
+    for _, tc := range cases {
+        v.sess.email = "alice@example.com"
+        if tc.as == "-" { v.sess.email = "" } else if tc.as != "" { v.sess.email = tc.as }
+        status, body := v.do(tc.method, tc.path, tc.body)
+        e, _ := body["error"].(map[string]any)
+        if status != tc.status || e == nil || e["code"] != tc.code || e["message"] == "" || len(body) != 1 || len(e) != 2 {
+            t.Errorf("%s: expected %d %s, got %d %v", tc.name, tc.status, tc.code, status, body)
+        }
+    }
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"quickr/interfaces/httpx"
+	apiview "quickr/interfaces/presenters/api"
+	"quickr/services"
 )
 
 // JWT cookie settings
@@ -39,40 +41,69 @@ func getAdminEmail() string {
 	return a
 }
 
-func getAdminName() string {
-	n := os.Getenv("ADMIN_NAME")
-	if n == "" {
-		log.Println("Config warning: missing env ADMIN_NAME; defaulting to 'Admin'")
-		return "Admin"
-	}
-	return n
-}
-
-// RequireAuth middleware validates the JWT session cookie and ensures the user is not disabled
+// RequireAuth middleware validates the JWT session cookie and ensures the user is not disabled.
+// Scripts may send a personal API token as "Authorization: Bearer <token>" instead.
 func (h *AppHandler) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		email, role, err := h.Session.Parse(c)
-		if err != nil || strings.TrimSpace(email) == "" {
-			accept := c.GetHeader("Accept")
-			if strings.Contains(accept, "text/html") || c.Request.Method == http.MethodGet {
-				c.Redirect(http.StatusFound, "/login")
-				c.Abort()
-				return
-			}
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		fail := h.authenticate(c)
+		if fail == nil {
+			c.Next()
+			return
+		}
+		accept := c.GetHeader("Accept")
+		if fail.signIn && (strings.Contains(accept, "text/html") || c.Request.Method == http.MethodGet) {
+			c.Redirect(http.StatusFound, "/login")
+			c.Abort()
 			return
 		}
-		if disabled, _ := h.AuthService.IsUserDisabled(email); disabled {
-			h.Session.Clear(c)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "account revoked"})
+		c.AbortWithStatusJSON(fail.status, gin.H{"error": fail.message})
+	}
+}
+
+// RequireAPIAuth is RequireAuth for /api/v1: failures are always answered
+// with the v1 error envelope, never with a redirect to the login page.
+func (h *AppHandler) RequireAPIAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if fail := h.authenticate(c); fail != nil {
+			abortV1(c, fail.status, fail.code, fail.message)
 			return
 		}
-		c.Set("userEmail", email)
-		c.Set("userRole", role)
 		c.Next()
 	}
 }
 
+// authFailure is why a request could not be signed in. signIn is set when no
+// credentials were sent at all, so a browser can be sent to the login page.
+type authFailure struct {
+	status  int
+	code    string
+	message string
+	signIn  bool
+}
+
+// authenticate signs the request in from a bearer token or the session
+// cookie, setting userID, userEmail and userRole.
+func (h *AppHandler) authenticate(c *gin.Context) *authFailure {
+	if token, ok := bearerToken(c); ok {
+		return h.authenticateToken(c, token)
+	}
+	claims, err := h.Session.Parse(c)
+	if err != nil || strings.TrimSpace(claims.Subject) == "" {
+		return &authFailure{status: http.StatusUnauthorized, code: apiview.CodeUnauthenticated, message: "authentication required", signIn: true}
+	}
+	// The role and disabled state come from the account, not the cookie, so admin changes apply at once
+	u, err := h.AuthService.SessionUser(claims.Subject, claims.Generation)
+	if err != nil {
+		h.Session.Clear(c)
+		return &authFailure{status: http.StatusUnauthorized, code: apiview.CodeUnauthenticated, message: err.Error(), signIn: !errors.Is(err, services.ErrAccountRevoked)}
+	}
+	c.Set("userID", u.ID)
+	c.Set("userEmail", u.Email)
+	c.Set("userRole", u.Role)
+	c.Set(sessionIDKey, claims.ID)
+	return nil
+}
+
 func (h *AppHandler) RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, _ := c.Get("userRole")
@@ -106,18 +137,18 @@ func (h *AppHandler) RequestMagicLink() gin.HandlerFunc {
 			return
 		}
 
-		// If admin email, ensure admin user and login immediately
-		if strings.EqualFold(email, getAdminEmail()) {
-			_ = h.AuthService.EnsureAdmin(email)
-			if err := h.Session.SignIn(c, email, "admin"); err != nil {
-				c.String(http.StatusInternalServerError, err.Error())
+		base := httpx.ResolveBaseURL(c.Request, h.AppBaseURL)
+		// The first admin needs no invitation but signs in through a mailed link like everyone else
+		if h.AuthService.SeedsFirstAdmin(email, getAdminEmail()) {
+			if err := h.AuthService.SendAdminMagicLink(email, base); err != nil {
+				log.Printf("[AUTH] sending the admin magic link failed: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "could not send magic link"})
 				return
 			}
-			c.Redirect(http.StatusFound, "/admin")
+			c.JSON(http.StatusOK, gin.H{"message": "Magic link sent if email is invited"})
 			return
 		}
 
-		base := httpx.ResolveBaseURL(c.Request, h.AppBaseURL)
 		if err := h.AuthService.RequireAndSendMagicLink(email, base); err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": "email not invited"})
 			return
@@ -134,23 +165,15 @@ func (h *AppHandler) RedeemMagicLink() gin.HandlerFunc {
 			c.String(http.StatusBadRequest, "missing token")
 			return
 		}
-		email, role, err := h.AuthService.RedeemMagicToken(tokenParam, func(e string) bool { return strings.EqualFold(e, getAdminEmail()) })
+		u, err := h.AuthService.RedeemMagicToken(tokenParam, func(e string) bool { return h.AuthService.SeedsFirstAdmin(e, getAdminEmail()) })
 		if err != nil {
 			c.String(http.StatusUnauthorized, err.Error())
 			return
 		}
-		if err := h.Session.SignIn(c, email, role); err != nil {
+		if err := h.Session.SignIn(c, u); err != nil {
 			c.String(http.StatusInternalServerError, err.Error())
 			return
 		}
 		c.Redirect(http.StatusFound, "/")
 	}
 }
-
-// POST /logout clears the cookie
-func Logout() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.SetCookie(cookieName, "", -1, "/", "", true, true)
-		c.Redirect(http.StatusFound, "/login")
-	}
-}
\ No newline at end of file
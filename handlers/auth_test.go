@@ -0,0 +1,123 @@
+package handlers
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "quickr/interfaces/session"
+    "quickr/models"
+    "quickr/repositories"
+    "quickr/services"
+)
+
+// outbox records the magic links that would have been mailed.
+type outbox struct{ links map[string]string }
+
+func (o *outbox) SendMagicLink(email, link string) error { o.links[email] = link; return nil }
+
+type allowAll struct{}
+
+func (allowAll) Allow(string) bool { return true }
+
+// authClient drives the login routes with a real session manager.
+type authClient struct {
+    r    *gin.Engine
+    h    *AppHandler
+    db   *gorm.DB
+    mail *outbox
+}
+
+func newAuthClient(t *testing.T) *authClient {
+    gin.SetMode(gin.TestMode)
+    t.Setenv("ADMIN_EMAIL", "root@example.com")
+    db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "auth.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Invitation{}, &models.UserEvent{}, &models.Session{}); err != nil { t.Fatalf("migrate: %v", err) }
+    mail := &outbox{links: map[string]string{}}
+    auth := services.NewAuthService(repositories.NewGormUserRepository(db), repositories.NewGormInvitationRepository(db), mail, "", nil)
+    sessions := services.NewSessionService(repositories.NewGormSessionRepository(db))
+    h := &AppHandler{AuthService: auth, RateLimiter: allowAll{}, AppBaseURL: "http://quickr.test", Session: session.NewManager([]byte("secret"), "session", time.Hour, sessions), SessionService: sessions}
+    r := gin.New()
+    r.POST("/login", h.RequestMagicLink())
+    r.GET("/magic", h.RedeemMagicLink())
+    r.GET("/admin", h.RequireAuth(), h.RequireAdmin(), func(c *gin.Context) { c.String(http.StatusOK, "admin") })
+    return &authClient{r: r, h: h, db: db, mail: mail}
+}
+
+func (a *authClient) login(email string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest("POST", "/login", strings.NewReader(url.Values{"email": {email}}.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    w := httptest.NewRecorder()
+    a.r.ServeHTTP(w, req)
+    return w
+}
+
+func (a *authClient) get(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+    req := httptest.NewRequest("GET", path, nil)
+    for _, c := range cookies { req.AddCookie(c) }
+    w := httptest.NewRecorder()
+    a.r.ServeHTTP(w, req)
+    return w
+}
+
+func sessionCookie(w *httptest.ResponseRecorder) *http.Cookie {
+    for _, c := range w.Result().Cookies() {
+        if c.Name == "session" && c.Value != "" { return c }
+    }
+    return nil
+}
+
+func TestRequestMagicLink_AdminEmailAloneIsNotASession(t *testing.T) {
+    a := newAuthClient(t)
+    for _, email := range []string{"root@example.com", " ROOT@example.com "} {
+        w := a.login(email)
+        if w.Code != http.StatusOK || sessionCookie(w) != nil || w.Header().Get("Location") != "" {
+            t.Fatalf("%q: expected only a mailed link, got %d %v %s", email, w.Code, w.Result().Cookies(), w.Body.String())
+        }
+    }
+    if w := a.get("/admin"); w.Code == http.StatusOK { t.Fatalf("expected /admin to stay closed without a session, got %d", w.Code) }
+    var admins int64
+    a.db.Model(&models.User{}).Where("role = ?", "admin").Count(&admins)
+    if admins != 0 { t.Fatalf("expected no admin account before a link is redeemed, got %d", admins) }
+
+    link := a.mail.links["root@example.com"]
+    if !strings.Contains(link, "/magic?token=") { t.Fatalf("expected a magic link mailed to the admin, got %q", link) }
+    u, _ := url.Parse(link)
+    w := a.get(u.RequestURI())
+    cookie := sessionCookie(w)
+    if w.Code != http.StatusFound || cookie == nil { t.Fatalf("expected the mailed link to sign the admin in, got %d", w.Code) }
+    if w := a.get("/admin", cookie); w.Code != http.StatusOK { t.Fatalf("expected an admin session, got %d %s", w.Code, w.Body.String()) }
+    if w := a.get(u.RequestURI()); w.Code != http.StatusUnauthorized { t.Fatalf("expected the link to work once, got %d", w.Code) }
+}
+
+func TestRequestMagicLink_UninvitedEmailIsRefused(t *testing.T) {
+    a := newAuthClient(t)
+    if w := a.login("mallory@example.com"); w.Code != http.StatusForbidden || sessionCookie(w) != nil { t.Fatalf("expected 403 without a session, got %d", w.Code) }
+    if len(a.mail.links) != 0 { t.Fatalf("expected nothing mailed, got %v", a.mail.links) }
+}
+
+func TestAdminSetupLink(t *testing.T) {
+    a := newAuthClient(t)
+    first, err := a.h.AuthService.AdminSetupLink("root@example.com", "http://quickr.test")
+    if err != nil || first == "" { t.Fatalf("expected a setup link while no admin exists, got %q %v", first, err) }
+    second, _ := a.h.AuthService.AdminSetupLink("root@example.com", "http://quickr.test")
+    if len(a.mail.links) != 0 { t.Fatalf("expected setup links not to be mailed, got %v", a.mail.links) }
+
+    u, _ := url.Parse(first)
+    if w := a.get(u.RequestURI()); w.Code != http.StatusUnauthorized { t.Fatalf("expected a restart to replace the earlier setup link, got %d", w.Code) }
+    u, _ = url.Parse(second)
+    cookie := sessionCookie(a.get(u.RequestURI()))
+    if w := a.get("/admin", cookie); w.Code != http.StatusOK { t.Fatalf("expected the setup link to sign in the admin, got %d", w.Code) }
+    if w := a.get(u.RequestURI()); w.Code != http.StatusUnauthorized { t.Fatalf("expected the setup link to work once, got %d", w.Code) }
+
+    if link, err := a.h.AuthService.AdminSetupLink("root@example.com", "http://quickr.test"); link != "" || err != nil { t.Fatalf("expected no setup link once an admin exists, got %q %v", link, err) }
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+    "html/template"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "quickr/models"
+    "quickr/repositories"
+    "quickr/services"
+)
+
+// handlerFakeClickRepo stores events and reports them all in one daily and hourly bucket.
+type handlerFakeClickRepo struct{ events []models.ClickEvent }
+
+func (f *handlerFakeClickRepo) Create(event *models.ClickEvent) error { f.events = append(f.events, *event); return nil }
+func (f *handlerFakeClickRepo) CountByDay(linkID uint, since time.Time) ([]repositories.ClickBucket, error) {
+    return []repositories.ClickBucket{{Bucket: time.Now().UTC().Format("2006-01-02"), Clicks: int64(len(f.events))}}, nil
+}
+func (f *handlerFakeClickRepo) CountByHour(linkID uint, since time.Time) ([]repositories.ClickBucket, error) {
+    return []repositories.ClickBucket{{Bucket: time.Now().UTC().Format("2006-01-02 15:00"), Clicks: int64(len(f.events))}}, nil
+}
+
+func (f *handlerFakeClickRepo) TopLinksSince(since time.Time, limit int) ([]repositories.LinkClicks, error) { return nil, nil }
+func (f *handlerFakeClickRepo) CountByLinkAndHour(since time.Time) ([]repositories.LinkClickBucket, error) { return nil, nil }
+
+func TestHandleRedirect_RecordsClickEvent(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) { return &models.Link{ID: 4, Alias: alias, URL: "https://example.com"}, nil } }
+    clicks := &handlerFakeClickRepo{}
+    svc := services.NewLinkService(repo)
+    h := &AppHandler{ LinkService: svc, StatsService: services.NewStatsService(svc, services.WithClickEvents(clicks, "salt")) }
+    r := gin.New()
+    r.GET("/:alias", h.HandleRedirect())
+
+    req := httptest.NewRequest("GET", "/vpn", nil)
+    req.Header.Set("User-Agent", "curl/8.4.0")
+    req.Header.Set("Referer", "https://wiki.example.com/page")
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusFound || len(clicks.events) != 1 { t.Fatalf("expected a redirect and one click event, got %d %+v", w.Code, clicks.events) }
+    if e := clicks.events[0]; e.LinkID != 4 || e.UAFamily != "curl" || e.ReferrerHost != "wiki.example.com" || e.IPHash == "" {
+        t.Fatalf("unexpected click event %+v", e)
+    }
+}
+
+func TestHandleLinkDetail(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    repo := &fakeRepo{ FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 4, Alias: "vpn", URL: "https://vpn.example.com", Clicks: 9}, nil } }
+    clicks := &handlerFakeClickRepo{events: make([]models.ClickEvent, 3)}
+    svc := services.NewLinkService(repo)
+    h := &AppHandler{ LinkService: svc, StatsService: services.NewStatsService(svc, services.WithClickEvents(clicks, "")) }
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    r.GET("/links/:id", func(c *gin.Context) { c.Set("userEmail", "alice@example.com") }, h.HandleLinkDetail())
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/links/4?days=7", nil))
+    body := w.Body.String()
+    if w.Code != http.StatusOK || !strings.Contains(body, "3 clicks in the last 7 days") || !strings.Contains(body, "3 clicks in the last 24 hours") {
+        t.Fatalf("unexpected detail page %d - %s", w.Code, body)
+    }
+    if n := strings.Count(body, "<rect "); n != 7+24 { t.Fatalf("expected one bar per day and hour, got %d", n) }
+
+    w2 := httptest.NewRecorder()
+    r.ServeHTTP(w2, httptest.NewRequest("GET", "/links/4?days=1000", nil))
+    if !strings.Contains(w2.Body.String(), "in the last 30 days") { t.Fatal("expected unknown ranges to fall back to 30 days") }
+}
+
+type handlerFakeClickBatchRepo struct{}
+
+func (handlerFakeClickBatchRepo) ApplyClicks(counts map[uint]int64, events []models.ClickEvent) error { return nil }
+
+func TestAdminMetrics_ReportsClickBuffer(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    buf := services.NewClickBuffer(handlerFakeClickBatchRepo{}, time.Hour, 10)
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) { return &models.Link{ID: 4, Alias: alias, URL: "https://example.com"}, nil } }
+    svc := services.NewLinkService(repo, services.WithBufferedClicks(buf))
+    h := &AppHandler{ LinkService: svc, StatsService: services.NewStatsService(svc, services.WithClickEvents(&handlerFakeClickRepo{}, ""), services.WithBufferedClickEvents(buf)), ClickBuffer: buf }
+    r := gin.New()
+    r.GET("/admin/metrics", h.AdminMetrics())
+    r.GET("/:alias", h.HandleRedirect())
+
+    for i := 0; i < 2; i++ { r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/vpn", nil)) }
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/metrics", nil))
+    body := w.Body.String()
+    if w.Code != http.StatusOK || !strings.Contains(body, `"pending_clicks":2`) || !strings.Contains(body, `"pending_events":2`) {
+        t.Fatalf("expected the queued redirects in the metrics, got %d - %s", w.Code, body)
+    }
+}
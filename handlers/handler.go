@@ -15,6 +15,16 @@ type AppHandler struct {
     RateLimiter RateLimiter
     AppBaseURL  string
     Session     session.Service
+    // TrashRetentionDays is shown on the trash page; 0 means deleted links are kept forever
+    TrashRetentionDays int
+    // ClickBuffer, when set, holds clicks waiting to be written; its metrics are served to admins
+    ClickBuffer *services.ClickBuffer
+    // TokenService, when set, lets requests authenticate with personal API tokens
+    TokenService *services.TokenService
+    // UserService backs the admin users page
+    UserService *services.UserService
+    // SessionService, when set, lists the user's devices on the settings page for them to sign out
+    SessionService *services.SessionService
 }
 
 func NewAppHandler(linkSvc *services.LinkService, authSvc *services.AuthService, statsSvc *services.StatsService, limiter RateLimiter, appBaseURL string, sess session.Service) *AppHandler {
@@ -29,8 +39,8 @@ func NewAppHandler(linkSvc *services.LinkService, authSvc *services.AuthService,
 }
 
 // Helper to construct default session when wiring without DI container
-func NewDefaultSession(jwtSecret []byte) session.Service {
-    return session.NewManager(jwtSecret, "session", 180*24*time.Hour)
+func NewDefaultSession(jwtSecret []byte, store session.Store) session.Service {
+    return session.NewManager(jwtSecret, "session", 180*24*time.Hour, store)
 }
 
 
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"quickr/models"
+	"quickr/services"
+)
+
+type LinkAliasRequest struct {
+	Alias string `json:"alias" binding:"required"`
+}
+
+// GET /api/links/:id/aliases
+func (h *AppHandler) ListLinkAliases() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		aliases, err := h.LinkService.ListAliases(c.Param("id"))
+		if err != nil {
+			h.linkAliasError(c, err)
+			return
+		}
+		h.renderLinkAliases(c, aliases)
+	}
+}
+
+// POST /api/links/:id/aliases
+func (h *AppHandler) AddLinkAlias() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alias := c.PostForm("alias")
+		if c.GetHeader("HX-Request") != "true" {
+			var req LinkAliasRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+				return
+			}
+			alias = req.Alias
+		}
+		aliases, err := h.LinkService.AddAlias(c.Param("id"), alias, currentActor(c))
+		if err != nil {
+			h.linkAliasError(c, err)
+			return
+		}
+		h.renderLinkAliases(c, aliases)
+	}
+}
+
+// DELETE /api/links/:id/aliases/:alias
+func (h *AppHandler) RemoveLinkAlias() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		aliases, err := h.LinkService.RemoveAlias(c.Param("id"), c.Param("alias"), currentActor(c))
+		if err != nil {
+			h.linkAliasError(c, err)
+			return
+		}
+		h.renderLinkAliases(c, aliases)
+	}
+}
+
+// renderLinkAliases answers with the edit modal's alias panel for HTMX and the list as JSON otherwise.
+func (h *AppHandler) renderLinkAliases(c *gin.Context, aliases []models.LinkAlias) {
+	if c.GetHeader("HX-Request") != "true" {
+		c.JSON(http.StatusOK, aliases)
+		return
+	}
+	link, err := h.LinkService.GetLinkByID(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusNotFound, "Link not found")
+		return
+	}
+	canEdit, _ := h.LinkService.CanEdit(link, currentActor(c))
+	c.HTML(http.StatusOK, "link_aliases.html", gin.H{
+		"link":    link,
+		"aliases": aliases,
+		"canEdit": canEdit,
+	})
+}
+
+func (h *AppHandler) linkAliasError(c *gin.Context, err error) {
+	status, msg := http.StatusInternalServerError, "Failed to update aliases"
+	switch {
+	case errors.Is(err, services.ErrLinkNotFound):
+		status, msg = http.StatusNotFound, "Link not found"
+	case errors.Is(err, services.ErrAliasNotFound):
+		status, msg = http.StatusNotFound, "This link has no such alias"
+	case errors.Is(err, services.ErrAliasReserved):
+		status, msg = http.StatusBadRequest, "Alias is reserved"
+	case errors.Is(err, services.ErrAliasExists):
+		status, msg = http.StatusConflict, "Alias already exists"
+	case errors.Is(err, services.ErrAliasRetired):
+		status, msg = http.StatusConflict, "Alias still forwards to a renamed link"
+	case errors.Is(err, services.ErrForbidden):
+		status, msg = http.StatusForbidden, forbiddenMessage
+	}
+	if c.GetHeader("HX-Request") == "true" {
+		c.String(status, msg)
+	} else {
+		c.JSON(status, gin.H{"error": msg})
+	}
+}
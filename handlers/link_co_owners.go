@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"quickr/models"
+	"quickr/services"
+)
+
+type CoOwnerRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// GET /api/links/:id/co-owners
+func (h *AppHandler) ListCoOwners() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		users, err := h.LinkService.ListCoOwners(c.Param("id"))
+		if err != nil {
+			h.coOwnerError(c, err)
+			return
+		}
+		h.renderCoOwners(c, users)
+	}
+}
+
+// POST /api/links/:id/co-owners
+func (h *AppHandler) AddCoOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email := c.PostForm("email")
+		if c.GetHeader("HX-Request") != "true" {
+			var req CoOwnerRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+				return
+			}
+			email = req.Email
+		}
+		users, err := h.LinkService.AddCoOwner(c.Param("id"), email, currentActor(c))
+		if err != nil {
+			h.coOwnerError(c, err)
+			return
+		}
+		h.renderCoOwners(c, users)
+	}
+}
+
+// DELETE /api/links/:id/co-owners/:userID
+func (h *AppHandler) RemoveCoOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.ParseUint(c.Param("userID"), 10, 64)
+		if err != nil {
+			h.coOwnerError(c, services.ErrUserNotFound)
+			return
+		}
+		users, err := h.LinkService.RemoveCoOwner(c.Param("id"), uint(userID), currentActor(c))
+		if err != nil {
+			h.coOwnerError(c, err)
+			return
+		}
+		h.renderCoOwners(c, users)
+	}
+}
+
+// renderCoOwners answers with the edit modal's co-owner panel for HTMX and the list as JSON otherwise.
+func (h *AppHandler) renderCoOwners(c *gin.Context, users []models.User) {
+	if c.GetHeader("HX-Request") != "true" {
+		c.JSON(http.StatusOK, users)
+		return
+	}
+	link, err := h.LinkService.GetLinkByID(c.Param("id"))
+	if err != nil {
+		c.String(http.StatusNotFound, "Link not found")
+		return
+	}
+	canEdit, _ := h.LinkService.CanEdit(link, currentActor(c))
+	c.HTML(http.StatusOK, "link_co_owners.html", gin.H{
+		"link":     link,
+		"coOwners": users,
+		"canEdit":  canEdit,
+	})
+}
+
+func (h *AppHandler) coOwnerError(c *gin.Context, err error) {
+	status, msg := http.StatusInternalServerError, "Failed to update co-owners"
+	switch {
+	case errors.Is(err, services.ErrLinkNotFound):
+		status, msg = http.StatusNotFound, "Link not found"
+	case errors.Is(err, services.ErrUserNotFound):
+		status, msg = http.StatusBadRequest, "No user with that email"
+	case errors.Is(err, services.ErrForbidden):
+		status, msg = http.StatusForbidden, forbiddenMessage
+	}
+	if c.GetHeader("HX-Request") == "true" {
+		c.String(status, msg)
+	} else {
+		c.JSON(status, gin.H{"error": msg})
+	}
+}
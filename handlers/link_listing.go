@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	webview "quickr/interfaces/presenters/web"
+	"quickr/services"
+)
+
+// date inputs post a day without a zone; it is read in server local time
+const formDateLayout = "2006-01-02"
+
+var errInvalidLimit = errors.New("invalid limit")
+
+// linkFilters reads the listing parameters shared by the home page, the table
+// rows and /api/v1/links. ?status= is honoured for admins only.
+func linkFilters(c *gin.Context) webview.LinkFilters {
+	return webview.LinkFilters{
+		Q:           strings.TrimSpace(c.Query("q")),
+		Creator:     strings.TrimSpace(c.Query("creator")),
+		CreatedFrom: strings.TrimSpace(c.Query("created_from")),
+		CreatedTo:   strings.TrimSpace(c.Query("created_to")),
+		Status:      statusFilter(c),
+		Sort:        c.Query("sort"),
+		Order:       c.Query("order"),
+	}
+}
+
+// linkListOptions turns the listing parameters into service options. Dates
+// are either a day, with created_to including that whole day, or an RFC 3339
+// instant, with created_to excluded.
+func linkListOptions(c *gin.Context, f webview.LinkFilters) (services.LinkListOptions, error) {
+	o := services.LinkListOptions{Search: f.Q, Creator: f.Creator, Status: f.Status, Sort: f.Sort, Order: f.Order, Cursor: c.Query("cursor")}
+	var err error
+	if o.CreatedFrom, err = parseListDate(f.CreatedFrom, false); err != nil {
+		return o, err
+	}
+	if o.CreatedTo, err = parseListDate(f.CreatedTo, true); err != nil {
+		return o, err
+	}
+	if v := c.Query("limit"); v != "" {
+		if o.Limit, err = strconv.Atoi(v); err != nil || o.Limit <= 0 {
+			return o, errInvalidLimit
+		}
+	}
+	return o, nil
+}
+
+func parseListDate(v string, endOfDay bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation(formDateLayout, v, time.Local)
+	if err != nil {
+		return nil, errInvalidDate
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+    "fmt"
+    "html/template"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+)
+
+func TestAPIv1_ListLinksPaging(t *testing.T) {
+    v := newV1Client(t)
+    for i := 1; i <= 5; i++ { v.do("POST", "/api/v1/links", fmt.Sprintf(`{"alias":"link-%d","url":"https://example.com"}`, i)) }
+
+    var aliases []string
+    path := "/api/v1/links?sort=alias&limit=2"
+    for pages := 1; ; pages++ {
+        status, body := v.do("GET", path, "")
+        if status != http.StatusOK { t.Fatalf("GET %s: %d %v", path, status, body) }
+        for _, item := range body["items"].([]any) { aliases = append(aliases, item.(map[string]any)["alias"].(string)) }
+        next, _ := body["next_cursor"].(string)
+        if next == "" { break }
+        if pages == 3 { t.Fatalf("expected three pages, still got a cursor: %v", body) }
+        path = "/api/v1/links?sort=alias&limit=2&cursor=" + url.QueryEscape(next)
+    }
+    if strings.Join(aliases, ",") != "link-1,link-2,link-3,link-4,link-5" { t.Fatalf("expected every link once in alias order, got %v", aliases) }
+
+    _, first := v.do("GET", "/api/v1/links?sort=alias&limit=2", "")
+    for name, tc := range map[string]struct{ query, code string }{
+        "cursor from another sort": {"sort=clicks&cursor=" + url.QueryEscape(first["next_cursor"].(string)), "invalid_cursor"},
+        "unknown sort":             {"sort=url", "invalid_sort"},
+        "backwards date range":     {"created_from=2024-05-02&created_to=2024-05-01", "invalid_date_range"},
+        "unparsable date":          {"created_from=yesterday", "invalid_date_range"},
+        "bad limit":                {"limit=-1", "invalid_request"},
+    } {
+        status, body := v.do("GET", "/api/v1/links?"+tc.query, "")
+        if code := body["error"].(map[string]any)["code"]; status != http.StatusBadRequest || code != tc.code { t.Errorf("%s: expected 400 %s, got %d %v", name, tc.code, status, body) }
+    }
+    if _, body := v.do("GET", "/api/v1/links?creator=bob@example.com", ""); len(body["items"].([]any)) != 0 { t.Fatalf("expected no links by bob, got %v", body) }
+}
+
+func TestSearchLinks_InfiniteScroll(t *testing.T) {
+    v := newV1Client(t)
+    for i := 1; i <= 3; i++ { v.do("POST", "/api/v1/links", fmt.Sprintf(`{"alias":"link-%d","url":"https://example.com"}`, i)) }
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    r.Use(func(c *gin.Context) { c.Set("userEmail", "alice@example.com"); c.Set("userRole", "user"); c.Set("userID", uint(1)); c.Next() })
+    r.GET("/api/search", v.h.SearchLinks())
+    r.DELETE("/api/links/:id", v.h.DeleteLink())
+    get := func(path string) *httptest.ResponseRecorder {
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+        return w
+    }
+
+    w := get("/api/search?sort=alias&limit=2")
+    body := w.Body.String()
+    if w.Code != http.StatusOK || !strings.Contains(body, `id="link-1"`) || !strings.Contains(body, `id="link-2"`) || strings.Contains(body, `id="link-3"`) {
+        t.Fatalf("expected the first two rows, got %d %s", w.Code, body)
+    }
+    if got := w.Header().Get("HX-Replace-Url"); got != "/?sort=alias" { t.Fatalf("expected the filters in the address bar, got %q", got) }
+    _, more, found := strings.Cut(body, `id="links-more" hx-get="`)
+    if !found { t.Fatalf("expected a sentinel row loading the next page, got %s", body) }
+    next, _, _ := strings.Cut(more, `"`)
+    next = strings.ReplaceAll(next, "&amp;", "&")
+
+    w = get(next)
+    body = w.Body.String()
+    if !strings.Contains(body, `id="link-3"`) || strings.Contains(body, `id="links-more"`) || w.Header().Get("HX-Replace-Url") != "" {
+        t.Fatalf("expected the last row without a sentinel, got %s", body)
+    }
+    if w = get("/api/search?q=nothing"); !strings.Contains(w.Body.String(), `id="links-empty"`) { t.Fatalf("expected the empty row, got %s", w.Body.String()) }
+    if w = get("/api/search?sort=url"); w.Code != http.StatusBadRequest { t.Fatalf("expected 400 for an unknown sort, got %d", w.Code) }
+
+    req := httptest.NewRequest("DELETE", "/api/links/2", nil)
+    req.Header.Set("HX-Request", "true")
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusOK || w.Body.Len() != 0 { t.Fatalf("expected an empty 200 so only the row is swapped out, got %d %q", w.Code, w.Body.String()) }
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"quickr/services"
+)
+
+// datetime-local inputs post minutes precision without a zone; they are read in server local time
+const formDateTimeLayout = "2006-01-02T15:04"
+
+var errInvalidDate = errors.New("invalid date")
+
+// linkOptionsFromForm reads the modal controls. Each group carries a hidden
+// marker field because unchecked boxes and empty dates are not posted, and
+// inline edits only ever post one field.
+func linkOptionsFromForm(c *gin.Context) (services.LinkOptions, error) {
+	var o services.LinkOptions
+	if c.PostForm("passthrough_form") != "" {
+		enabled := c.PostForm("passthrough") == "on"
+		o.Passthrough = &enabled
+		o.QueryMerge = c.PostForm("query_merge")
+	}
+	if c.PostForm("schedule_form") != "" {
+		var sched services.Schedule
+		var err error
+		if sched.ActiveFrom, err = parseFormTime(c.PostForm("active_from")); err != nil {
+			return o, err
+		}
+		if sched.ExpiresAt, err = parseFormTime(c.PostForm("expires_at")); err != nil {
+			return o, err
+		}
+		o.Schedule = &sched
+	}
+	return o, nil
+}
+
+func parseFormTime(v string) (*time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation(formDateTimeLayout, v, time.Local)
+	if err != nil {
+		return nil, errInvalidDate
+	}
+	return &t, nil
+}
+
+// currentActor is the signed-in user as recorded on links and in their
+// history, by email and user ID. ADMIN_NAME is only how admins are shown.
+func currentActor(c *gin.Context) services.Actor {
+	emailVal, _ := c.Get("userEmail")
+	email, _ := emailVal.(string)
+	a := services.Actor{Name: email}
+	if role, _ := c.Get("userRole"); role == "admin" {
+		a.Admin = true
+	}
+	if id, ok := c.Get("userID"); ok {
+		if uid, ok := id.(uint); ok {
+			a.UserID = &uid
+		}
+	}
+	return a
+}
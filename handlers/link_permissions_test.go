@@ -0,0 +1,145 @@
+package handlers
+
+import (
+    "html/template"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "quickr/models"
+    "quickr/services"
+)
+
+type handlerFakeCoOwnerRepo struct{}
+
+func (handlerFakeCoOwnerRepo) Add(linkID, userID uint) error                 { return nil }
+func (handlerFakeCoOwnerRepo) Remove(linkID, userID uint) error              { return nil }
+func (handlerFakeCoOwnerRepo) ListUsers(linkID uint) ([]models.User, error) { return []models.User{}, nil }
+func (handlerFakeCoOwnerRepo) IsCoOwner(linkID, userID uint) (bool, error) { return userID == 2, nil }
+
+type handlerFakeLinkAliasRepo struct{}
+
+func (handlerFakeLinkAliasRepo) Add(linkID uint, alias string) error    { return nil }
+func (handlerFakeLinkAliasRepo) Remove(linkID uint, alias string) error { return nil }
+func (handlerFakeLinkAliasRepo) ListByLinkID(linkID uint) ([]models.LinkAlias, error) {
+    return []models.LinkAlias{{ID: 1, LinkID: linkID, Alias: "wireguard"}}, nil
+}
+
+type handlerFakeUserRepo struct{}
+
+func (handlerFakeUserRepo) FindByEmail(email string) (*models.User, error) { return &models.User{ID: 4, Email: email}, nil }
+func (handlerFakeUserRepo) Save(user *models.User) error                   { return nil }
+func (handlerFakeUserRepo) Create(user *models.User) error                 { return nil }
+func (handlerFakeUserRepo) ListByEmails(emails []string) ([]models.User, error) { return nil, nil }
+func (handlerFakeUserRepo) HasAdmin() (bool, error) { return false, nil }
+func (handlerFakeUserRepo) FindByID(id uint) (*models.User, error) { return &models.User{ID: id}, nil }
+func (handlerFakeUserRepo) List() ([]models.User, error)          { return nil, nil }
+func (handlerFakeUserRepo) SetRole(id uint, role string) error     { return nil }
+func (handlerFakeUserRepo) SetDisabled(id uint, disabled bool) error { return nil }
+func (handlerFakeUserRepo) Delete(id uint, reassignTo *uint) error   { return nil }
+func (handlerFakeUserRepo) BumpSessionGeneration(id uint) error       { return nil }
+
+// Every link mutation must answer 403 to users who neither own nor co-own the
+// link, for HTMX and JSON callers alike, and succeed for owners, co-owners and admins.
+func TestLinkMutations_RequireOwnership(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    ownerID := uint(1)
+    revs := &handlerFakeRevisionRepo{revs: []models.LinkRevision{
+        {ID: 1, LinkID: 5, Action: "create", Actor: "owner", AfterState: `{"alias":"vpn","url":"https://vpn.example.com","query_merge":"target"}`},
+    }}
+    repo := &fakeRepo{ FindByIDFunc: func(id string) (*models.Link, error) {
+        return &models.Link{ID: 5, Alias: "vpn", URL: "https://vpn.example.com", CreatedBy: &ownerID, Aliases: []models.LinkAlias{{ID: 1, LinkID: 5, Alias: "wireguard"}}}, nil
+    } }
+    h := &AppHandler{ LinkService: services.NewLinkService(repo,
+        services.WithRevisions(revs), services.WithCoOwners(handlerFakeCoOwnerRepo{}), services.WithUsers(handlerFakeUserRepo{}),
+        services.WithLinkAliases(handlerFakeLinkAliasRepo{})) }
+
+    newRouter := func(userID uint, role string) *gin.Engine {
+        r := gin.New()
+        r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+        r.Use(func(c *gin.Context) { c.Set("userEmail", "someone@example.com"); c.Set("userRole", role); c.Set("userID", userID) })
+        r.PUT("/api/links/:id", h.UpdateLink())
+        r.DELETE("/api/links/:id", h.DeleteLink())
+        r.POST("/api/links/:id/revisions/:revisionID/restore", h.RestoreLinkRevision())
+        r.POST("/api/links/:id/co-owners", h.AddCoOwner())
+        r.DELETE("/api/links/:id/co-owners/:userID", h.RemoveCoOwner())
+        r.POST("/api/links/:id/aliases", h.AddLinkAlias())
+        r.DELETE("/api/links/:id/aliases/:alias", h.RemoveLinkAlias())
+        return r
+    }
+    form := url.Values{"url": {"https://new.example.com"}}.Encode()
+    requests := []struct {
+        name, method, path, body, contentType string
+        htmx                                  bool
+    }{
+        {"update (htmx)", "PUT", "/api/links/5", form, "application/x-www-form-urlencoded", true},
+        {"update", "PUT", "/api/links/5", form, "application/x-www-form-urlencoded", false},
+        {"delete (htmx)", "DELETE", "/api/links/5", "", "", true},
+        {"delete", "DELETE", "/api/links/5", "", "", false},
+        {"restore (htmx)", "POST", "/api/links/5/revisions/1/restore", "", "", true},
+        {"restore", "POST", "/api/links/5/revisions/1/restore", "", "", false},
+        {"add co-owner (htmx)", "POST", "/api/links/5/co-owners", "email=new%40example.com", "application/x-www-form-urlencoded", true},
+        {"add co-owner", "POST", "/api/links/5/co-owners", `{"email":"new@example.com"}`, "application/json", false},
+        {"remove co-owner (htmx)", "DELETE", "/api/links/5/co-owners/2", "", "", true},
+        {"remove co-owner", "DELETE", "/api/links/5/co-owners/2", "", "", false},
+        {"add alias (htmx)", "POST", "/api/links/5/aliases", "alias=vpn-setup", "application/x-www-form-urlencoded", true},
+        {"add alias", "POST", "/api/links/5/aliases", `{"alias":"vpn-setup"}`, "application/json", false},
+        {"remove alias (htmx)", "DELETE", "/api/links/5/aliases/wireguard", "", "", true},
+        {"remove alias", "DELETE", "/api/links/5/aliases/wireguard", "", "", false},
+    }
+    callers := []struct {
+        name   string
+        userID uint
+        role   string
+        want   int
+    }{
+        {"owner", 1, "user", http.StatusOK},
+        {"co-owner", 2, "user", http.StatusOK},
+        {"admin", 9, "admin", http.StatusOK},
+        {"stranger", 3, "user", http.StatusForbidden},
+    }
+    for _, caller := range callers {
+        r := newRouter(caller.userID, caller.role)
+        for _, tc := range requests {
+            req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+            if tc.contentType != "" { req.Header.Set("Content-Type", tc.contentType) }
+            if tc.htmx { req.Header.Set("HX-Request", "true") }
+            w := httptest.NewRecorder()
+            r.ServeHTTP(w, req)
+            if w.Code != caller.want {
+                t.Errorf("%s by %s: expected %d, got %d - %s", tc.name, caller.name, caller.want, w.Code, w.Body.String())
+            }
+            if caller.want == http.StatusForbidden && !strings.Contains(w.Body.String(), "owner") {
+                t.Errorf("%s by %s: expected an explanation, got %q", tc.name, caller.name, w.Body.String())
+            }
+        }
+    }
+}
+
+func TestRestoreLink_AliasConflict(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    repo := &fakeRepo{
+        FindDeletedByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 5, Alias: "vpn"}, nil },
+        ExistsByAliasFunc:   func(alias string) (bool, error) { return alias == "vpn", nil },
+    }
+    h := &AppHandler{ LinkService: services.NewLinkService(repo) }
+    r := gin.New()
+    r.Use(func(c *gin.Context) { c.Set("userRole", "admin") })
+    r.POST("/api/links/:id/restore", h.RestoreLink())
+
+    req := httptest.NewRequest("POST", "/api/links/5/restore", nil)
+    req.Header.Set("HX-Request", "true")
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "alias") {
+        t.Fatalf("expected 409 with explanation, got %d - %s", w.Code, w.Body.String())
+    }
+
+    repo.ExistsByAliasFunc = func(alias string) (bool, error) { return false, nil }
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("POST", "/api/links/5/restore", nil))
+    if w.Code != http.StatusOK { t.Fatalf("expected 200, got %d - %s", w.Code, w.Body.String()) }
+}
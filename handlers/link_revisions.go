@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"quickr/services"
+)
+
+// GET /api/links/:id/revisions
+func (h *AppHandler) ListLinkRevisions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		revs, err := h.LinkService.ListRevisions(id)
+		if err != nil {
+			if c.GetHeader("HX-Request") == "true" {
+				c.String(http.StatusInternalServerError, "Failed to load history")
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load history"})
+			}
+			return
+		}
+		if c.GetHeader("HX-Request") == "true" {
+			c.HTML(http.StatusOK, "link_revisions.html", gin.H{
+				"linkID":    id,
+				"revisions": revs,
+			})
+			return
+		}
+		c.JSON(http.StatusOK, revs)
+	}
+}
+
+// POST /api/links/:id/revisions/:revisionID/restore
+func (h *AppHandler) RestoreLinkRevision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := h.LinkService.RestoreRevision(c.Param("id"), c.Param("revisionID"), currentActor(c))
+		if err != nil {
+			status, msg := http.StatusInternalServerError, "Failed to restore revision"
+			switch {
+			case errors.Is(err, services.ErrRevisionNotFound):
+				status, msg = http.StatusNotFound, "Revision not found"
+			case errors.Is(err, services.ErrLinkNotFound):
+				status, msg = http.StatusNotFound, "Link not found"
+			case errors.Is(err, services.ErrForbidden):
+				status, msg = http.StatusForbidden, forbiddenMessage
+			case errors.Is(err, services.ErrAliasExists):
+				status, msg = http.StatusConflict, "Alias already exists"
+			case errors.Is(err, services.ErrAliasReserved):
+				status, msg = http.StatusBadRequest, "Alias is reserved"
+			case errors.Is(err, services.ErrInvalidURL):
+				status, msg = http.StatusBadRequest, "Invalid URL format"
+			case isOptionError(err):
+				status, msg = http.StatusBadRequest, optionErrorMessage(err)
+			}
+			if c.GetHeader("HX-Request") == "true" {
+				c.String(status, msg)
+			} else {
+				c.JSON(status, gin.H{"error": msg})
+			}
+			return
+		}
+		if c.GetHeader("HX-Request") == "true" {
+			c.HTML(http.StatusOK, "link_row.html", link)
+			return
+		}
+		c.JSON(http.StatusOK, link)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "quickr/models"
+    "quickr/services"
+)
+
+type handlerFakeRevisionRepo struct{ revs []models.LinkRevision }
+
+func (f *handlerFakeRevisionRepo) Create(rev *models.LinkRevision) error {
+    rev.ID = uint(len(f.revs) + 1)
+    f.revs = append(f.revs, *rev)
+    return nil
+}
+
+func (f *handlerFakeRevisionRepo) ListByLinkID(linkID string) ([]models.LinkRevision, error) {
+    var out []models.LinkRevision
+    for i := len(f.revs) - 1; i >= 0; i-- {
+        if fmt.Sprint(f.revs[i].LinkID) == linkID { out = append(out, f.revs[i]) }
+    }
+    return out, nil
+}
+
+func (f *handlerFakeRevisionRepo) FindByID(id string) (*models.LinkRevision, error) {
+    for i := range f.revs {
+        if fmt.Sprint(f.revs[i].ID) == id { return &f.revs[i], nil }
+    }
+    return nil, errors.New("not found")
+}
+
+func TestLinkRevisions_ListAndRestore(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    carolID := uint(3)
+    current := &models.Link{ID: 5, Alias: "oncall", URL: "https://wrong.example.com", CreatedBy: &carolID}
+    revs := &handlerFakeRevisionRepo{revs: []models.LinkRevision{
+        {ID: 1, LinkID: 5, Action: "create", Actor: "alice", AfterState: `{"alias":"oncall","url":"https://wiki.example.com/oncall","query_merge":"target"}`},
+        {ID: 2, LinkID: 5, Action: "update", Actor: "bob", BeforeState: `{"alias":"oncall","url":"https://wiki.example.com/oncall","query_merge":"target"}`, AfterState: `{"alias":"oncall","url":"https://wrong.example.com","query_merge":"target"}`},
+    }}
+    repo := &fakeRepo{ FindByIDFunc: func(id string) (*models.Link, error) { cp := *current; return &cp, nil } }
+    h := &AppHandler{ LinkService: services.NewLinkService(repo, services.WithRevisions(revs)) }
+    r := gin.New()
+    r.Use(func(c *gin.Context) { c.Set("userEmail", "carol@example.com"); c.Set("userRole", "user"); c.Set("userID", carolID) })
+    r.GET("/api/links/:id/revisions", h.ListLinkRevisions())
+    r.POST("/api/links/:id/revisions/:revisionID/restore", h.RestoreLinkRevision())
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/api/links/5/revisions", nil))
+    var history []services.Revision
+    if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &history) != nil || len(history) != 2 || history[0].Actor != "bob" {
+        t.Fatalf("unexpected history %d: %s", w.Code, w.Body.String())
+    }
+
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("POST", "/api/links/5/revisions/1/restore", nil))
+    var restored models.Link
+    if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &restored) != nil || restored.URL != "https://wiki.example.com/oncall" {
+        t.Fatalf("unexpected restore %d: %s", w.Code, w.Body.String())
+    }
+    if last := revs.revs[len(revs.revs)-1]; last.Action != "restore" || last.Actor != "carol@example.com" {
+        t.Fatalf("expected restore revision by carol, got %+v", last)
+    }
+
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("POST", "/api/links/6/revisions/1/restore", nil))
+    if w.Code != http.StatusNotFound { t.Fatalf("expected 404 for a revision of another link, got %d", w.Code) }
+}
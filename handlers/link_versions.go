@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	apiview "quickr/interfaces/presenters/api"
+	webview "quickr/interfaces/presenters/web"
+	"quickr/models"
+)
+
+// linkETag identifies what an editor has seen of a link. It follows the
+// link's Version, so it changes on every edit but not on every click.
+func linkETag(link *models.Link) string {
+	return `"` + strconv.FormatUint(uint64(link.Version), 10) + `"`
+}
+
+// requestedVersion is the version an edit was based on, read from If-Match or
+// else from the version the form or body carried. 0 means the edit is
+// unconditional. ok is false when If-Match names something that can never
+// match, which is itself a failed precondition.
+func requestedVersion(c *gin.Context, fallback string) (version uint, ok bool) {
+	tag := strings.TrimSpace(c.GetHeader("If-Match"))
+	if tag == "" {
+		tag = strings.TrimSpace(fallback)
+	} else if tag == "*" {
+		return 0, true
+	} else {
+		tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+	}
+	if tag == "" {
+		return 0, true
+	}
+	v, err := strconv.ParseUint(tag, 10, 32)
+	if err != nil || v == 0 {
+		return 0, false
+	}
+	return uint(v), true
+}
+
+// writeLinkConflict answers an edit that lost the race with the link as it
+// is now: the conflict view for HTMX, a 412 with the current link otherwise.
+func (h *AppHandler) writeLinkConflict(c *gin.Context, id string) {
+	current, err := h.LinkService.GetLinkByID(id)
+	if err != nil {
+		c.String(http.StatusNotFound, "Link not found")
+		return
+	}
+	c.Header("ETag", linkETag(current))
+	if c.GetHeader("HX-Request") == "true" {
+		// Shown in place of the edit modal; a 412 would not be swapped in
+		c.Header("HX-Retarget", "#modal-root")
+		c.Header("HX-Reswap", "innerHTML")
+		c.HTML(http.StatusOK, "modal_link_conflict.html", webview.LinkConflictView(current, c.Request.PostForm))
+		return
+	}
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": conflictMessage, "link": current})
+}
+
+// writeV1LinkConflict is the /api/v1 412: the usual error envelope plus the
+// current link, so the client can merge and retry with its ETag.
+func (h *AppHandler) writeV1LinkConflict(c *gin.Context, id string) {
+	current, err := h.LinkService.GetLinkByID(id)
+	if err != nil {
+		abortV1(c, http.StatusNotFound, apiview.CodeNotFound, "Link not found")
+		return
+	}
+	c.Header("ETag", linkETag(current))
+	c.AbortWithStatusJSON(http.StatusPreconditionFailed, apiview.ConflictResponse{
+		Error:   apiview.Error{Code: apiview.CodeVersionConflict, Message: conflictMessage},
+		Current: apiview.NewLink(*current),
+	})
+}
+
+// writeV1Link sends a single link with its ETag.
+func writeV1Link(c *gin.Context, status int, link *models.Link) {
+	c.Header("ETag", linkETag(link))
+	c.JSON(status, apiview.NewLink(*link))
+}
+
+const conflictMessage = "Someone else changed this link since you loaded it"
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+    "encoding/json"
+    "fmt"
+    "html/template"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// send is v1Client.do with extra request headers, returning the recorder.
+func (v *v1Client) send(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(method, path, strings.NewReader(body))
+    for k, val := range headers { req.Header.Set(k, val) }
+    w := httptest.NewRecorder()
+    v.r.ServeHTTP(w, req)
+    return w
+}
+
+func TestAPIv1_ETagAndIfMatch(t *testing.T) {
+    v := newV1Client(t)
+    v.do("POST", "/api/v1/links", `{"alias":"vpn","url":"https://vpn.example.com"}`)
+
+    w := v.send("GET", "/api/v1/links/1", "", nil)
+    if w.Header().Get("ETag") != `"1"` { t.Fatalf("expected ETag \"1\", got %q", w.Header().Get("ETag")) }
+    w = v.send("PATCH", "/api/v1/links/1", `{"url":"https://mine.example.com"}`, map[string]string{"If-Match": `"1"`})
+    if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` { t.Fatalf("expected a matching If-Match to save as version 2, got %d %q", w.Code, w.Header().Get("ETag")) }
+
+    for name, tc := range map[string]struct {
+        body    string
+        ifMatch string
+    }{
+        "stale If-Match":     {`{"url":"https://stale.example.com"}`, `"1"`},
+        "weak stale ETag":    {`{"url":"https://stale.example.com"}`, `W/"1"`},
+        "unknown ETag":       {`{"url":"https://stale.example.com"}`, `"abc"`},
+        "stale body version": {`{"url":"https://stale.example.com","version":1}`, ""},
+    } {
+        w = v.send("PATCH", "/api/v1/links/1", tc.body, map[string]string{"If-Match": tc.ifMatch})
+        var body struct {
+            Error   struct{ Code string }
+            Current map[string]any
+        }
+        json.Unmarshal(w.Body.Bytes(), &body)
+        if w.Code != http.StatusPreconditionFailed || body.Error.Code != "version_conflict" || body.Current["url"] != "https://mine.example.com" || w.Header().Get("ETag") != `"2"` {
+            t.Errorf("%s: expected 412 with the current link, got %d %s", name, w.Code, w.Body.String())
+        }
+    }
+
+    if w = v.send("PATCH", "/api/v1/links/1", `{"url":"https://any.example.com"}`, map[string]string{"If-Match": "*"}); w.Code != http.StatusOK { t.Fatalf("expected If-Match * to save, got %d", w.Code) }
+    if status, link := v.do("PATCH", "/api/v1/links/1", `{"url":"https://blind.example.com"}`); status != http.StatusOK || link["version"] != float64(4) { t.Fatalf("expected an unconditional edit to save, got %d %v", status, link) }
+}
+
+func TestAPIv1_VersionConflictOnLinkRoutes(t *testing.T) {
+    v := newV1Client(t)
+    v.do("POST", "/api/v1/links", `{"alias":"vpn","url":"https://vpn.example.com"}`)
+    // each of these writes loses the version check between its read and its save
+    race := false
+    v.db.Callback().Update().Before("gorm:update").Register("test:race", func(tx *gorm.DB) {
+        if !race || tx.Statement.Table != "links" { return }
+        race = false
+        tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "version < 0"}}})
+    })
+
+    for name, req := range map[string][2]string{
+        "add alias": {"/api/v1/links/1/aliases", `{"alias":"wg"}`},
+        "edit":      {"/api/v1/links/1", `{"url":"https://mine.example.com"}`},
+    } {
+        race = true
+        method := "POST"
+        if name == "edit" { method = "PATCH" }
+        w := v.send(method, req[0], req[1], nil)
+        var body struct {
+            Error   struct{ Code string }
+            Current map[string]any
+        }
+        json.Unmarshal(w.Body.Bytes(), &body)
+        if w.Code != http.StatusPreconditionFailed || body.Error.Code != "version_conflict" || body.Current["url"] != "https://vpn.example.com" || w.Header().Get("ETag") != `"1"` {
+            t.Errorf("%s: expected 412 with the current link, got %d %s", name, w.Code, w.Body.String())
+        }
+    }
+}
+
+func TestUpdateLink_VersionConflict(t *testing.T) {
+    v := newV1Client(t)
+    v.do("POST", "/api/v1/links", `{"alias":"vpn","url":"https://vpn.example.com"}`)
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    r.Use(func(c *gin.Context) { c.Set("userEmail", "alice@example.com"); c.Set("userRole", "user"); c.Set("userID", uint(1)); c.Next() })
+    r.GET("/api/links/:id/modal/edit", v.h.GetLinkEditModal())
+    r.PUT("/api/links/:id", v.h.UpdateLink())
+    put := func(form url.Values, htmx bool) *httptest.ResponseRecorder {
+        req := httptest.NewRequest("PUT", "/api/links/1", strings.NewReader(form.Encode()))
+        req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+        if htmx { req.Header.Set("HX-Request", "true") }
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        return w
+    }
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/api/links/1/modal/edit", nil))
+    if !strings.Contains(w.Body.String(), `name="version" value="1"`) || w.Header().Get("ETag") != `"1"` { t.Fatalf("expected the modal to carry version 1, got %s", w.Body.String()) }
+
+    // bob's tab saves first, then alice's modal, opened at version 1, submits
+    v.do("PATCH", "/api/v1/links/1", `{"url":"https://theirs.example.com"}`)
+    w = put(url.Values{"alias": {"vpn"}, "url": {"https://mine.example.com"}, "version": {"1"}}, true)
+    body := w.Body.String()
+    if w.Code != http.StatusOK || w.Header().Get("HX-Retarget") != "#modal-root" || !strings.Contains(body, "Someone else changed this link") {
+        t.Fatalf("expected the conflict view in the modal, got %d %q %s", w.Code, w.Header().Get("HX-Retarget"), body)
+    }
+    if !strings.Contains(body, "https://theirs.example.com") || !strings.Contains(body, `name="version" value="2"`) { t.Fatalf("expected both versions and a resubmit at version 2, got %s", body) }
+    if _, link := v.do("GET", "/api/v1/links/1", ""); link["url"] != "https://theirs.example.com" { t.Fatalf("expected the stale edit not to overwrite, got %v", link) }
+
+    if w = put(url.Values{"url": {"https://mine.example.com"}, "version": {"1"}}, false); w.Code != http.StatusPreconditionFailed || !strings.Contains(w.Body.String(), "theirs.example.com") {
+        t.Fatalf("expected a 412 with the current link for JSON clients, got %d %s", w.Code, w.Body.String())
+    }
+    if w = put(url.Values{"alias": {"vpn"}, "url": {"https://mine.example.com"}, "version": {"2"}}, true); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `id="link-1"`) {
+        t.Fatalf("expected overwriting from the conflict view to save the row, got %d %s", w.Code, w.Body.String())
+    }
+}
+
+func TestEditModal_ScheduleSurvivesAResave(t *testing.T) {
+    local := time.Local
+    time.Local = time.FixedZone("UTC+2", 2*60*60)
+    defer func() { time.Local = local }()
+    v := newV1Client(t)
+    v.do("POST", "/api/v1/links", `{"alias":"vpn","url":"https://vpn.example.com","expires_at":"2030-01-02T10:00:00Z"}`)
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    r.Use(func(c *gin.Context) { c.Set("userEmail", "alice@example.com"); c.Set("userRole", "user"); c.Set("userID", uint(1)); c.Next() })
+    r.GET("/api/links/:id/modal/edit", v.h.GetLinkEditModal())
+    r.PUT("/api/links/:id", v.h.UpdateLink())
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/api/links/1/modal/edit", nil))
+    if !strings.Contains(w.Body.String(), `value="2030-01-02T12:00"`) { t.Fatalf("expected the expiry in server local time, got %s", w.Body.String()) }
+
+    // saving the modal as opened keeps the expiry where it was
+    form := url.Values{"alias": {"vpn"}, "url": {"https://vpn.example.com"}, "expires_at": {"2030-01-02T12:00"}, "version": {"1"}}
+    req := httptest.NewRequest("PUT", "/api/links/1", strings.NewReader(form.Encode()))
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    r.ServeHTTP(httptest.NewRecorder(), req)
+    _, link := v.do("GET", "/api/v1/links/1", "")
+    expires, _ := time.Parse(time.RFC3339, fmt.Sprint(link["expires_at"]))
+    if link["version"] != float64(2) || !expires.Equal(time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)) {
+        t.Fatalf("expected the expiry unchanged after a resave, got %v", link)
+    }
+}
+
+func TestAPIv1_ConcurrentCreateSameAlias(t *testing.T) {
+    v := newV1Client(t)
+    const n = 8
+    codes := make(chan *httptest.ResponseRecorder, n)
+    var wg sync.WaitGroup
+    for i := 0; i < n; i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            codes <- v.send("POST", "/api/v1/links", fmt.Sprintf(`{"alias":"vpn","url":"https://vpn%d.example.com"}`, i), nil)
+        }(i)
+    }
+    wg.Wait()
+    close(codes)
+
+    created := 0
+    for w := range codes {
+        switch {
+        case w.Code == http.StatusCreated:
+            created++
+        case w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"alias_exists"`):
+            t.Errorf("expected 201 or 409 alias_exists, got %d %s", w.Code, w.Body.String())
+        }
+    }
+    if created != 1 { t.Fatalf("expected exactly one create to win, got %d", created) }
+    if status, page := v.do("GET", "/api/v1/links", ""); status != http.StatusOK || len(page["items"].([]any)) != 1 { t.Fatalf("expected a single vpn link, got %d %v", status, page) }
+}
+
+// Primary and secondary aliases sit in different tables, so no single index
+// covers a clash between them; the check and the write share one transaction.
+func TestAPIv1_ConcurrentPrimaryAndSecondaryAlias(t *testing.T) {
+    v := newV1Client(t)
+    const n = 4
+    for i := 1; i <= n; i++ {
+        if status, _ := v.do("POST", "/api/v1/links", fmt.Sprintf(`{"alias":"link%d","url":"https://%d.example.com"}`, i, i)); status != http.StatusCreated { t.Fatalf("seed: %d", status) }
+    }
+    codes := make(chan *httptest.ResponseRecorder, 2*n)
+    var wg sync.WaitGroup
+    for i := 1; i <= n; i++ {
+        wg.Add(2)
+        go func(i int) {
+            defer wg.Done()
+            codes <- v.send("POST", "/api/v1/links", fmt.Sprintf(`{"alias":"wg","url":"https://wg%d.example.com"}`, i), nil)
+        }(i)
+        go func(i int) {
+            defer wg.Done()
+            codes <- v.send("POST", fmt.Sprintf("/api/v1/links/%d/aliases", i), `{"alias":"wg"}`, nil)
+        }(i)
+    }
+    wg.Wait()
+    close(codes)
+
+    won := 0
+    for w := range codes {
+        switch {
+        case w.Code == http.StatusCreated:
+            won++
+        case w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"alias_exists"`):
+            t.Errorf("expected 201 or 409 alias_exists, got %d %s", w.Code, w.Body.String())
+        }
+    }
+    if won != 1 { t.Fatalf("expected exactly one link to get wg, got %d", won) }
+}
@@ -1,30 +1,147 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"quickr/domain/linkstatus"
+	"quickr/domain/linktemplate"
+	"quickr/models"
+	"quickr/services"
 )
 
 func (h *AppHandler) HandleRedirect() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		alias := c.Param("alias")
+		args := pathArgs(c)
 		log.Printf("[DEBUG] HandleRedirect called for alias: %s", alias)
 
-		link, err := h.LinkService.FindByAlias(alias)
+		link, target, err := h.LinkService.ResolveTarget(alias, args, c.Request.URL.RawQuery)
 		if err != nil {
+			if errors.Is(err, services.ErrLinkExpired) || errors.Is(err, services.ErrLinkNotActive) {
+				renderUnavailable(c, link)
+				return
+			}
+			if errors.Is(err, services.ErrMissingArgument) {
+				c.String(http.StatusBadRequest, fmt.Sprintf("This link expects %d path argument(s), e.g. %s/value", linktemplate.RequiredArgs(link.URL), aliasPath(c)))
+				return
+			}
+			if errors.Is(err, services.ErrUnexpectedPath) {
+				h.renderNoExtraPath(c, alias)
+				return
+			}
+			if errors.Is(err, services.ErrInvalidPlaceholder) {
+				c.String(http.StatusInternalServerError, "This link's target URL has an invalid placeholder; ask its owner to fix it")
+				return
+			}
 			log.Printf("[ERROR] Error finding link: %v", err)
-			c.String(http.StatusNotFound, "Link not found")
+			h.renderNotFound(c, alias)
 			return
 		}
 
 		if err := h.LinkService.IncrementClicks(link.ID); err != nil {
 			log.Printf("[ERROR] Error updating clicks: %v", err)
 		}
+		if h.StatsService != nil {
+			if err := h.StatsService.RecordClick(link.ID, c.ClientIP(), c.Request.UserAgent(), c.Request.Referer()); err != nil {
+				log.Printf("[ERROR] Error recording click: %v", err)
+			}
+		}
 
-		log.Printf("[DEBUG] Redirecting to: %s", link.URL)
+		log.Printf("[DEBUG] Redirecting to: %s", target)
 		// Use 302 Found instead of 301 Moved Permanently to avoid browser caching
-		c.Redirect(http.StatusFound, link.URL)
+		c.Redirect(http.StatusFound, target)
+	}
+}
+
+// renderUnavailable explains why a scheduled or expired link does not redirect.
+func renderUnavailable(c *gin.Context, link *models.Link) {
+	code := http.StatusNotFound
+	if link.Status == linkstatus.Expired {
+		code = http.StatusGone
+	}
+	c.HTML(code, "link_unavailable.html", gin.H{
+		"alias":      link.Alias,
+		"status":     link.Status,
+		"activeFrom": link.ActiveFrom,
+		"expiresAt":  link.ExpiresAt,
+	})
+}
+
+// renderNotFound offers signed-in users the closest existing aliases and a
+// shortcut to create the missing one; anonymous visitors are asked to sign in
+// so the page does not reveal which aliases exist.
+func (h *AppHandler) renderNotFound(c *gin.Context, alias string) {
+	data := gin.H{"alias": alias, "signedIn": h.signedIn(c)}
+	if data["signedIn"] == true {
+		suggestions, err := h.LinkService.SuggestAliases(alias)
+		if err != nil {
+			log.Printf("[ERROR] Error suggesting aliases: %v", err)
+		}
+		data["suggestions"] = suggestions
+		data["canCreate"] = !h.LinkService.IsAliasReserved(alias)
+	}
+	c.HTML(http.StatusNotFound, "link_not_found.html", data)
+}
+
+// renderNoExtraPath answers a path appended to a link that takes none. Signed-in
+// users are pointed at the link itself rather than offered to create it;
+// anonymous visitors get the usual not-found page.
+func (h *AppHandler) renderNoExtraPath(c *gin.Context, alias string) {
+	if !h.signedIn(c) {
+		h.renderNotFound(c, alias)
+		return
+	}
+	c.HTML(http.StatusNotFound, "link_not_found.html", gin.H{"alias": alias, "signedIn": true, "noExtraPath": true})
+}
+
+// signedIn reports whether a public route was reached with a valid session
+// of a user who has not been disabled or signed out everywhere.
+func (h *AppHandler) signedIn(c *gin.Context) bool {
+	if h.Session == nil {
+		return false
+	}
+	claims, err := h.Session.Parse(c)
+	if err != nil || strings.TrimSpace(claims.Subject) == "" {
+		return false
+	}
+	if h.AuthService != nil {
+		if _, err := h.AuthService.SessionUser(claims.Subject, claims.Generation); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// aliasPath is the request path up to and including the alias, such as
+// /jira or /go/jira.
+func aliasPath(c *gin.Context) string {
+	return strings.TrimSuffix(c.Request.URL.Path, c.Param("rest"))
+}
+
+// pathArgs splits the path after the alias into its segments. It splits the
+// escaped path and unescapes each segment on its own, so an argument holding
+// an escaped slash (%2F) stays one argument.
+func pathArgs(c *gin.Context) []string {
+	skip := strings.Count(aliasPath(c), "/") + 1
+	segs := strings.Split(c.Request.URL.EscapedPath(), "/")
+	if skip > len(segs) {
+		return nil
+	}
+	var args []string
+	for _, seg := range segs[skip:] {
+		if seg == "" {
+			continue
+		}
+		if v, err := url.PathUnescape(seg); err == nil {
+			seg = v
+		}
+		args = append(args, seg)
 	}
-}
\ No newline at end of file
+	return args
+}
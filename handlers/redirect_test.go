@@ -2,12 +2,17 @@ package handlers
 
 import (
     "errors"
+    "html/template"
     "net/http"
     "net/http/httptest"
+    "strings"
     "testing"
+    "time"
 
     "github.com/gin-gonic/gin"
+    "quickr/interfaces/session"
     "quickr/models"
+    "quickr/repositories"
     "quickr/services"
 )
 
@@ -16,7 +21,7 @@ func TestHandleRedirect_Success(t *testing.T) {
     r := gin.New()
 
     // Use a real LinkService with an in-memory fake repository
-    repo := &services_fakeRepoForHandlers{ FindByAliasFunc: func(alias string) (*models.Link, error) { return &models.Link{ID: 1, Alias: alias, URL: "https://example.com"}, nil }, IncrementClicksFunc: func(id uint) error { return nil } }
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) { return &models.Link{ID: 1, Alias: alias, URL: "https://example.com"}, nil }, IncrementClicksFunc: func(id uint) error { return nil } }
     svc := services.NewLinkService(repo)
     h := &AppHandler{ LinkService: svc }
     r.GET("/:alias", h.HandleRedirect())
@@ -34,33 +39,170 @@ func TestHandleRedirect_Success(t *testing.T) {
 func TestHandleRedirect_NotFound(t *testing.T) {
     gin.SetMode(gin.TestMode)
     r := gin.New()
-    repo := &services_fakeRepoForHandlers{ FindByAliasFunc: func(alias string) (*models.Link, error) { return nil, errors.New("db not found") } }
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    repo := &fakeRepo{
+        FindByAliasFunc: func(alias string) (*models.Link, error) { return nil, errors.New("db not found") },
+        ListAliasCandidatesFunc: func(now time.Time) ([]repositories.AliasCandidate, error) {
+            return []repositories.AliasCandidate{{Alias: "vpn", URL: "https://vpn.example.com"}, {Alias: "wiki", URL: "https://wiki.example.com"}}, nil
+        },
+    }
     svc := services.NewLinkService(repo)
-    h := &AppHandler{ LinkService: svc }
+    sess := &fakeSession{}
+    h := &AppHandler{ LinkService: svc, Session: sess }
     r.GET("/:alias", h.HandleRedirect())
 
+    // anonymous visitors only get a sign-in prompt
     w := httptest.NewRecorder()
-    req := httptest.NewRequest("GET", "/missing", nil)
+    req := httptest.NewRequest("GET", "/vnp", nil)
     r.ServeHTTP(w, req)
     if w.Code != http.StatusNotFound { t.Fatalf("expected 404, got %d", w.Code) }
+    if body := w.Body.String(); !strings.Contains(body, `href="/login"`) || strings.Contains(body, "vpn.example.com") || strings.Contains(body, "Create this link") {
+        t.Fatalf("expected a sign-in prompt without suggestions, got %s", body)
+    }
+
+    sess.email = "alice@example.com"
+    w2 := httptest.NewRecorder()
+    r.ServeHTTP(w2, httptest.NewRequest("GET", "/vnp", nil))
+    body := w2.Body.String()
+    if w2.Code != http.StatusNotFound || !strings.Contains(body, `href="/vpn"`) || strings.Contains(body, "wiki.example.com") {
+        t.Fatalf("expected vpn to be suggested, got %d - %s", w2.Code, body)
+    }
+    if !strings.Contains(body, `href="/?create=vnp"`) { t.Fatalf("expected a create shortcut, got %s", body) }
 }
 
-// services_fakeRepoForHandlers provides only the methods used by LinkService in redirect tests
-type services_fakeRepoForHandlers struct {
-    FindByAliasFunc func(alias string) (*models.Link, error)
-    IncrementClicksFunc func(id uint) error
+func TestHandleRedirect_TemplateLink(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) { return &models.Link{ID: 2, Alias: alias, URL: "https://jira.example.com/browse/{1}"}, nil } }
+    svc := services.NewLinkService(repo)
+    h := &AppHandler{ LinkService: svc }
+    r.GET("/:alias", h.HandleRedirect())
+    r.GET("/:alias/*rest", h.HandleRedirect())
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/jira/PROJ-123", nil))
+    if w.Code != http.StatusFound { t.Fatalf("expected 302, got %d", w.Code) }
+    if loc := w.Header().Get("Location"); loc != "https://jira.example.com/browse/PROJ-123" {
+        t.Fatalf("unexpected location: %q", loc)
+    }
+
+    w2 := httptest.NewRecorder()
+    r.ServeHTTP(w2, httptest.NewRequest("GET", "/jira", nil))
+    if w2.Code != http.StatusBadRequest || !strings.Contains(w2.Body.String(), "e.g. /jira/value") { t.Fatalf("expected 400 for missing argument, got %d %s", w2.Code, w2.Body.String()) }
+
+    // an escaped slash stays inside its argument
+    w3 := httptest.NewRecorder()
+    r.ServeHTTP(w3, httptest.NewRequest("GET", "/jira/a%2Fb", nil))
+    if loc := w3.Header().Get("Location"); w3.Code != http.StatusFound || loc != "https://jira.example.com/browse/a%2Fb" { t.Fatalf("expected a%%2Fb as one argument, got %d %q", w3.Code, loc) }
+
+    // the hint follows the prefix the link was reached through
+    r.GET("/go/:alias", h.HandleRedirect())
+    w4 := httptest.NewRecorder()
+    r.ServeHTTP(w4, httptest.NewRequest("GET", "/go/jira", nil))
+    if !strings.Contains(w4.Body.String(), "e.g. /go/jira/value") { t.Fatalf("expected the /go/ prefix in the hint, got %s", w4.Body.String()) }
 }
 
-func (f *services_fakeRepoForHandlers) Create(link *models.Link) error { return nil }
-func (f *services_fakeRepoForHandlers) FindByAlias(alias string) (*models.Link, error) { return f.FindByAliasFunc(alias) }
-func (f *services_fakeRepoForHandlers) FindByID(id string) (*models.Link, error) { return nil, errors.New("unused") }
-func (f *services_fakeRepoForHandlers) ExistsByAlias(alias string) (bool, error) { return false, nil }
-func (f *services_fakeRepoForHandlers) ExistsByAliasExceptID(alias string, id string) (bool, error) { return false, nil }
-func (f *services_fakeRepoForHandlers) Delete(link *models.Link) error { return nil }
-func (f *services_fakeRepoForHandlers) Save(link *models.Link) error { return nil }
-func (f *services_fakeRepoForHandlers) ListAll() ([]models.Link, error) { return nil, nil }
-func (f *services_fakeRepoForHandlers) Search(query string) ([]models.Link, error) { return nil, nil }
-func (f *services_fakeRepoForHandlers) IncrementClicks(id uint) error { if f.IncrementClicksFunc == nil { return nil }; return f.IncrementClicksFunc(id) }
-func (f *services_fakeRepoForHandlers) GetLinkByID(id string) (*models.Link, error) { return nil, errors.New("unused") }
+func TestHandleRedirect_Passthrough(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) {
+        return &models.Link{ID: 3, Alias: alias, URL: "https://docs.example.com/?lang=en", Passthrough: true, QueryMerge: "target"}, nil
+    } }
+    h := &AppHandler{ LinkService: services.NewLinkService(repo) }
+    r.GET("/:alias", h.HandleRedirect())
+    r.GET("/:alias/*rest", h.HandleRedirect())
 
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/docs/guides/setup?tab=2&lang=fr", nil))
+    if w.Code != http.StatusFound { t.Fatalf("expected 302, got %d", w.Code) }
+    if loc := w.Header().Get("Location"); loc != "https://docs.example.com/guides/setup?lang=en&tab=2" {
+        t.Fatalf("unexpected location: %q", loc)
+    }
+}
 
+func TestHandleRedirect_NoExtraPath(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) {
+        return &models.Link{ID: 1, Alias: alias, URL: "https://vpn.example.com"}, nil
+    } }
+    sess := &fakeSession{email: "alice@example.com"}
+    h := &AppHandler{ LinkService: services.NewLinkService(repo), Session: sess }
+    r.GET("/:alias", h.HandleRedirect())
+    r.GET("/:alias/*rest", h.HandleRedirect())
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/vpn/extra", nil))
+    if body := w.Body.String(); w.Code != http.StatusNotFound || !strings.Contains(body, "takes no extra path") || !strings.Contains(body, `href="/vpn"`) || strings.Contains(body, "Create this link") {
+        t.Fatalf("expected a pointer to the link instead of a create shortcut, got %d - %s", w.Code, body)
+    }
+
+    sess.email = ""
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/vpn/extra", nil))
+    if body := w.Body.String(); w.Code != http.StatusNotFound || strings.Contains(body, "takes no extra path") || !strings.Contains(body, `href="/login"`) {
+        t.Fatalf("expected anonymous visitors not to learn the alias exists, got %d - %s", w.Code, body)
+    }
+}
+
+func TestHandleRedirect_ExpiredAndScheduled(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    past := time.Now().Add(-time.Hour)
+    future := time.Now().Add(time.Hour)
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) {
+        if alias == "old" { return &models.Link{ID: 1, Alias: alias, URL: "https://example.com", ExpiresAt: &past}, nil }
+        return &models.Link{ID: 2, Alias: alias, URL: "https://example.com", ActiveFrom: &future}, nil
+    } }
+    h := &AppHandler{ LinkService: services.NewLinkService(repo) }
+    r.GET("/:alias", h.HandleRedirect())
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/old", nil))
+    if w.Code != http.StatusGone || !strings.Contains(w.Body.String(), "expired") {
+        t.Fatalf("expected 410 expired page, got %d - %s", w.Code, w.Body.String())
+    }
+    w2 := httptest.NewRecorder()
+    r.ServeHTTP(w2, httptest.NewRequest("GET", "/soon", nil))
+    if w2.Code != http.StatusNotFound || !strings.Contains(w2.Body.String(), "not active yet") {
+        t.Fatalf("expected 404 not-active page, got %d", w2.Code)
+    }
+}
+
+func TestHandleRedirect_SecondaryAliasCountsOnSharedLink(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    var clicked uint
+    repo := &fakeRepo{
+        FindByAliasFunc: func(alias string) (*models.Link, error) {
+            // the repository resolves vpn-setup to the link whose primary alias is vpn
+            return &models.Link{ID: 4, Alias: "vpn", URL: "https://vpn.example.com"}, nil
+        },
+        IncrementClicksFunc: func(id uint) error { clicked = id; return nil },
+    }
+    h := &AppHandler{ LinkService: services.NewLinkService(repo) }
+    r.GET("/:alias", h.HandleRedirect())
+
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, httptest.NewRequest("GET", "/vpn-setup", nil))
+    if w.Code != http.StatusFound || w.Header().Get("Location") != "https://vpn.example.com" || clicked != 4 {
+        t.Fatalf("expected redirect counted on link 4, got %d %q clicked=%d", w.Code, w.Header().Get("Location"), clicked)
+    }
+}
+
+// fakeSession signs everyone in as email; an empty email means no session.
+type fakeSession struct {
+    email string
+    gen   uint
+}
+
+func (f *fakeSession) Parse(c *gin.Context) (*session.Claims, error) {
+    if f.email == "" { return nil, errors.New("no session") }
+    claims := &session.Claims{Generation: f.gen}
+    claims.Subject = f.email
+    return claims, nil
+}
+func (f *fakeSession) SignIn(c *gin.Context, u *models.User) error { f.email, f.gen = u.Email, u.SessionGeneration; return nil }
+func (f *fakeSession) Clear(c *gin.Context) { f.email, f.gen = "", 0 }
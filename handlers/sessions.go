@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	webview "quickr/interfaces/presenters/web"
+	"quickr/services"
+)
+
+// sessionIDKey holds the stored session a request signed in with, if any
+const sessionIDKey = "sessionID"
+
+// DELETE /settings/sessions/:id signs one of the user's devices out
+func (h *AppHandler) RevokeSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireSession(c) {
+			return
+		}
+		id := c.Param("id")
+		if err := h.SessionService.RevokeSession(c.GetUint("userID"), id); err != nil {
+			c.String(http.StatusNotFound, services.ErrSessionNotFound.Error())
+			return
+		}
+		if id == c.GetString(sessionIDKey) {
+			h.signedOut(c)
+			return
+		}
+		h.renderSessions(c)
+	}
+}
+
+// DELETE /settings/sessions signs every device of the user out, this one included
+func (h *AppHandler) RevokeAllSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireSession(c) {
+			return
+		}
+		if err := h.SessionService.RevokeAllSessions(c.GetUint("userID")); err != nil {
+			c.String(http.StatusInternalServerError, "Failed to sign out")
+			return
+		}
+		h.signedOut(c)
+	}
+}
+
+// POST /logout revokes this device's session and clears the cookie
+func (h *AppHandler) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.Session.Clear(c)
+		c.Redirect(http.StatusFound, "/login")
+	}
+}
+
+// signedOut sends a browser whose own session was just revoked to the login page
+func (h *AppHandler) signedOut(c *gin.Context) {
+	h.Session.Clear(c)
+	if c.GetHeader("HX-Request") == "true" {
+		c.Header("HX-Redirect", "/login")
+		c.Status(http.StatusOK)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/login")
+}
+
+func (h *AppHandler) renderSessions(c *gin.Context) {
+	sessions, err := h.SessionService.ListSessions(c.GetUint("userID"))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Service error")
+		return
+	}
+	c.HTML(http.StatusOK, "sessions.html", gin.H{"sessions": webview.SessionRows(sessions, c.GetString(sessionIDKey))})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+    "html/template"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+
+    "quickr/models"
+    "quickr/repositories"
+    "quickr/services"
+)
+
+func TestSessions_DevicesAndLogout(t *testing.T) {
+    a := newAuthClient(t)
+    if err := a.db.AutoMigrate(&models.APIToken{}); err != nil { t.Fatalf("migrate: %v", err) }
+    if err := a.db.Create(&models.User{Email: "bob@example.com", Role: "user"}).Error; err != nil { t.Fatalf("seed: %v", err) }
+    a.h.TokenService = services.NewTokenService(repositories.NewGormAPITokenRepository(a.db))
+    a.r.SetHTMLTemplate(template.Must(template.ParseGlob("../templates/*.html")))
+    a.r.GET("/settings", a.h.RequireAuth(), a.h.HandleSettings())
+    a.r.DELETE("/settings/sessions", a.h.RequireAuth(), a.h.RevokeAllSessions())
+    a.r.DELETE("/settings/sessions/:id", a.h.RequireAuth(), a.h.RevokeSession())
+    a.r.POST("/logout", a.h.Logout())
+    signIn := func(agent string) *http.Cookie {
+        delete(a.mail.links, "bob@example.com")
+        if w := a.login("bob@example.com"); w.Code != http.StatusOK { t.Fatalf("expected bob to get a link, got %d", w.Code) }
+        u, _ := url.Parse(a.mail.links["bob@example.com"])
+        req := httptest.NewRequest("GET", u.RequestURI(), nil)
+        req.Header.Set("User-Agent", agent)
+        w := httptest.NewRecorder()
+        a.r.ServeHTTP(w, req)
+        if sessionCookie(w) == nil { t.Fatalf("expected the link to sign bob in") }
+        return sessionCookie(w)
+    }
+    send := func(method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+        req := httptest.NewRequest(method, path, nil)
+        req.Header.Set("HX-Request", "true")
+        req.AddCookie(cookie)
+        w := httptest.NewRecorder()
+        a.r.ServeHTTP(w, req)
+        return w
+    }
+    laptop, phone := signIn("Firefox on the laptop"), signIn("Safari on the phone")
+
+    body := a.get("/settings", laptop).Body.String()
+    if !strings.Contains(body, "Firefox on the laptop") || !strings.Contains(body, "Safari on the phone") || strings.Count(body, "This device") != 1 {
+        t.Fatalf("expected both devices listed, this one marked, got %s", body)
+    }
+    var stored []models.Session
+    a.db.Order("created_at").Find(&stored)
+    if len(stored) != 2 || stored[0].UserAgent != "Firefox on the laptop" { t.Fatalf("expected a stored session per sign-in, got %+v", stored) }
+
+    // signing the phone out from the laptop ends the phone's session only
+    if w := send("DELETE", "/settings/sessions/"+stored[1].ID, laptop); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Safari") {
+        t.Fatalf("expected the phone removed from the list, got %d %s", w.Code, w.Body.String())
+    }
+    if w := a.get("/settings", phone); w.Code != http.StatusFound { t.Fatalf("expected the phone signed out, got %d", w.Code) }
+    if w := send("DELETE", "/settings/sessions/"+stored[1].ID, laptop); w.Code != http.StatusNotFound { t.Fatalf("expected 404 for a revoked session, got %d", w.Code) }
+
+    // a logged-out cookie no longer works even if it was copied first
+    req := httptest.NewRequest("POST", "/logout", nil)
+    req.AddCookie(laptop)
+    a.r.ServeHTTP(httptest.NewRecorder(), req)
+    if w := a.get("/settings", laptop); w.Code != http.StatusFound { t.Fatalf("expected the logged-out cookie refused, got %d", w.Code) }
+
+    laptop, phone = signIn("Firefox on the laptop"), signIn("Safari on the phone")
+    if w := send("DELETE", "/settings/sessions", laptop); w.Header().Get("HX-Redirect") != "/login" { t.Fatalf("expected to be sent to sign in, got %d %v", w.Code, w.Header()) }
+    for _, cookie := range []*http.Cookie{laptop, phone} {
+        if w := a.get("/settings", cookie); w.Code != http.StatusFound { t.Fatalf("expected every device signed out, got %d", w.Code) }
+    }
+}
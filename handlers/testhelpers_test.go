@@ -0,0 +1,162 @@
+package handlers
+
+import (
+    "errors"
+    "time"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+// errNotFaked is what lookups return when a test leaves their Func unset.
+var errNotFaked = errors.New("not faked")
+
+// fakeRepo is a function-backed test double for repositories.LinkRepository.
+// Unlike the services fake it never panics: handler tests only set the
+// methods they care about, and every other method finds nothing.
+type fakeRepo struct {
+    CreateFunc                func(link *models.Link) error
+    FindByAliasFunc           func(alias string) (*models.Link, error)
+    FindByIDFunc              func(id string) (*models.Link, error)
+    ExistsByAliasFunc         func(alias string) (bool, error)
+    ExistsByAliasExceptIDFunc func(alias string, id string) (bool, error)
+    DeleteFunc                func(link *models.Link) error
+    SaveFunc                  func(link *models.Link) error
+    ListAllFunc               func() ([]models.Link, error)
+    ListPageFunc              func(q repositories.LinkQuery) ([]models.Link, error)
+    SearchFunc                func(query string) ([]models.Link, error)
+    IncrementClicksFunc       func(id uint) error
+    ListExpiredUnmarkedFunc   func(now time.Time) ([]models.Link, error)
+    MarkExpiredFunc           func(id uint, at time.Time) error
+    ListDeletedFunc           func() ([]models.Link, error)
+    FindDeletedByIDFunc       func(id string) (*models.Link, error)
+    RestoreFunc               func(link *models.Link) error
+    PurgeDeletedBeforeFunc    func(cutoff time.Time) (int64, error)
+    CountFunc                 func() (int64, error)
+    SumClicksFunc             func() (int64, error)
+    CountCreatorsFunc         func() (int64, error)
+    TopByClicksFunc           func(limit int) ([]models.Link, error)
+    ListCreatedSinceFunc      func(since time.Time, limit int) ([]models.Link, error)
+    FindByIDsFunc             func(ids []uint) ([]models.Link, error)
+    ListAliasCandidatesFunc   func(now time.Time) ([]repositories.AliasCandidate, error)
+}
+
+func (f *fakeRepo) Create(link *models.Link) error {
+    if f.CreateFunc == nil { return nil }
+    return f.CreateFunc(link)
+}
+
+func (f *fakeRepo) FindByAlias(alias string) (*models.Link, error) {
+    if f.FindByAliasFunc == nil { return nil, errNotFaked }
+    return f.FindByAliasFunc(alias)
+}
+
+func (f *fakeRepo) FindByID(id string) (*models.Link, error) {
+    if f.FindByIDFunc == nil { return nil, errNotFaked }
+    return f.FindByIDFunc(id)
+}
+
+func (f *fakeRepo) ExistsByAlias(alias string) (bool, error) {
+    if f.ExistsByAliasFunc == nil { return false, nil }
+    return f.ExistsByAliasFunc(alias)
+}
+
+func (f *fakeRepo) ExistsByAliasExceptID(alias string, id string) (bool, error) {
+    if f.ExistsByAliasExceptIDFunc == nil { return false, nil }
+    return f.ExistsByAliasExceptIDFunc(alias, id)
+}
+
+func (f *fakeRepo) Delete(link *models.Link) error {
+    if f.DeleteFunc == nil { return nil }
+    return f.DeleteFunc(link)
+}
+
+func (f *fakeRepo) Save(link *models.Link) error {
+    if f.SaveFunc == nil { return nil }
+    return f.SaveFunc(link)
+}
+
+func (f *fakeRepo) ListAll() ([]models.Link, error) {
+    if f.ListAllFunc == nil { return nil, nil }
+    return f.ListAllFunc()
+}
+
+func (f *fakeRepo) ListPage(q repositories.LinkQuery) ([]models.Link, error) {
+    if f.ListPageFunc == nil { return nil, nil }
+    return f.ListPageFunc(q)
+}
+
+func (f *fakeRepo) Search(query string) ([]models.Link, error) {
+    if f.SearchFunc == nil { return nil, nil }
+    return f.SearchFunc(query)
+}
+
+func (f *fakeRepo) IncrementClicks(id uint) error {
+    if f.IncrementClicksFunc == nil { return nil }
+    return f.IncrementClicksFunc(id)
+}
+
+func (f *fakeRepo) ListExpiredUnmarked(now time.Time) ([]models.Link, error) {
+    if f.ListExpiredUnmarkedFunc == nil { return nil, nil }
+    return f.ListExpiredUnmarkedFunc(now)
+}
+
+func (f *fakeRepo) MarkExpired(id uint, at time.Time) error {
+    if f.MarkExpiredFunc == nil { return nil }
+    return f.MarkExpiredFunc(id, at)
+}
+
+func (f *fakeRepo) ListDeleted() ([]models.Link, error) {
+    if f.ListDeletedFunc == nil { return nil, nil }
+    return f.ListDeletedFunc()
+}
+
+func (f *fakeRepo) FindDeletedByID(id string) (*models.Link, error) {
+    if f.FindDeletedByIDFunc == nil { return nil, errNotFaked }
+    return f.FindDeletedByIDFunc(id)
+}
+
+func (f *fakeRepo) Restore(link *models.Link) error {
+    if f.RestoreFunc == nil { return nil }
+    return f.RestoreFunc(link)
+}
+
+func (f *fakeRepo) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+    if f.PurgeDeletedBeforeFunc == nil { return 0, nil }
+    return f.PurgeDeletedBeforeFunc(cutoff)
+}
+
+func (f *fakeRepo) Count() (int64, error) {
+    if f.CountFunc == nil { return 0, nil }
+    return f.CountFunc()
+}
+
+func (f *fakeRepo) SumClicks() (int64, error) {
+    if f.SumClicksFunc == nil { return 0, nil }
+    return f.SumClicksFunc()
+}
+
+func (f *fakeRepo) CountCreators() (int64, error) {
+    if f.CountCreatorsFunc == nil { return 0, nil }
+    return f.CountCreatorsFunc()
+}
+
+func (f *fakeRepo) TopByClicks(limit int) ([]models.Link, error) {
+    if f.TopByClicksFunc == nil { return nil, nil }
+    return f.TopByClicksFunc(limit)
+}
+
+func (f *fakeRepo) ListCreatedSince(since time.Time, limit int) ([]models.Link, error) {
+    if f.ListCreatedSinceFunc == nil { return nil, nil }
+    return f.ListCreatedSinceFunc(since, limit)
+}
+
+func (f *fakeRepo) FindByIDs(ids []uint) ([]models.Link, error) {
+    if f.FindByIDsFunc == nil { return nil, nil }
+    return f.FindByIDsFunc(ids)
+}
+
+func (f *fakeRepo) ListAliasCandidates(now time.Time) ([]repositories.AliasCandidate, error) {
+    if f.ListAliasCandidatesFunc == nil { return nil, nil }
+    return f.ListAliasCandidatesFunc(now)
+}
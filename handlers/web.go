@@ -3,15 +3,28 @@ package handlers
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	webview "quickr/interfaces/presenters/web"
+	"quickr/services"
 )
 
-// HandleHome renders the homepage with all links
+// HandleHome renders the first page of links with the filters in the query
+// string; filters that do not parse are dropped rather than failing the page.
 func (h *AppHandler) HandleHome() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		links, err := h.LinkService.ListLinks()
+		filters := linkFilters(c)
+		opts, err := linkListOptions(c, filters)
+		opts.Cursor = ""
+		var page *services.LinkPage
+		if err == nil {
+			page, err = h.LinkService.ListLinksPage(opts)
+		}
+		if isListingError(err) {
+			filters = webview.LinkFilters{}
+			page, err = h.LinkService.ListLinksPage(services.LinkListOptions{})
+		}
 		if err != nil {
 			log.Printf("Service error: %v", err)
 			c.String(http.StatusInternalServerError, "Service error")
@@ -20,8 +33,13 @@ func (h *AppHandler) HandleHome() gin.HandlerFunc {
 		emailVal, _ := c.Get("userEmail")
 		roleVal, _ := c.Get("userRole")
 		isAdmin := roleVal == "admin"
-		log.Printf("Found %d links", len(links))
-		c.HTML(http.StatusOK, "index.html", webview.HomeView(links, emailVal.(string), isAdmin))
+		view := webview.HomeView(page.Links, emailVal.(string), isAdmin, filters.Status)
+		for k, v := range webview.LinkRowsView(page.Links, filters, "", page.NextCursor) {
+			view[k] = v
+		}
+		// /?create=alias opens the create modal pre-filled, e.g. from the not-found page
+		view["createAlias"] = c.Query("create")
+		c.HTML(http.StatusOK, "index.html", view)
 	}
 }
 
@@ -53,4 +71,57 @@ func (h *AppHandler) HandleHot() gin.HandlerFunc {
 		isAdmin := roleVal == "admin"
 		c.HTML(http.StatusOK, "hot.html", webview.HotView(hot, emailVal.(string), isAdmin))
 	}
-}
\ No newline at end of file
+}
+
+// GET /trash lists deleted links that can still be restored
+func (h *AppHandler) HandleTrash() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		links, err := h.LinkService.ListTrash()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Service error")
+			return
+		}
+		emailVal, _ := c.Get("userEmail")
+		roleVal, _ := c.Get("userRole")
+		isAdmin := roleVal == "admin"
+		c.HTML(http.StatusOK, "trash.html", webview.TrashView(links, emailVal.(string), isAdmin, h.TrashRetentionDays))
+	}
+}
+
+// GET /links/:id?days=7|30|90 shows a link's click history
+func (h *AppHandler) HandleLinkDetail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := h.LinkService.GetLinkByID(c.Param("id"))
+		if err != nil {
+			c.String(http.StatusNotFound, "Link not found")
+			return
+		}
+		days := detailRange(c.Query("days"))
+		now := time.Now()
+		daily, err := h.StatsService.ClicksByDay(link.ID, days, now)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Service error")
+			return
+		}
+		hourly, err := h.StatsService.ClicksByHour(link.ID, 24, now)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Service error")
+			return
+		}
+		emailVal, _ := c.Get("userEmail")
+		roleVal, _ := c.Get("userRole")
+		isAdmin := roleVal == "admin"
+		c.HTML(http.StatusOK, "link_detail.html", webview.LinkDetailView(link, daily, hourly, days, emailVal.(string), isAdmin))
+	}
+}
+
+// detailRange accepts the chart ranges offered on the detail page, defaulting to 30 days.
+func detailRange(q string) int {
+	switch q {
+	case "7":
+		return 7
+	case "90":
+		return 90
+	}
+	return 30
+}
@@ -0,0 +1,185 @@
+// Package api holds the request-independent JSON shapes of /api/v1. Models
+// never reach the wire directly, so renaming a column or adding a GORM field
+// does not change the contract.
+package api
+
+import (
+	"time"
+
+	"quickr/models"
+)
+
+// Link is a link as served by /api/v1.
+type Link struct {
+	ID          uint       `json:"id"`
+	Alias       string     `json:"alias"`
+	URL         string     `json:"url"`
+	Aliases     []string   `json:"aliases"`
+	Clicks      uint       `json:"clicks"`
+	Passthrough bool       `json:"passthrough"`
+	QueryMerge  string     `json:"query_merge"`
+	ActiveFrom  *time.Time `json:"active_from"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	// Status is active, scheduled or expired
+	Status    string     `json:"status"`
+	CreatedBy string     `json:"created_by"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy string     `json:"deleted_by,omitempty"`
+	// Version matches the ETag; send it back as If-Match or "version" to edit safely
+	Version uint `json:"version"`
+}
+
+// LinkAlias is a secondary alias of a link.
+type LinkAlias struct {
+	Alias     string    `json:"alias"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// User is a co-owner of a link.
+type User struct {
+	ID    uint   `json:"id"`
+	Email string `json:"email"`
+}
+
+// Revision is one entry of a link's history. Before is absent on creation and
+// After on deletion.
+type Revision struct {
+	ID        uint          `json:"id"`
+	LinkID    uint          `json:"link_id"`
+	Action    string        `json:"action"`
+	Actor     string        `json:"actor"`
+	ActorID   *uint         `json:"actor_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Before    *LinkSnapshot `json:"before,omitempty"`
+	After     *LinkSnapshot `json:"after,omitempty"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// LinkSnapshot is a link's editable fields at one revision.
+type LinkSnapshot struct {
+	Alias       string     `json:"alias"`
+	URL         string     `json:"url"`
+	Passthrough bool       `json:"passthrough"`
+	QueryMerge  string     `json:"query_merge"`
+	ActiveFrom  *time.Time `json:"active_from"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Aliases     []string   `json:"aliases,omitempty"`
+}
+
+// FieldChange is one field that differs between a revision's before and after.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// List wraps every collection. NextCursor is set on paged listings that have
+// more items; pass it back as ?cursor= to get them.
+type List[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ErrorResponse is the body of every non-2xx /api/v1 response.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// ConflictResponse is the 412 sent when an edit was based on an old version
+// of a link; Current is the link as it is now.
+type ConflictResponse struct {
+	Error   Error `json:"error"`
+	Current Link  `json:"current"`
+}
+
+// Error is a machine-readable Code (one of the Code constants) and a
+// human-readable Message.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes. Clients should branch on these, never on messages.
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeAliasExists       = "alias_exists"
+	CodeAliasReserved     = "alias_reserved"
+	CodeAliasRetired      = "alias_retired"
+	CodeInvalidURL        = "invalid_url"
+	CodeInvalidQueryMerge = "invalid_query_merge"
+	CodeInvalidSchedule   = "invalid_schedule"
+	CodeInvalidStatus     = "invalid_status"
+	CodeInvalidSort       = "invalid_sort"
+	CodeInvalidCursor     = "invalid_cursor"
+	CodeInvalidDateRange  = "invalid_date_range"
+	CodeVersionConflict   = "version_conflict"
+	CodeNotFound          = "not_found"
+	CodeUserNotFound      = "user_not_found"
+	CodeUnauthenticated   = "unauthenticated"
+	CodeInsufficientScope = "insufficient_scope"
+	CodeForbidden         = "forbidden"
+	CodeInternal          = "internal_error"
+)
+
+func NewLink(l models.Link) Link {
+	dto := Link{
+		ID:          l.ID,
+		Alias:       l.Alias,
+		URL:         l.URL,
+		Aliases:     make([]string, 0, len(l.Aliases)),
+		Clicks:      l.Clicks,
+		Passthrough: l.Passthrough,
+		QueryMerge:  l.QueryMerge,
+		ActiveFrom:  l.ActiveFrom,
+		ExpiresAt:   l.ExpiresAt,
+		Status:      l.Status,
+		CreatedBy:   l.CreatedByName,
+		UpdatedBy:   l.UpdatedByName,
+		CreatedAt:   l.CreatedAt,
+		UpdatedAt:   l.UpdatedAt,
+		Version:     l.Version,
+	}
+	for _, a := range l.Aliases {
+		dto.Aliases = append(dto.Aliases, a.Alias)
+	}
+	if l.DeletedAt.Valid {
+		at := l.DeletedAt.Time
+		dto.DeletedAt = &at
+		dto.DeletedBy = l.DeletedByName
+	}
+	return dto
+}
+
+func NewLinks(links []models.Link) List[Link] {
+	items := make([]Link, 0, len(links))
+	for _, l := range links {
+		items = append(items, NewLink(l))
+	}
+	return List[Link]{Items: items}
+}
+
+func NewLinkAliases(aliases []models.LinkAlias) List[LinkAlias] {
+	items := make([]LinkAlias, 0, len(aliases))
+	for _, a := range aliases {
+		items = append(items, LinkAlias{Alias: a.Alias, CreatedAt: a.CreatedAt})
+	}
+	return List[LinkAlias]{Items: items}
+}
+
+func NewUsers(users []models.User) List[User] {
+	items := make([]User, 0, len(users))
+	for _, u := range users {
+		items = append(items, User{ID: u.ID, Email: u.Email})
+	}
+	return List[User]{Items: items}
+}
+
+func NewRevisions(revs []Revision) List[Revision] {
+	if revs == nil {
+		revs = []Revision{}
+	}
+	return List[Revision]{Items: revs}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+    "encoding/json"
+    "strings"
+    "testing"
+    "time"
+
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+func TestNewLink(t *testing.T) {
+    deleted := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+    l := models.Link{ID: 7, Alias: "vpn", Aliases: []models.LinkAlias{{Alias: "wireguard"}}, CreatedByName: "alice", DeletedAt: gorm.DeletedAt{Time: deleted, Valid: true}, DeletedByName: "bob"}
+    dto := NewLink(l)
+    if dto.ID != 7 || len(dto.Aliases) != 1 || dto.Aliases[0] != "wireguard" || dto.CreatedBy != "alice" || dto.DeletedAt == nil || !dto.DeletedAt.Equal(deleted) || dto.DeletedBy != "bob" {
+        t.Fatalf("unexpected dto %+v", dto)
+    }
+    raw, _ := json.Marshal(NewLink(models.Link{Alias: "wiki"}))
+    if s := string(raw); strings.Contains(s, "deleted") || strings.Contains(s, "Valid") || !strings.Contains(s, `"aliases":[]`) {
+        t.Fatalf("expected a live link without trash fields and with an empty alias list, got %s", s)
+    }
+}
+
+func TestNewLists(t *testing.T) {
+    for name, v := range map[string]any{"links": NewLinks(nil), "aliases": NewLinkAliases(nil), "users": NewUsers(nil), "revisions": NewRevisions(nil)} {
+        if raw, _ := json.Marshal(v); string(raw) != `{"items":[]}` { t.Errorf("%s: expected an empty items list, got %s", name, raw) }
+    }
+}
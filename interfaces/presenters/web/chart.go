@@ -0,0 +1,64 @@
+package web
+
+import (
+	"math"
+
+	"quickr/services"
+)
+
+// Chart is an SVG bar chart laid out ahead of time, since the templates have
+// no functions to do arithmetic with.
+type Chart struct {
+	Width, Height float64
+	Bars          []ChartBar
+	Max, Total    int64
+	// First and Last label the ends of the x axis
+	First, Last string
+}
+
+// ChartBar is one bar; Label and Clicks feed its tooltip.
+type ChartBar struct {
+	X, Y, Width, Height float64
+	Label               string
+	Clicks              int64
+}
+
+const (
+	chartWidth  = 720
+	chartHeight = 160
+	barGap      = 0.2 // share of each slot left empty between bars
+)
+
+// BuildChart scales points into bars; layout formats each point's time for its label.
+func BuildChart(points []services.ClickPoint, layout string) Chart {
+	ch := Chart{Width: chartWidth, Height: chartHeight, Bars: []ChartBar{}}
+	if len(points) == 0 {
+		return ch
+	}
+	for _, p := range points {
+		ch.Total += p.Clicks
+		if p.Clicks > ch.Max {
+			ch.Max = p.Clicks
+		}
+	}
+	slot := float64(chartWidth) / float64(len(points))
+	for i, p := range points {
+		h := 0.0
+		if ch.Max > 0 {
+			h = round2(float64(p.Clicks) / float64(ch.Max) * chartHeight)
+		}
+		ch.Bars = append(ch.Bars, ChartBar{
+			X:      round2(float64(i)*slot + slot*barGap/2),
+			Y:      round2(chartHeight - h),
+			Width:  round2(slot * (1 - barGap)),
+			Height: h,
+			Label:  p.Time.Format(layout),
+			Clicks: p.Clicks,
+		})
+	}
+	ch.First, ch.Last = ch.Bars[0].Label, ch.Bars[len(ch.Bars)-1].Label
+	return ch
+}
+
+// round2 keeps the SVG attributes short.
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
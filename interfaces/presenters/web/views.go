@@ -1,17 +1,23 @@
 package web
 
 import (
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
 	"quickr/models"
 	"quickr/services"
 )
 
-func HomeView(links []models.Link, email string, isAdmin bool) map[string]any {
+func HomeView(links []models.Link, email string, isAdmin bool, statusFilter string) map[string]any {
 	return map[string]any{
-		"title":     "Home",
-		"active":    "home",
-		"links":     links,
-		"userEmail": email,
-		"isAdmin":   isAdmin,
+		"title":        "Home",
+		"active":       "home",
+		"links":        links,
+		"userEmail":    email,
+		"isAdmin":      isAdmin,
+		"statusFilter": statusFilter,
 	}
 }
 
@@ -33,6 +39,7 @@ func HotView(hot services.HotStats, email string, isAdmin bool) map[string]any {
 	return map[string]any{
 		"active":    "hot",
 		"recent":    hot.Recent,
+		"trending":  hot.Trending,
 		"top7d":     hot.Top7d,
 		"top30d":    hot.Top30d,
 		"topAll":    hot.TopAll,
@@ -41,4 +48,193 @@ func HotView(hot services.HotStats, email string, isAdmin bool) map[string]any {
 	}
 }
 
+func TrashView(links []models.Link, email string, isAdmin bool, retentionDays int) map[string]any {
+	return map[string]any{
+		"title":         "Trash",
+		"active":        "trash",
+		"links":         links,
+		"retentionDays": retentionDays,
+		"userEmail":     email,
+		"isAdmin":       isAdmin,
+	}
+}
+
+// LinkDetailView shows a link's clicks per day over the last days days and
+// per hour over the last 24 hours.
+func LinkDetailView(link *models.Link, daily, hourly []services.ClickPoint, days int, email string, isAdmin bool) map[string]any {
+	return map[string]any{
+		"title":       link.Alias,
+		"active":      "",
+		"link":        link,
+		"days":        days,
+		"ranges":      []int{7, 30, 90},
+		"dailyChart":  BuildChart(daily, "Jan 2"),
+		"hourlyChart": BuildChart(hourly, "15:00"),
+		"userEmail":   email,
+		"isAdmin":     isAdmin,
+	}
+}
+
+// APITokenRow is a personal API token as listed on the settings page.
+type APITokenRow struct {
+	models.APIToken
+	Expired bool
+}
+
+// APITokensView lists tokens, flagging those past their expiry at now.
+func APITokensView(tokens []models.APIToken, now time.Time) map[string]any {
+	rows := make([]APITokenRow, 0, len(tokens))
+	for _, t := range tokens {
+		rows = append(rows, APITokenRow{APIToken: t, Expired: t.ExpiresAt != nil && !t.ExpiresAt.After(now)})
+	}
+	return map[string]any{"tokens": rows}
+}
+
+// SessionRow is a signed-in device as listed on the settings page; Current
+// marks the one the page was requested from.
+type SessionRow struct {
+	models.Session
+	Current bool
+}
+
+// SessionRows lists sessions, marking the one with ID current.
+func SessionRows(sessions []models.Session, current string) []SessionRow {
+	rows := make([]SessionRow, 0, len(sessions))
+	for _, s := range sessions {
+		rows = append(rows, SessionRow{Session: s, Current: s.ID == current})
+	}
+	return rows
+}
+
+func SettingsView(tokens []models.APIToken, now time.Time, email string, isAdmin bool) map[string]any {
+	view := APITokensView(tokens, now)
+	view["title"] = "Settings"
+	view["active"] = "settings"
+	view["userEmail"] = email
+	view["isAdmin"] = isAdmin
+	return view
+}
+
+// LinkFilters are the listing controls above the links table, as typed.
+type LinkFilters struct {
+	Q           string
+	Creator     string
+	CreatedFrom string
+	CreatedTo   string
+	Status      string
+	Sort        string
+	Order       string
+}
+
+// Query is the /api/search query string for these filters, continuing from
+// cursor when it is set.
+func (f LinkFilters) Query(cursor string) string {
+	v := url.Values{}
+	for k, s := range map[string]string{"q": f.Q, "creator": f.Creator, "created_from": f.CreatedFrom, "created_to": f.CreatedTo, "status": f.Status, "sort": f.Sort, "order": f.Order, "cursor": cursor} {
+		if s != "" {
+			v.Set(k, s)
+		}
+	}
+	return v.Encode()
+}
+
+// LinkRowsView is one page of the links table. nextPage loads the page after
+// it when the last row scrolls into view; empty is set when the first page
+// has no rows at all.
+func LinkRowsView(links []models.Link, filters LinkFilters, cursor, nextCursor string) map[string]any {
+	view := map[string]any{
+		"links":   links,
+		"filters": filters,
+		"empty":   len(links) == 0 && cursor == "",
+	}
+	if nextCursor != "" {
+		view["nextPage"] = "/api/search?" + filters.Query(nextCursor)
+	}
+	return view
+}
+
+// ConflictField is one field of a rejected edit next to its current value.
+type ConflictField struct {
+	Label   string
+	Theirs  string
+	Yours   string
+	Changed bool
+}
+
+// FormField is a hidden input the conflict view posts again on overwrite.
+type FormField struct {
+	Name  string
+	Value string
+}
 
+// LinkConflictView compares what an edit posted with the link as it is now.
+// resubmit re-posts the same edit against the current version; edits that
+// posted only the alias or only the URL keep the other as it is now, so the
+// overwrite always answers with a whole row.
+func LinkConflictView(current *models.Link, posted url.Values) map[string]any {
+	var fields []ConflictField
+	add := func(label, theirs, yours string) {
+		fields = append(fields, ConflictField{Label: label, Theirs: theirs, Yours: yours, Changed: theirs != yours})
+	}
+	if posted.Has("alias") {
+		add("Alias", current.Alias, posted.Get("alias"))
+	}
+	if posted.Has("url") {
+		add("URL", current.URL, posted.Get("url"))
+	}
+	if posted.Has("passthrough_form") {
+		add("Passthrough", passthroughLabel(current.Passthrough, current.QueryMerge), passthroughLabel(posted.Get("passthrough") == "on", posted.Get("query_merge")))
+	}
+	if posted.Has("schedule_form") {
+		add("Active from", formTime(current.ActiveFrom), posted.Get("active_from"))
+		add("Expires at", formTime(current.ExpiresAt), posted.Get("expires_at"))
+	}
+
+	names := make([]string, 0, len(posted))
+	for name := range posted {
+		if name != "version" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var resubmit []FormField
+	for _, name := range names {
+		for _, v := range posted[name] {
+			resubmit = append(resubmit, FormField{Name: name, Value: v})
+		}
+	}
+	if !posted.Has("alias") {
+		resubmit = append(resubmit, FormField{Name: "alias", Value: current.Alias})
+	}
+	if !posted.Has("url") {
+		resubmit = append(resubmit, FormField{Name: "url", Value: current.URL})
+	}
+	resubmit = append(resubmit, FormField{Name: "version", Value: strconv.FormatUint(uint64(current.Version), 10)})
+
+	return map[string]any{
+		"link":     current,
+		"fields":   fields,
+		"resubmit": resubmit,
+	}
+}
+
+func passthroughLabel(on bool, merge string) string {
+	if !on {
+		return "Off"
+	}
+	switch merge {
+	case "request":
+		return "On, visitor's query values win"
+	case "append":
+		return "On, duplicate query values kept"
+	}
+	return "On, link's query values win"
+}
+
+// formTime renders t like the modal's datetime-local inputs do.
+func formTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Local().Format("2006-01-02T15:04")
+}
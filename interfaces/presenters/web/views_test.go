@@ -1,14 +1,17 @@
 package web
 
 import (
+    "net/url"
     "testing"
+    "time"
     "quickr/models"
     "quickr/services"
 )
 
 func TestHomeView(t *testing.T) {
-    m := HomeView([]models.Link{{Alias: "a"}}, "user@example.com", true)
+    m := HomeView([]models.Link{{Alias: "a"}}, "user@example.com", true, "expired")
     if m["title"] != "Home" || m["active"] != "home" { t.Fatalf("unexpected meta: %+v", m) }
+    if m["statusFilter"] != "expired" { t.Fatalf("unexpected status filter: %+v", m["statusFilter"]) }
     if m["userEmail"] != "user@example.com" || m["isAdmin"] != true { t.Fatalf("unexpected user fields") }
 }
 
@@ -26,4 +29,36 @@ func TestHotView(t *testing.T) {
     if m["active"] != "hot" { t.Fatalf("unexpected active: %+v", m) }
 }
 
+func TestTrashView(t *testing.T) {
+    m := TrashView([]models.Link{{Alias: "gone"}}, "u", false, 30)
+    if m["active"] != "trash" || m["retentionDays"] != 30 { t.Fatalf("unexpected trash view: %+v", m) }
+}
+
+func TestBuildChart(t *testing.T) {
+    start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+    points := []services.ClickPoint{{Time: start, Clicks: 2}, {Time: start.AddDate(0, 0, 1), Clicks: 0}, {Time: start.AddDate(0, 0, 2), Clicks: 4}}
+    ch := BuildChart(points, "Jan 2")
+    if ch.Total != 6 || ch.Max != 4 || len(ch.Bars) != 3 || ch.First != "Mar 1" || ch.Last != "Mar 3" { t.Fatalf("unexpected chart %+v", ch) }
+    if ch.Bars[2].Height != ch.Height || ch.Bars[2].Y != 0 { t.Fatalf("expected the peak to fill the chart, got %+v", ch.Bars[2]) }
+    if ch.Bars[0].Height != ch.Height/2 || ch.Bars[1].Height != 0 { t.Fatalf("expected bars scaled to the peak, got %+v", ch.Bars) }
+    if ch.Bars[1].X <= ch.Bars[0].X+ch.Bars[0].Width { t.Fatalf("expected a gap between bars, got %+v", ch.Bars) }
+    if empty := BuildChart(nil, "Jan 2"); len(empty.Bars) != 0 || empty.Max != 0 { t.Fatalf("unexpected empty chart %+v", empty) }
+}
+
+func TestLinkDetailView(t *testing.T) {
+    m := LinkDetailView(&models.Link{Alias: "vpn"}, nil, nil, 7, "u", false)
+    if m["title"] != "vpn" || m["days"] != 7 { t.Fatalf("unexpected detail view: %+v", m) }
+}
 
+func TestLinkConflictView(t *testing.T) {
+    current := &models.Link{ID: 4, Alias: "vpn", URL: "https://theirs.example.com", Version: 3}
+    m := LinkConflictView(current, url.Values{"url": {"https://mine.example.com"}, "version": {"2"}})
+    fields := m["fields"].([]ConflictField)
+    if len(fields) != 1 || fields[0] != (ConflictField{Label: "URL", Theirs: "https://theirs.example.com", Yours: "https://mine.example.com", Changed: true}) {
+        t.Fatalf("expected only the posted URL compared, got %+v", fields)
+    }
+    want := []FormField{{"url", "https://mine.example.com"}, {"alias", "vpn"}, {"version", "3"}}
+    if got := m["resubmit"].([]FormField); len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+        t.Fatalf("expected the edit re-posted against version 3 with the current alias, got %+v", got)
+    }
+}
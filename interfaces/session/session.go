@@ -8,48 +8,65 @@ import (
 
     "github.com/gin-gonic/gin"
     "github.com/golang-jwt/jwt/v5"
+    "quickr/models"
 )
 
+// Claims name the user (Subject), their stored session (ID) and their
+// session generation at sign-in. The role is not part of the cookie; it is
+// loaded with the user on every request so role changes apply at once.
 type Claims struct {
-    Role string `json:"role"`
+    Generation uint `json:"gen,omitempty"`
     jwt.RegisteredClaims
 }
 
+// Store keeps the server side of each session. A cookie is only honoured
+// while the session it names is in the store, so deleting it signs that
+// browser out even though the cookie has not expired.
+type Store interface {
+    Create(userID uint, userAgent, ip string, expiresAt time.Time) (id string, err error)
+    Validate(id string) error
+    Revoke(id string) error
+}
+
 type Manager struct {
     secret     []byte
     cookieName string
     maxAge     time.Duration
+    store      Store
 }
 
-func NewManager(secret []byte, cookieName string, maxAge time.Duration) *Manager {
-    return &Manager{secret: secret, cookieName: cookieName, maxAge: maxAge}
+func NewManager(secret []byte, cookieName string, maxAge time.Duration, store Store) *Manager {
+    return &Manager{secret: secret, cookieName: cookieName, maxAge: maxAge, store: store}
 }
 
 // Interface for handlers to depend on
 type Service interface {
-    Parse(c *gin.Context) (email string, role string, err error)
-    SignIn(c *gin.Context, email string, role string) error
+    Parse(c *gin.Context) (*Claims, error)
+    SignIn(c *gin.Context, user *models.User) error
     Clear(c *gin.Context)
 }
 
-func (m *Manager) Parse(c *gin.Context) (string, string, error) {
-    cookie, err := c.Cookie(m.cookieName)
+// Parse verifies the session cookie and that its session is still stored.
+func (m *Manager) Parse(c *gin.Context) (*Claims, error) {
+    claims, err := m.claims(c)
     if err != nil {
-        return "", "", err
+        return nil, err
     }
-    token, err := jwt.ParseWithClaims(cookie, &Claims{}, func(token *jwt.Token) (interface{}, error) { return m.secret, nil })
-    if err != nil || !token.Valid {
-        return "", "", errors.New("invalid session")
+    if err := m.store.Validate(claims.ID); err != nil {
+        return nil, errors.New("invalid session")
     }
-    claims, ok := token.Claims.(*Claims)
-    if !ok {
-        return "", "", errors.New("invalid session")
-    }
-    return claims.Subject, claims.Role, nil
+    return claims, nil
 }
 
-func (m *Manager) SignIn(c *gin.Context, email string, role string) error {
-    claims := &Claims{Role: role, RegisteredClaims: jwt.RegisteredClaims{Subject: email, IssuedAt: jwt.NewNumericDate(time.Now()), ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.maxAge))}}
+// SignIn stores a new session for user and sets its cookie.
+func (m *Manager) SignIn(c *gin.Context, user *models.User) error {
+    now := time.Now()
+    id, err := m.store.Create(user.ID, c.Request.UserAgent(), c.ClientIP(), now.Add(m.maxAge))
+    if err != nil {
+        log.Println("failed to store session:", err)
+        return errors.New("failed to start session")
+    }
+    claims := &Claims{Generation: user.SessionGeneration, RegisteredClaims: jwt.RegisteredClaims{ID: id, Subject: user.Email, IssuedAt: jwt.NewNumericDate(now), ExpiresAt: jwt.NewNumericDate(now.Add(m.maxAge))}}
     jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
     signed, err := jwtToken.SignedString(m.secret)
     if err != nil {
@@ -61,8 +78,29 @@ func (m *Manager) SignIn(c *gin.Context, email string, role string) error {
     return nil
 }
 
+// Clear revokes the stored session, if the cookie names one, and removes the cookie.
 func (m *Manager) Clear(c *gin.Context) {
+    if claims, err := m.claims(c); err == nil && claims.ID != "" {
+        if err := m.store.Revoke(claims.ID); err != nil {
+            log.Println("failed to revoke session:", err)
+        }
+    }
     c.SetCookie(m.cookieName, "", -1, "/", "", true, true)
 }
 
-
+// claims reads the cookie and checks its signature and expiry.
+func (m *Manager) claims(c *gin.Context) (*Claims, error) {
+    cookie, err := c.Cookie(m.cookieName)
+    if err != nil {
+        return nil, err
+    }
+    token, err := jwt.ParseWithClaims(cookie, &Claims{}, func(token *jwt.Token) (interface{}, error) { return m.secret, nil })
+    if err != nil || !token.Valid {
+        return nil, errors.New("invalid session")
+    }
+    claims, ok := token.Claims.(*Claims)
+    if !ok {
+        return nil, errors.New("invalid session")
+    }
+    return claims, nil
+}
@@ -1,40 +1,61 @@
 package session
 
 import (
+    "errors"
+    "fmt"
+    "net/http"
     "net/http/httptest"
     "testing"
     "time"
 
     "github.com/gin-gonic/gin"
+    "quickr/models"
 )
 
+// memStore keeps sessions in memory by ID.
+type memStore map[string]uint
+
+func (m memStore) Create(userID uint, userAgent, ip string, expiresAt time.Time) (string, error) {
+    id := fmt.Sprintf("s%d", len(m)+1)
+    m[id] = userID
+    return id, nil
+}
+func (m memStore) Validate(id string) error {
+    if _, ok := m[id]; !ok { return errors.New("no such session") }
+    return nil
+}
+func (m memStore) Revoke(id string) error { delete(m, id); return nil }
+
 func TestSession_SignIn_Parse_Clear(t *testing.T) {
     gin.SetMode(gin.TestMode)
-    m := NewManager([]byte("secret"), "session", time.Hour)
+    store := memStore{}
+    m := NewManager([]byte("secret"), "session", time.Hour, store)
+    withCookies := func(cookies []*http.Cookie) (*gin.Context, *httptest.ResponseRecorder) {
+        r := httptest.NewRequest("GET", "/", nil)
+        for _, ck := range cookies { r.AddCookie(ck) }
+        w := httptest.NewRecorder()
+        c, _ := gin.CreateTestContext(w)
+        c.Request = r
+        return c, w
+    }
 
-    // SignIn sets cookie
-    w := httptest.NewRecorder()
-    c, _ := gin.CreateTestContext(w)
-    if err := m.SignIn(c, "user@example.com", "admin"); err != nil { t.Fatalf("signin error: %v", err) }
+    // SignIn stores a session and sets its cookie
+    c, w := withCookies(nil)
+    if err := m.SignIn(c, &models.User{ID: 7, Email: "user@example.com", SessionGeneration: 3}); err != nil { t.Fatalf("signin error: %v", err) }
     cookies := w.Result().Cookies()
-    if len(cookies) == 0 { t.Fatalf("expected a cookie to be set") }
+    if len(cookies) == 0 || store["s1"] != 7 { t.Fatalf("expected a stored session and a cookie, got %v %v", store, cookies) }
 
     // Parse reads cookie
-    r := httptest.NewRequest("GET", "/", nil)
-    for _, ck := range cookies { r.AddCookie(ck) }
-    w2 := httptest.NewRecorder()
-    c2, _ := gin.CreateTestContext(w2)
-    c2.Request = r
-    email, role, err := m.Parse(c2)
-    if err != nil || email != "user@example.com" || role != "admin" { t.Fatalf("unexpected parse: %q %q %v", email, role, err) }
-
-    // Clear removes cookie
-    w3 := httptest.NewRecorder()
-    c3, _ := gin.CreateTestContext(w3)
+    c2, _ := withCookies(cookies)
+    claims, err := m.Parse(c2)
+    if err != nil || claims.Subject != "user@example.com" || claims.Generation != 3 || claims.ID != "s1" { t.Fatalf("unexpected parse: %+v %v", claims, err) }
+
+    // Clear revokes the session and removes the cookie
+    c3, w3 := withCookies(cookies)
     m.Clear(c3)
     if cleared := w3.Result().Cookies(); len(cleared) == 0 {
         t.Fatalf("expected clear to set expired cookie")
     }
+    c4, _ := withCookies(cookies)
+    if _, err := m.Parse(c4); err == nil || len(store) != 0 { t.Fatalf("expected the cleared cookie to stop working, got %v %v", err, store) }
 }
-
-
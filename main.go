@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"html/template"
 	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -56,10 +63,19 @@ func main() {
 	loadTemplates(r)
 	mountStatic(r)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	h := wireHandlers(db)
+	logAdminSetupLink(h)
+	warnReservedAliases(h)
 	registerRoutes(r, h)
+	startSweeper(h.LinkService)
+	startTrashPurger(h.LinkService, h.TrashRetentionDays)
+	flushClicks := startClickBuffer(h.ClickBuffer)
 
-	start(r)
+	serve(ctx, r)
+	flushClicks()
 }
 
 func warnEnv() {
@@ -77,7 +93,7 @@ func must(err error) {
 
 func mustDB() *gorm.DB {
 	dbPath := filepath.Join("data", "quickr.db")
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(repositories.SQLiteDSN(dbPath)), &gorm.Config{})
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
@@ -85,9 +101,35 @@ func mustDB() *gorm.DB {
 }
 
 func mustMigrate(db *gorm.DB) {
-	if err := db.AutoMigrate(&models.Link{}, &models.User{}, &models.Invitation{}); err != nil {
+	if err := repositories.PrepareLiveAliasIndex(db); err != nil {
+		log.Fatal("Failed to prepare the alias index: ", err)
+	}
+	dropped, err := repositories.PrepareSecondaryAliasIndex(db)
+	if err != nil {
+		log.Fatal("Failed to prepare the secondary alias index: ", err)
+	}
+	if dropped > 0 {
+		log.Printf("Dropped %d secondary alias(es) of trashed links already used by another link", dropped)
+	}
+	if err := db.AutoMigrate(&models.Link{}, &models.LinkRevision{}, &models.LinkCoOwner{}, &models.LinkAlias{}, &models.AliasHistory{}, &models.ClickEvent{}, &models.User{}, &models.Invitation{}, &models.APIToken{}, &models.UserEvent{}, &models.Session{}); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
+	if err := repositories.BackfillLinkAuthors(db, os.Getenv("ADMIN_EMAIL"), getenvDefault("ADMIN_NAME", "Admin")); err != nil {
+		log.Fatal("Failed to backfill link authors:", err)
+	}
+}
+
+// warnReservedAliases names links whose alias a newer page has since taken
+// over; they keep working under /go/ but no longer at the root.
+func warnReservedAliases(h *handlers.AppHandler) {
+	taken, err := h.LinkService.ReservedAliasesInUse()
+	if err != nil {
+		log.Printf("Alias warning: could not check for reserved aliases: %v", err)
+		return
+	}
+	if len(taken) > 0 {
+		log.Printf("Alias warning: reserved for quickr pages, so these links only redirect under /go/ until renamed: %s", strings.Join(taken, ", "))
+	}
 }
 
 func newRouter() *gin.Engine {
@@ -121,15 +163,165 @@ func wireHandlers(db *gorm.DB) *handlers.AppHandler {
 	rateLimiter := ratelimit.NewIPLimiter(20) // 20 requests per minute per IP for login
 	appBaseURL := getenvDefault("APP_BASE_URL", "http://localhost:8080")
 
-	linkRepo := repositories.NewGormLinkRepository(db)
-	userRepo := repositories.NewGormUserRepository(db)
+	clickBuffer := newClickBuffer(db)
+	linkRepo := newAliasCache(repositories.NewGormLinkRepository(db))
+	// Every signed-in request loads its user; writes through the cache apply at once
+	userRepo := repositories.NewCachedUserRepository(repositories.NewGormUserRepository(db), time.Minute)
 	invRepo := repositories.NewGormInvitationRepository(db)
-	linkService := services.NewLinkService(linkRepo)
+	revRepo := repositories.NewGormLinkRevisionRepository(db)
+	coOwnerRepo := repositories.NewGormLinkCoOwnerRepository(db)
+	linkAliasRepo := repositories.NewGormLinkAliasRepository(db)
+	aliasHistoryRepo := repositories.NewGormAliasHistoryRepository(db)
+	linkService := services.NewLinkService(linkRepo,
+		services.WithRevisions(revRepo),
+		services.WithCoOwners(coOwnerRepo),
+		services.WithUsers(userRepo),
+		services.WithLinkAliases(linkAliasRepo),
+		services.WithAliasHistory(aliasHistoryRepo, aliasForwardTTL()),
+		services.WithAdminName(getenvDefault("ADMIN_NAME", "Admin")),
+		services.WithBufferedClicks(clickBuffer),
+		services.WithAliasCache(linkRepo),
+		services.WithTransactions(repositories.NewGormLinkTransactor(db)),
+	)
 	authService := services.NewAuthService(userRepo, invRepo, emailSender, appBaseURL, nil)
-	statsService := services.NewStatsService(linkService)
+	statsService := services.NewStatsService(linkService,
+		services.WithClickEvents(repositories.NewGormClickEventRepository(db), clickHashSalt()),
+		services.WithBufferedClickEvents(clickBuffer),
+	)
 	jwtSecret := os.Getenv("JWT_SECRET")
-	sess := session.NewManager([]byte(jwtSecret), "session", 180*24*60*60*1e9)
-	return handlers.NewAppHandler(linkService, authService, statsService, rateLimiter, appBaseURL, sess)
+	sessionService := services.NewSessionService(repositories.NewGormSessionRepository(db))
+	sess := session.NewManager([]byte(jwtSecret), "session", 180*24*60*60*1e9, sessionService)
+	h := handlers.NewAppHandler(linkService, authService, statsService, rateLimiter, appBaseURL, sess)
+	h.TrashRetentionDays = trashRetentionDays()
+	h.ClickBuffer = clickBuffer
+	h.TokenService = services.NewTokenService(repositories.NewGormAPITokenRepository(db))
+	h.UserService = services.NewUserService(userRepo, invRepo, repositories.NewGormUserEventRepository(db))
+	h.SessionService = sessionService
+	return h
+}
+
+// logAdminSetupLink prints a one-time sign-in link for ADMIN_EMAIL while no
+// admin account exists yet, so the first admin can sign in without mail.
+func logAdminSetupLink(h *handlers.AppHandler) {
+	link, err := h.AuthService.AdminSetupLink(os.Getenv("ADMIN_EMAIL"), h.AppBaseURL)
+	if err != nil {
+		log.Printf("[SETUP] could not create the admin setup link: %v", err)
+		return
+	}
+	if link != "" {
+		log.Printf("[SETUP] No admin account yet. Open this link within 24 hours to sign in as %s; it works once: %s", os.Getenv("ADMIN_EMAIL"), link)
+	}
+}
+
+// newAliasCache puts a cache of ALIAS_CACHE_SIZE (default 10000; 0 disables
+// it) alias lookups, each kept for ALIAS_CACHE_TTL (default 5m), in front of
+// repo and fills it with the ALIAS_CACHE_WARM (default 1000) most clicked links.
+func newAliasCache(repo repositories.LinkRepository) *repositories.CachedLinkRepository {
+	size, err := strconv.Atoi(getenvDefault("ALIAS_CACHE_SIZE", "10000"))
+	if err != nil || size < 0 {
+		log.Printf("Config warning: invalid ALIAS_CACHE_SIZE; defaulting to 10000")
+		size = 10000
+	}
+	ttl, err := time.ParseDuration(getenvDefault("ALIAS_CACHE_TTL", "5m"))
+	if err != nil || ttl <= 0 {
+		log.Printf("Config warning: invalid ALIAS_CACHE_TTL; defaulting to 5m")
+		ttl = 5 * time.Minute
+	}
+	warm, err := strconv.Atoi(getenvDefault("ALIAS_CACHE_WARM", "1000"))
+	if err != nil || warm < 0 {
+		log.Printf("Config warning: invalid ALIAS_CACHE_WARM; defaulting to 1000")
+		warm = 1000
+	}
+	cache := repositories.NewCachedLinkRepository(repo, size, ttl)
+	if size > 0 && warm > 0 {
+		if n, err := cache.Warm(min(warm, size)); err != nil {
+			log.Printf("[CACHE] warmup failed: %v", err)
+		} else {
+			log.Printf("[CACHE] warmed alias cache with %d link(s)", n)
+		}
+	}
+	return cache
+}
+
+// newClickBuffer batches click writes: every CLICK_FLUSH_INTERVAL (default 1s)
+// or once CLICK_FLUSH_BATCH (default 500) click events are waiting.
+func newClickBuffer(db *gorm.DB) *services.ClickBuffer {
+	interval, err := time.ParseDuration(getenvDefault("CLICK_FLUSH_INTERVAL", "1s"))
+	if err != nil || interval <= 0 {
+		log.Printf("Config warning: invalid CLICK_FLUSH_INTERVAL; defaulting to 1s")
+		interval = time.Second
+	}
+	batch, err := strconv.Atoi(getenvDefault("CLICK_FLUSH_BATCH", "500"))
+	if err != nil || batch <= 0 {
+		log.Printf("Config warning: invalid CLICK_FLUSH_BATCH; defaulting to 500")
+		batch = 500
+	}
+	return services.NewClickBuffer(repositories.NewGormClickBatchRepository(db), interval, batch)
+}
+
+// startClickBuffer flushes buffered clicks in the background. The returned
+// func stops it and waits for the final flush.
+func startClickBuffer(buf *services.ClickBuffer) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// startSweeper stamps expired links in the background. With
+// LINK_EXPIRY_FREE_ALIAS=true expired links are also soft-deleted so their
+// alias can be claimed again.
+func startSweeper(links *services.LinkService) {
+	interval, err := time.ParseDuration(getenvDefault("LINK_SWEEP_INTERVAL", "1m"))
+	if err != nil || interval <= 0 {
+		log.Printf("Config warning: invalid LINK_SWEEP_INTERVAL; defaulting to 1m")
+		interval = time.Minute
+	}
+	freeAlias := os.Getenv("LINK_EXPIRY_FREE_ALIAS") == "true"
+	go links.RunExpirySweeper(context.Background(), interval, freeAlias)
+}
+
+// trashRetentionDays reads TRASH_RETENTION_DAYS (default 30); 0 keeps deleted links forever.
+func trashRetentionDays() int {
+	days, err := strconv.Atoi(getenvDefault("TRASH_RETENTION_DAYS", "30"))
+	if err != nil || days < 0 {
+		log.Printf("Config warning: invalid TRASH_RETENTION_DAYS; defaulting to 30")
+		return 30
+	}
+	return days
+}
+
+// aliasForwardTTL reads ALIAS_FORWARD_DAYS (default 0): how long a renamed
+// alias keeps redirecting to its link; 0 forwards forever.
+func aliasForwardTTL() time.Duration {
+	days, err := strconv.Atoi(getenvDefault("ALIAS_FORWARD_DAYS", "0"))
+	if err != nil || days < 0 {
+		log.Printf("Config warning: invalid ALIAS_FORWARD_DAYS; defaulting to 0")
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// clickHashSalt reads CLICK_HASH_SALT, falling back to JWT_SECRET, to hash client IPs in the click log.
+func clickHashSalt() string {
+	if salt := os.Getenv("CLICK_HASH_SALT"); salt != "" {
+		return salt
+	}
+	return os.Getenv("JWT_SECRET")
+}
+
+// startTrashPurger hourly hard-deletes links that have been in the trash longer than the retention period.
+func startTrashPurger(links *services.LinkService, days int) {
+	if days == 0 {
+		return
+	}
+	go links.RunTrashPurger(context.Background(), time.Hour, time.Duration(days)*24*time.Hour)
 }
 
 func registerRoutes(r *gin.Engine, h *handlers.AppHandler) {
@@ -137,37 +329,60 @@ func registerRoutes(r *gin.Engine, h *handlers.AppHandler) {
 	r.GET("/login", h.ShowLogin())
 	r.POST("/login", h.RequestMagicLink())
 	r.GET("/magic", h.RedeemMagicLink())
-	r.POST("/logout", handlers.Logout())
+	r.POST("/logout", h.Logout())
 
 	// Web routes (require auth)
 	r.GET("/", h.RequireAuth(), h.HandleHome())
 	r.GET("/stats", h.RequireAuth(), h.HandleStats())
 	r.GET("/hot", h.RequireAuth(), h.HandleHot())
+	r.GET("/trash", h.RequireAuth(), h.HandleTrash())
+	r.GET("/links/:id", h.RequireAuth(), h.HandleLinkDetail())
+	r.GET("/settings", h.RequireAuth(), h.HandleSettings())
+	r.POST("/settings/tokens", h.RequireAuth(), h.CreateAPIToken())
+	r.DELETE("/settings/tokens/:id", h.RequireAuth(), h.RevokeAPIToken())
+	r.DELETE("/settings/sessions", h.RequireAuth(), h.RevokeAllSessions())
+	r.DELETE("/settings/sessions/:id", h.RequireAuth(), h.RevokeSession())
 
 	// Redirect route with debug handler (keep public)
-	r.GET("/go/:alias", func(c *gin.Context) {
+	goRedirect := func(c *gin.Context) {
 		log.Printf("[DEBUG] About to call redirect handler for alias: %s", c.Param("alias"))
 		h.HandleRedirect()(c)
-	})
+	}
+	r.GET("/go/:alias", goRedirect)
+	// Extra segments feed template links, e.g. /go/jira/PROJ-123
+	r.GET("/go/:alias/*rest", goRedirect)
 
 	// Root-level alias redirect. Must come after fixed routes.
-	r.GET("/:alias", func(c *gin.Context) {
+	rootRedirect := func(c *gin.Context) {
 		alias := c.Param("alias")
 		if reserved.IsReservedAlias(alias) {
+			if isAPIv1(c) {
+				handlers.NotFoundV1()(c)
+				return
+			}
 			c.Status(http.StatusNotFound)
 			return
 		}
 		h.HandleRedirect()(c)
-	})
+	}
+	r.GET("/:alias", rootRedirect)
+	r.GET("/:alias/*rest", rootRedirect)
 
 	// Admin routes
-	admin := r.Group("/admin", h.RequireAuth(), h.RequireAdmin())
+	admin := r.Group("/admin", h.RequireAuth(), h.RequireSession(), h.RequireAdmin())
 	{
 		admin.GET("", h.AdminDashboard())
 		admin.POST("/invitations", h.CreateInvitation())
 		admin.POST("/invitations/:id/send", h.SendInvitation())
 		admin.POST("/invitations/:id/revoke", h.RevokeInvitation())
 		admin.POST("/invitations/revoke-email", h.RevokeInvitationsByEmail())
+		admin.GET("/metrics", h.AdminMetrics())
+		admin.GET("/users", h.AdminUsers())
+		admin.POST("/users/:id/role", h.SetUserRole())
+		admin.POST("/users/:id/disable", h.DisableUser())
+		admin.POST("/users/:id/enable", h.EnableUser())
+		admin.POST("/users/:id/logout", h.SignOutUser())
+		admin.DELETE("/users/:id", h.DeleteUser())
 	}
 
 	// API routes (require auth)
@@ -181,14 +396,63 @@ func registerRoutes(r *gin.Engine, h *handlers.AppHandler) {
 		api.GET("/links/:id/edit", h.GetLinkEditField())
 		api.PUT("/links/:id", h.UpdateLink())
 		api.DELETE("/links/:id", h.DeleteLink())
+		api.POST("/links/:id/restore", h.RestoreLink())
+		api.GET("/links/:id/revisions", h.ListLinkRevisions())
+		api.POST("/links/:id/revisions/:revisionID/restore", h.RestoreLinkRevision())
+		api.GET("/links/:id/aliases", h.ListLinkAliases())
+		api.POST("/links/:id/aliases", h.AddLinkAlias())
+		api.DELETE("/links/:id/aliases/:alias", h.RemoveLinkAlias())
+		api.GET("/links/:id/co-owners", h.ListCoOwners())
+		api.POST("/links/:id/co-owners", h.AddCoOwner())
+		api.DELETE("/links/:id/co-owners/:userID", h.RemoveCoOwner())
 		api.GET("/search", h.SearchLinks())
 	}
+
+	// Versioned JSON API (session cookie or bearer token)
+	v1 := r.Group("/api/v1", h.RequireAPIAuth())
+	{
+		v1.GET("/links", h.ListLinksV1())
+		v1.POST("/links", h.CreateLinkV1())
+		v1.GET("/links/:id", h.GetLinkV1())
+		v1.PATCH("/links/:id", h.UpdateLinkV1())
+		v1.DELETE("/links/:id", h.DeleteLinkV1())
+		v1.POST("/links/:id/restore", h.RestoreLinkV1())
+		v1.GET("/links/:id/revisions", h.ListLinkRevisionsV1())
+		v1.POST("/links/:id/revisions/:revisionID/restore", h.RestoreLinkRevisionV1())
+		v1.GET("/links/:id/aliases", h.ListLinkAliasesV1())
+		v1.POST("/links/:id/aliases", h.AddLinkAliasV1())
+		v1.DELETE("/links/:id/aliases/:alias", h.RemoveLinkAliasV1())
+		v1.GET("/links/:id/co-owners", h.ListCoOwnersV1())
+		v1.POST("/links/:id/co-owners", h.AddCoOwnerV1())
+		v1.DELETE("/links/:id/co-owners/:userID", h.RemoveCoOwnerV1())
+	}
+	r.NoRoute(func(c *gin.Context) {
+		if isAPIv1(c) {
+			handlers.NotFoundV1()(c)
+		}
+	})
 }
 
-func start(r *gin.Engine) {
-	log.Printf("Server starting on http://localhost:8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+// isAPIv1 reports whether an unmatched request was meant for /api/v1, whose
+// clients expect a JSON error rather than gin's plain text 404.
+func isAPIv1(c *gin.Context) bool { return strings.HasPrefix(c.Request.URL.Path, "/api/v1/") }
+
+// serve runs the server until ctx is cancelled, then lets in-flight requests
+// finish for up to 10 seconds.
+func serve(ctx context.Context, r *gin.Engine) {
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		log.Printf("Server starting on http://localhost:8080")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+	<-ctx.Done()
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[ERROR] Graceful shutdown failed: %v", err)
 	}
 }
 
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AliasHistory keeps a link's previous alias forwarding to it after a rename.
+// ExpiresAt ends the forwarding; nil forwards forever.
+type AliasHistory struct {
+	ID        uint   `gorm:"primarykey"`
+	LinkID    uint   `gorm:"index;not null"`
+	Alias     string `gorm:"index;not null"`
+	RetiredBy *uint
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
+
+func (AliasHistory) TableName() string { return "alias_history" }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// APIToken is a personal access token for scripts and the browser extension.
+// Only the SHA-256 of the secret is stored; Prefix keeps its first characters
+// so the owner can tell tokens apart. Scopes is a comma separated list of
+// read and write.
+type APIToken struct {
+	ID         uint       `gorm:"primarykey"`
+	UserID     uint       `gorm:"index;not null"`
+	User       User       `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Name       string     `gorm:"not null"`
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	Prefix     string     `gorm:"not null"`
+	Scopes     string     `gorm:"not null;default:read"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ClickEvent is one redirect through a link. The client IP is only stored
+// hashed and the referrer only as its host.
+type ClickEvent struct {
+	ID           uint      `gorm:"primarykey"`
+	LinkID       uint      `gorm:"index:idx_click_link_time,priority:1;not null"`
+	CreatedAt    time.Time `gorm:"index:idx_click_link_time,priority:2;index"`
+	IPHash       string
+	UAFamily     string
+	ReferrerHost string
+}
@@ -8,10 +8,40 @@ import (
 
 type Link struct {
 	ID          uint           `gorm:"primarykey"`
-	Alias       string         `gorm:"uniqueIndex:idx_alias_deleted;not null"`
+	// Alias is unique among live links; trashed links may share it
+	Alias       string         `gorm:"uniqueIndex:idx_links_live_alias,where:deleted_at IS NULL;not null"`
 	URL         string         `gorm:"not null"`
-	Clicks      uint           `gorm:"default:0"`
-	CreatorName string         `gorm:"not null"`
-	CreatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"uniqueIndex:idx_alias_deleted"`
-}
\ No newline at end of file
+	// Aliases are the link's secondary aliases, oldest first
+	Aliases     []LinkAlias    `gorm:"foreignKey:LinkID"`
+	Clicks      uint           `gorm:"default:0;index"`
+	// CreatorName is the creator's email at creation time; older admin links
+	// hold ADMIN_NAME instead. CreatedBy and UpdatedBy reference the users
+	// behind the first and the latest edit.
+	CreatorName string         `gorm:"not null;index"`
+	CreatedBy   *uint          `gorm:"index"`
+	UpdatedBy   *uint          `gorm:"index"`
+	Creator     *User          `gorm:"foreignKey:CreatedBy;constraint:OnDelete:SET NULL" json:"-"`
+	Updater     *User          `gorm:"foreignKey:UpdatedBy;constraint:OnDelete:SET NULL" json:"-"`
+	// Display names resolved from Creator/Updater on read
+	CreatedByName string       `gorm:"-"`
+	UpdatedByName string       `gorm:"-"`
+	// Passthrough forwards extra path segments and the query string to URL.
+	// QueryMerge picks the winner for duplicate query keys: target | request | append
+	Passthrough bool           `gorm:"not null;default:false"`
+	QueryMerge  string         `gorm:"not null;default:target"`
+	// Optional activation window. ExpiredAt is stamped by the expiry sweeper.
+	ActiveFrom  *time.Time     `gorm:"index"`
+	ExpiresAt   *time.Time     `gorm:"index"`
+	ExpiredAt   *time.Time
+	// Status is derived from the window on read: active | scheduled | expired
+	Status      string         `gorm:"-"`
+	CreatedAt   time.Time      `gorm:"index"`
+	UpdatedAt   time.Time
+	// Version goes up on every save; edits made against an older version are rejected
+	Version     uint           `gorm:"not null;default:1"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	// DeletedBy references who moved the link to the trash; nil when the expiry sweeper did
+	DeletedBy     *uint        `gorm:"index"`
+	Deleter       *User        `gorm:"foreignKey:DeletedBy;constraint:OnDelete:SET NULL" json:"-"`
+	DeletedByName string       `gorm:"-"`
+}
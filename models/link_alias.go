@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// LinkAlias is an extra alias that redirects to the same link as its primary
+// alias, sharing its clicks and history. Secondary aliases are unique among
+// themselves in the database; collisions with primary aliases, which live in
+// the links table, are checked by the application.
+type LinkAlias struct {
+	ID        uint   `gorm:"primarykey"`
+	LinkID    uint   `gorm:"index;not null"`
+	Alias     string `gorm:"uniqueIndex:idx_link_aliases_unique_alias;not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LinkCoOwner grants a user the same edit and delete rights on a link as its creator.
+type LinkCoOwner struct {
+	LinkID    uint      `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false;index"`
+	User      User      `gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// LinkRevision records one change to a link: who made it, when, and the
+// link's state before and after as JSON snapshots.
+// Action: create | update | delete | restore
+// BeforeState is empty for creates, AfterState is empty for deletes.
+type LinkRevision struct {
+	ID          uint      `gorm:"primarykey"`
+	LinkID      uint      `gorm:"index;not null"`
+	Action      string    `gorm:"not null"`
+	Actor       string    `gorm:"not null"`
+	ActorID     *uint     `gorm:"index"`
+	BeforeState string
+	AfterState  string
+	CreatedAt   time.Time `gorm:"index"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session is a signed-in browser. Its ID is carried in the session cookie, so
+// deleting the row signs that browser out. UserAgent and IP are recorded at
+// sign-in to help the user tell their devices apart.
+type Session struct {
+	ID         string `gorm:"primarykey"`
+	UserID     uint   `gorm:"index;not null"`
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time `gorm:"index"`
+}
@@ -11,4 +11,7 @@ type User struct {
 	CreatedAt time.Time
 	LastLogin time.Time
 	Disabled  bool      `gorm:"not null;default:false"`
+	// SessionGeneration is stamped into session cookies at sign-in; raising it
+	// signs the user out everywhere
+	SessionGeneration uint `gorm:"not null;default:0"`
 }
\ No newline at end of file
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// UserEvent records a change to a user's access made from the admin
+// dashboard: Action is disabled, enabled, deleted, promoted or demoted. Email
+// and Actor (the admin's email) are kept as text so the history outlives the
+// accounts. Detail adds context, e.g. who a deleted user's links went to.
+type UserEvent struct {
+	ID        uint      `gorm:"primarykey"`
+	Email     string    `gorm:"index;not null"`
+	Action    string    `gorm:"not null"`
+	Actor     string    `gorm:"not null"`
+	Detail    string
+	CreatedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+type AliasHistoryRepository interface {
+    Create(entry *models.AliasHistory) error
+    FindActive(alias string, now time.Time) (*models.AliasHistory, error)
+    DeleteByAlias(alias string) error
+}
+
+type GormAliasHistoryRepository struct { db *gorm.DB }
+
+func NewGormAliasHistoryRepository(db *gorm.DB) *GormAliasHistoryRepository { return &GormAliasHistoryRepository{db: db} }
+
+func (r *GormAliasHistoryRepository) Create(entry *models.AliasHistory) error { return r.db.Create(entry).Error }
+
+// FindActive returns the most recent unexpired forwarding for alias
+func (r *GormAliasHistoryRepository) FindActive(alias string, now time.Time) (*models.AliasHistory, error) {
+    var entry models.AliasHistory
+    err := r.db.Where("alias = ? AND (expires_at IS NULL OR expires_at > ?)", alias, now).
+        Order("created_at desc, id desc").First(&entry).Error
+    if err != nil { return nil, err }
+    return &entry, nil
+}
+
+func (r *GormAliasHistoryRepository) DeleteByAlias(alias string) error {
+    return r.db.Where("alias = ?", alias).Delete(&models.AliasHistory{}).Error
+}
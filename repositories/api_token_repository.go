@@ -0,0 +1,49 @@
+package repositories
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "quickr/models"
+)
+
+type APITokenRepository interface {
+    Create(token *models.APIToken) error
+    ListByUser(userID uint) ([]models.APIToken, error)
+    FindByHash(hash string) (*models.APIToken, error)
+    Delete(userID, id uint) error
+    TouchLastUsed(id uint, at time.Time) error
+}
+
+type GormAPITokenRepository struct { db *gorm.DB }
+
+func NewGormAPITokenRepository(db *gorm.DB) *GormAPITokenRepository { return &GormAPITokenRepository{db: db} }
+
+func (r *GormAPITokenRepository) Create(token *models.APIToken) error { return r.db.Omit(clause.Associations).Create(token).Error }
+
+// ListByUser returns a user's tokens, newest first
+func (r *GormAPITokenRepository) ListByUser(userID uint) ([]models.APIToken, error) {
+    var tokens []models.APIToken
+    if err := r.db.Where("user_id = ?", userID).Order("created_at desc, id desc").Find(&tokens).Error; err != nil { return nil, err }
+    return tokens, nil
+}
+
+// FindByHash loads the token together with its owner
+func (r *GormAPITokenRepository) FindByHash(hash string) (*models.APIToken, error) {
+    var token models.APIToken
+    if err := r.db.Preload("User").Where("token_hash = ?", hash).First(&token).Error; err != nil { return nil, err }
+    return &token, nil
+}
+
+// Delete removes one of userID's tokens; ErrNotFound when userID owns no such token
+func (r *GormAPITokenRepository) Delete(userID, id uint) error {
+    res := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.APIToken{})
+    if res.Error != nil { return res.Error }
+    if res.RowsAffected == 0 { return ErrNotFound }
+    return nil
+}
+
+func (r *GormAPITokenRepository) TouchLastUsed(id uint, at time.Time) error {
+    return r.db.Model(&models.APIToken{ID: id}).UpdateColumn("last_used_at", at).Error
+}
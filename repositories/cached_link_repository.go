@@ -0,0 +1,165 @@
+package repositories
+
+import (
+    "container/list"
+    "errors"
+    "sync"
+    "time"
+
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+// CachedLinkRepository answers FindByAlias from a bounded, least recently
+// used cache whose entries live for ttl. Unknown aliases are cached too, so
+// typos and scanners do not reach the database either. Every other method
+// goes straight to the wrapped repository; writers keep the cache in sync by
+// calling Invalidate or InvalidateLink after a change.
+type CachedLinkRepository struct {
+    LinkRepository
+    size int
+    ttl  time.Duration
+    now  func() time.Time
+
+    mu      sync.Mutex
+    lru     *list.List               // of *aliasEntry, most recently used first
+    entries map[string]*list.Element // by alias
+    byLink  map[uint]map[string]struct{}
+    // gen changes on every invalidation; a lookup that raced with one is not cached
+    gen   uint64
+    stats AliasCacheStats
+}
+
+// AliasCacheStats counts how the cache answered FindByAlias.
+type AliasCacheStats struct {
+    Entries      int   `json:"entries"`
+    Hits         int64 `json:"hits"`
+    NegativeHits int64 `json:"negative_hits"`
+    Misses       int64 `json:"misses"`
+    Evictions    int64 `json:"evictions"`
+}
+
+type aliasEntry struct {
+    alias   string
+    link    *models.Link // nil: no live link answers alias
+    expires time.Time
+}
+
+func NewCachedLinkRepository(repo LinkRepository, size int, ttl time.Duration) *CachedLinkRepository {
+    return &CachedLinkRepository{
+        LinkRepository: repo,
+        size:           size,
+        ttl:            ttl,
+        now:            time.Now,
+        lru:            list.New(),
+        entries:        map[string]*list.Element{},
+        byLink:         map[uint]map[string]struct{}{},
+    }
+}
+
+// FindByAlias returns a copy of the cached link, gorm.ErrRecordNotFound for a
+// cached miss, and otherwise reads through to the wrapped repository.
+func (r *CachedLinkRepository) FindByAlias(alias string) (*models.Link, error) {
+    r.mu.Lock()
+    if el, ok := r.entries[alias]; ok {
+        e := el.Value.(*aliasEntry)
+        if r.now().Before(e.expires) {
+            r.lru.MoveToFront(el)
+            if e.link == nil {
+                r.stats.NegativeHits++
+                r.mu.Unlock()
+                return nil, gorm.ErrRecordNotFound
+            }
+            r.stats.Hits++
+            link := *e.link
+            r.mu.Unlock()
+            return &link, nil
+        }
+        r.remove(el)
+    }
+    r.stats.Misses++
+    gen := r.gen
+    r.mu.Unlock()
+
+    link, err := r.LinkRepository.FindByAlias(alias)
+    if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) { return nil, err }
+    r.mu.Lock()
+    if r.gen == gen { r.put(alias, link) }
+    r.mu.Unlock()
+    return link, err
+}
+
+// Invalidate forgets the given aliases, found or not.
+func (r *CachedLinkRepository) Invalidate(aliases ...string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.gen++
+    for _, alias := range aliases {
+        if el, ok := r.entries[alias]; ok { r.remove(el) }
+    }
+}
+
+// InvalidateLink forgets every alias that resolved to the link with id.
+func (r *CachedLinkRepository) InvalidateLink(id uint) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.gen++
+    for alias := range r.byLink[id] {
+        if el, ok := r.entries[alias]; ok { r.remove(el) }
+    }
+}
+
+// Warm caches the limit most clicked links under their primary and secondary aliases.
+func (r *CachedLinkRepository) Warm(limit int) (int, error) {
+    r.mu.Lock()
+    gen := r.gen
+    r.mu.Unlock()
+    links, err := r.LinkRepository.TopByClicks(limit)
+    if err != nil { return 0, err }
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if r.gen != gen { return 0, nil }
+    for i := range links {
+        link := links[i]
+        link.Creator, link.Updater, link.Aliases = nil, nil, nil
+        r.put(links[i].Alias, &link)
+        for _, a := range links[i].Aliases { r.put(a.Alias, &link) }
+    }
+    return len(links), nil
+}
+
+func (r *CachedLinkRepository) Stats() AliasCacheStats {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    s := r.stats
+    s.Entries = r.lru.Len()
+    return s
+}
+
+// put caches a copy of link (nil for a miss) under alias, evicting the least
+// recently used entry when full. Callers hold r.mu.
+func (r *CachedLinkRepository) put(alias string, link *models.Link) {
+    if r.size <= 0 { return }
+    if el, ok := r.entries[alias]; ok { r.remove(el) }
+    e := &aliasEntry{alias: alias, expires: r.now().Add(r.ttl)}
+    if link != nil {
+        copied := *link
+        e.link = &copied
+        if r.byLink[link.ID] == nil { r.byLink[link.ID] = map[string]struct{}{} }
+        r.byLink[link.ID][alias] = struct{}{}
+    }
+    r.entries[alias] = r.lru.PushFront(e)
+    for r.lru.Len() > r.size {
+        r.remove(r.lru.Back())
+        r.stats.Evictions++
+    }
+}
+
+// remove drops one entry. Callers hold r.mu.
+func (r *CachedLinkRepository) remove(el *list.Element) {
+    e := r.lru.Remove(el).(*aliasEntry)
+    delete(r.entries, e.alias)
+    if e.link == nil { return }
+    delete(r.byLink[e.link.ID], e.alias)
+    if len(r.byLink[e.link.ID]) == 0 { delete(r.byLink, e.link.ID) }
+}
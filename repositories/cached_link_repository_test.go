@@ -0,0 +1,177 @@
+package repositories
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+// countingRepo serves links from a map, counting lookups. Only the methods
+// the cache calls are implemented.
+type countingRepo struct {
+    LinkRepository
+    mu      sync.Mutex
+    links   map[string]models.Link
+    lookups int64
+    // afterRead, if set, runs between reading a link and returning it
+    afterRead func()
+}
+
+func (f *countingRepo) FindByAlias(alias string) (*models.Link, error) {
+    atomic.AddInt64(&f.lookups, 1)
+    f.mu.Lock()
+    link, ok := f.links[alias]
+    f.mu.Unlock()
+    if f.afterRead != nil { f.afterRead() }
+    if !ok { return nil, gorm.ErrRecordNotFound }
+    return &link, nil
+}
+
+func (f *countingRepo) TopByClicks(limit int) ([]models.Link, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    out := []models.Link{}
+    for _, l := range f.links {
+        if len(out) < limit { out = append(out, l) }
+    }
+    return out, nil
+}
+
+func (f *countingRepo) set(link models.Link) {
+    f.mu.Lock()
+    f.links[link.Alias] = link
+    f.mu.Unlock()
+}
+
+func TestCachedLinkRepository_HitsAndMisses(t *testing.T) {
+    inner := &countingRepo{links: map[string]models.Link{"vpn": {ID: 1, Alias: "vpn", URL: "https://vpn.example.com"}}}
+    cache := NewCachedLinkRepository(inner, 10, time.Minute)
+
+    for i := 0; i < 3; i++ {
+        link, err := cache.FindByAlias("vpn")
+        if err != nil || link.URL != "https://vpn.example.com" { t.Fatalf("unexpected lookup %+v err=%v", link, err) }
+        link.URL = "mutated"
+    }
+    for i := 0; i < 3; i++ {
+        if _, err := cache.FindByAlias("vnp"); !errors.Is(err, gorm.ErrRecordNotFound) { t.Fatalf("expected not found, got %v", err) }
+    }
+    if inner.lookups != 2 { t.Fatalf("expected one database lookup per alias, got %d", inner.lookups) }
+    if s := cache.Stats(); s.Hits != 2 || s.NegativeHits != 2 || s.Misses != 2 || s.Entries != 2 {
+        t.Fatalf("unexpected stats %+v", s)
+    }
+    if link, _ := cache.FindByAlias("vpn"); link.URL != "https://vpn.example.com" { t.Fatalf("a caller's change leaked into the cache: %+v", link) }
+}
+
+func TestCachedLinkRepository_ExpiresAndEvicts(t *testing.T) {
+    inner := &countingRepo{links: map[string]models.Link{}}
+    cache := NewCachedLinkRepository(inner, 2, time.Minute)
+    now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+    cache.now = func() time.Time { return now }
+
+    cache.FindByAlias("a")
+    now = now.Add(2 * time.Minute)
+    cache.FindByAlias("a")
+    if inner.lookups != 2 { t.Fatalf("expected an expired entry to be reloaded, got %d lookups", inner.lookups) }
+
+    cache.FindByAlias("b")
+    cache.FindByAlias("a") // a is now the most recently used
+    cache.FindByAlias("c")
+    if s := cache.Stats(); s.Entries != 2 || s.Evictions != 1 { t.Fatalf("expected the cache to stay at 2 entries, got %+v", s) }
+    before := inner.lookups
+    cache.FindByAlias("a")
+    cache.FindByAlias("b")
+    if inner.lookups != before+1 { t.Fatalf("expected only b to have been evicted, got %d lookups", inner.lookups-before) }
+}
+
+func TestCachedLinkRepository_Invalidate(t *testing.T) {
+    inner := &countingRepo{links: map[string]models.Link{"vpn": {ID: 1, Alias: "vpn", URL: "https://old.example.com"}}}
+    cache := NewCachedLinkRepository(inner, 10, time.Hour)
+    cache.FindByAlias("vpn")
+    cache.FindByAlias("wireguard")
+
+    // rename vpn to wireguard
+    inner.mu.Lock()
+    delete(inner.links, "vpn")
+    inner.links["wireguard"] = models.Link{ID: 1, Alias: "wireguard", URL: "https://new.example.com"}
+    inner.mu.Unlock()
+    cache.InvalidateLink(1)
+    cache.Invalidate("wireguard")
+
+    if _, err := cache.FindByAlias("vpn"); !errors.Is(err, gorm.ErrRecordNotFound) { t.Fatalf("expected the old alias to miss, got %v", err) }
+    if link, err := cache.FindByAlias("wireguard"); err != nil || link.URL != "https://new.example.com" {
+        t.Fatalf("expected the new alias to resolve, got %+v err=%v", link, err)
+    }
+}
+
+func TestCachedLinkRepository_Warm(t *testing.T) {
+    inner := &countingRepo{links: map[string]models.Link{
+        "vpn": {ID: 1, Alias: "vpn", Aliases: []models.LinkAlias{{LinkID: 1, Alias: "wireguard"}}},
+        "wiki": {ID: 2, Alias: "wiki"},
+    }}
+    cache := NewCachedLinkRepository(inner, 10, time.Hour)
+    if n, err := cache.Warm(10); err != nil || n != 2 { t.Fatalf("expected 2 links warmed, got %d err=%v", n, err) }
+    for _, alias := range []string{"vpn", "wireguard", "wiki"} {
+        if _, err := cache.FindByAlias(alias); err != nil { t.Fatalf("expected %s to be warm, got %v", alias, err) }
+    }
+    if inner.lookups != 0 { t.Fatalf("expected no database lookups after warmup, got %d", inner.lookups) }
+    cache.InvalidateLink(1)
+    if s := cache.Stats(); s.Entries != 1 { t.Fatalf("expected both aliases of link 1 dropped, got %+v", s) }
+}
+
+// A lookup that read the old link before an update must not cache it after
+// the update's invalidation.
+func TestCachedLinkRepository_LookupRacingInvalidation(t *testing.T) {
+    inner := &countingRepo{links: map[string]models.Link{"vpn": {ID: 1, Alias: "vpn", URL: "https://old.example.com"}}}
+    cache := NewCachedLinkRepository(inner, 10, time.Hour)
+    inner.afterRead = func() {
+        inner.afterRead = nil
+        inner.set(models.Link{ID: 1, Alias: "vpn", URL: "https://new.example.com"})
+        cache.InvalidateLink(1)
+        cache.Invalidate("vpn")
+    }
+    if link, _ := cache.FindByAlias("vpn"); link.URL != "https://old.example.com" { t.Fatalf("expected the racing lookup to see the old link, got %+v", link) }
+    if link, _ := cache.FindByAlias("vpn"); link.URL != "https://new.example.com" { t.Fatalf("expected the stale link not to be cached, got %+v", link) }
+}
+
+// Readers racing with writers must never leave a stale link in the cache
+// once the writers are done.
+func TestCachedLinkRepository_ConcurrentUpdatesAndReads(t *testing.T) {
+    inner := &countingRepo{links: map[string]models.Link{}}
+    for id := uint(1); id <= 4; id++ { inner.set(models.Link{ID: id, Alias: fmt.Sprintf("l%d", id), URL: "v0"}) }
+    cache := NewCachedLinkRepository(inner, 3, time.Hour)
+
+    var wg sync.WaitGroup
+    for w := uint(1); w <= 4; w++ {
+        wg.Add(1)
+        go func(id uint) {
+            defer wg.Done()
+            for v := 1; v <= 200; v++ {
+                inner.set(models.Link{ID: id, Alias: fmt.Sprintf("l%d", id), URL: fmt.Sprintf("v%d", v)})
+                cache.InvalidateLink(id)
+            }
+        }(w)
+    }
+    for r := 0; r < 8; r++ {
+        wg.Add(1)
+        go func(r int) {
+            defer wg.Done()
+            for i := 0; i < 500; i++ {
+                cache.FindByAlias(fmt.Sprintf("l%d", (r+i)%5+1)) // l5 never exists
+                _ = cache.Stats()
+            }
+        }(r)
+    }
+    wg.Wait()
+
+    for id := 1; id <= 4; id++ {
+        link, err := cache.FindByAlias(fmt.Sprintf("l%d", id))
+        if err != nil || link.URL != "v200" { t.Fatalf("expected l%d at v200, got %+v err=%v", id, link, err) }
+    }
+    if s := cache.Stats(); s.Entries > 3 { t.Fatalf("expected at most 3 entries, got %+v", s) }
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+    "strings"
+    "sync"
+    "time"
+
+    "quickr/models"
+)
+
+// CachedUserRepository answers FindByEmail, which every signed-in request
+// makes to load the current role and state, from memory for up to ttl.
+// Writes through it drop the whole cache, so role, disabled and session
+// generation changes apply from the next request on; ttl only bounds how
+// long changes made around it go unseen. Unknown emails are not cached.
+type CachedUserRepository struct {
+    UserRepository
+    ttl time.Duration
+    now func() time.Time
+
+    mu      sync.Mutex
+    entries map[string]userEntry
+    // gen changes on every write; a lookup that raced with one is not cached
+    gen uint64
+}
+
+type userEntry struct {
+    user    models.User
+    expires time.Time
+}
+
+func NewCachedUserRepository(repo UserRepository, ttl time.Duration) *CachedUserRepository {
+    return &CachedUserRepository{UserRepository: repo, ttl: ttl, now: time.Now, entries: map[string]userEntry{}}
+}
+
+// FindByEmail returns a copy of the cached user or reads through to the wrapped repository.
+func (r *CachedUserRepository) FindByEmail(email string) (*models.User, error) {
+    e := strings.TrimSpace(strings.ToLower(email))
+    r.mu.Lock()
+    if entry, ok := r.entries[e]; ok && r.now().Before(entry.expires) {
+        u := entry.user
+        r.mu.Unlock()
+        return &u, nil
+    }
+    gen := r.gen
+    r.mu.Unlock()
+
+    u, err := r.UserRepository.FindByEmail(e)
+    if err != nil { return nil, err }
+    r.mu.Lock()
+    if r.gen == gen && r.ttl > 0 { r.entries[e] = userEntry{user: *u, expires: r.now().Add(r.ttl)} }
+    r.mu.Unlock()
+    return u, nil
+}
+
+func (r *CachedUserRepository) Save(user *models.User) error   { return r.flush(r.UserRepository.Save(user)) }
+func (r *CachedUserRepository) Create(user *models.User) error { return r.flush(r.UserRepository.Create(user)) }
+func (r *CachedUserRepository) SetRole(id uint, role string) error {
+    return r.flush(r.UserRepository.SetRole(id, role))
+}
+func (r *CachedUserRepository) SetDisabled(id uint, disabled bool) error {
+    return r.flush(r.UserRepository.SetDisabled(id, disabled))
+}
+func (r *CachedUserRepository) Delete(id uint, reassignTo *uint) error {
+    return r.flush(r.UserRepository.Delete(id, reassignTo))
+}
+func (r *CachedUserRepository) BumpSessionGeneration(id uint) error {
+    return r.flush(r.UserRepository.BumpSessionGeneration(id))
+}
+
+// flush forgets every cached user after a write and passes its error on.
+func (r *CachedUserRepository) flush(err error) error {
+    r.mu.Lock()
+    r.gen++
+    r.entries = map[string]userEntry{}
+    r.mu.Unlock()
+    return err
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+    "path/filepath"
+    "testing"
+    "time"
+
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "quickr/models"
+)
+
+func TestCachedUserRepository(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkRevision{}, &models.LinkCoOwner{}, &models.AliasHistory{}, &models.APIToken{}, &models.Session{}); err != nil { t.Fatalf("migrate: %v", err) }
+    now := time.Now()
+    repo := NewCachedUserRepository(NewGormUserRepository(db), time.Minute)
+    repo.now = func() time.Time { return now }
+    for _, u := range []models.User{{Email: "root@example.com", Role: "admin"}, {Email: "bob@example.com", Role: "user"}} {
+        if err := repo.Create(&u); err != nil { t.Fatalf("seed: %v", err) }
+    }
+
+    u, err := repo.FindByEmail(" BOB@example.com ")
+    if err != nil || u.Role != "user" { t.Fatalf("expected bob, got %+v %v", u, err) }
+    u.Role = "admin"
+    if u, _ = repo.FindByEmail("bob@example.com"); u.Role != "user" { t.Fatalf("expected callers to get copies, got %+v", u) }
+
+    // changes made behind the cache's back show once the entry expires
+    db.Model(&models.User{}).Where("id = ?", 2).Update("disabled", true)
+    if u, _ = repo.FindByEmail("bob@example.com"); u.Disabled { t.Fatalf("expected the cached bob within the ttl") }
+    now = now.Add(time.Minute)
+    if u, _ = repo.FindByEmail("bob@example.com"); !u.Disabled { t.Fatalf("expected bob reloaded after the ttl, got %+v", u) }
+
+    // writes through the cache show at once
+    if err := repo.SetRole(2, "admin"); err != nil { t.Fatalf("promote: %v", err) }
+    if u, _ = repo.FindByEmail("bob@example.com"); u.Role != "admin" { t.Fatalf("expected the promotion at once, got %+v", u) }
+    if err := repo.BumpSessionGeneration(2); err != nil { t.Fatalf("bump: %v", err) }
+    if u, _ = repo.FindByEmail("bob@example.com"); u.SessionGeneration != 1 { t.Fatalf("expected the new generation at once, got %+v", u) }
+    if err := repo.BumpSessionGeneration(9); err != ErrNotFound { t.Fatalf("expected ErrNotFound, got %v", err) }
+    if err := repo.Delete(2, nil); err != nil { t.Fatalf("delete: %v", err) }
+    if _, err := repo.FindByEmail("bob@example.com"); err == nil { t.Fatalf("expected bob gone at once") }
+}
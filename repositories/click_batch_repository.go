@@ -0,0 +1,29 @@
+package repositories
+
+import (
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+// ClickBatchRepository writes buffered clicks: per-link counter increments
+// and click log events, all in one transaction.
+type ClickBatchRepository interface {
+    ApplyClicks(counts map[uint]int64, events []models.ClickEvent) error
+}
+
+type GormClickBatchRepository struct { db *gorm.DB }
+
+func NewGormClickBatchRepository(db *gorm.DB) *GormClickBatchRepository { return &GormClickBatchRepository{db: db} }
+
+// ApplyClicks leaves updated_at alone, like IncrementClicks. Counts for links
+// deleted in the meantime still land on their (trashed) rows.
+func (r *GormClickBatchRepository) ApplyClicks(counts map[uint]int64, events []models.ClickEvent) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        for id, n := range counts {
+            err := tx.Unscoped().Model(&models.Link{ID: id}).UpdateColumn("clicks", gorm.Expr("clicks + ?", n)).Error
+            if err != nil { return err }
+        }
+        if len(events) == 0 { return nil }
+        return tx.CreateInBatches(events, 200).Error
+    })
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+// ClickBucket is the number of clicks in one day ("2006-01-02") or hour
+// ("2006-01-02 15:00"), in UTC.
+type ClickBucket struct {
+    Bucket string
+    Clicks int64
+}
+
+// LinkClicks is the number of clicks a link got in some window.
+type LinkClicks struct {
+    LinkID uint
+    Clicks int64
+}
+
+// LinkClickBucket is LinkClicks for one UTC hour ("2006-01-02 15:00").
+type LinkClickBucket struct {
+    LinkID uint
+    Bucket string
+    Clicks int64
+}
+
+type ClickEventRepository interface {
+    Create(event *models.ClickEvent) error
+    CountByDay(linkID uint, since time.Time) ([]ClickBucket, error)
+    CountByHour(linkID uint, since time.Time) ([]ClickBucket, error)
+    TopLinksSince(since time.Time, limit int) ([]LinkClicks, error)
+    CountByLinkAndHour(since time.Time) ([]LinkClickBucket, error)
+}
+
+type GormClickEventRepository struct { db *gorm.DB }
+
+func NewGormClickEventRepository(db *gorm.DB) *GormClickEventRepository { return &GormClickEventRepository{db: db} }
+
+func (r *GormClickEventRepository) Create(event *models.ClickEvent) error { return r.db.Create(event).Error }
+
+// CountByDay counts a link's clicks since the given time per UTC day, oldest first; days without clicks are absent
+func (r *GormClickEventRepository) CountByDay(linkID uint, since time.Time) ([]ClickBucket, error) {
+    return r.countBy("%Y-%m-%d", linkID, since)
+}
+
+// CountByHour is CountByDay per UTC hour
+func (r *GormClickEventRepository) CountByHour(linkID uint, since time.Time) ([]ClickBucket, error) {
+    return r.countBy("%Y-%m-%d %H:00", linkID, since)
+}
+
+func (r *GormClickEventRepository) countBy(format string, linkID uint, since time.Time) ([]ClickBucket, error) {
+    var buckets []ClickBucket
+    err := r.db.Model(&models.ClickEvent{}).
+        Select("strftime(?, created_at) AS bucket, COUNT(*) AS clicks", format).
+        Where("link_id = ? AND created_at >= ?", linkID, since.UTC()).
+        Group("bucket").Order("bucket").Scan(&buckets).Error
+    if err != nil { return nil, err }
+    return buckets, nil
+}
+
+// TopLinksSince returns the live links with the most clicks since the given time, busiest first
+func (r *GormClickEventRepository) TopLinksSince(since time.Time, limit int) ([]LinkClicks, error) {
+    var top []LinkClicks
+    err := r.liveClicks(since).Select("click_events.link_id, COUNT(*) AS clicks").
+        Group("click_events.link_id").Order("clicks desc, click_events.link_id").Limit(limit).Scan(&top).Error
+    if err != nil { return nil, err }
+    return top, nil
+}
+
+// CountByLinkAndHour counts every live link's clicks since the given time per UTC hour
+func (r *GormClickEventRepository) CountByLinkAndHour(since time.Time) ([]LinkClickBucket, error) {
+    var buckets []LinkClickBucket
+    err := r.liveClicks(since).Select("click_events.link_id, strftime('%Y-%m-%d %H:00', click_events.created_at) AS bucket, COUNT(*) AS clicks").
+        Group("click_events.link_id, bucket").Scan(&buckets).Error
+    if err != nil { return nil, err }
+    return buckets, nil
+}
+
+// liveClicks scopes click events to links that are not in the trash
+func (r *GormClickEventRepository) liveClicks(since time.Time) *gorm.DB {
+    return r.db.Model(&models.ClickEvent{}).
+        Joins("JOIN links ON links.id = click_events.link_id AND links.deleted_at IS NULL").
+        Where("click_events.created_at >= ?", since.UTC())
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+type LinkAliasRepository interface {
+    Add(linkID uint, alias string) error
+    Remove(linkID uint, alias string) error
+    ListByLinkID(linkID uint) ([]models.LinkAlias, error)
+}
+
+type GormLinkAliasRepository struct { db *gorm.DB }
+
+func NewGormLinkAliasRepository(db *gorm.DB) *GormLinkAliasRepository { return &GormLinkAliasRepository{db: db} }
+
+// Add gives linkID the secondary alias. A trashed link holding it gives it
+// up, as restoring that link would drop the alias anyway; a live one makes
+// Add fail with ErrAliasExists.
+func (r *GormLinkAliasRepository) Add(linkID uint, alias string) error {
+    trashed := r.db.Unscoped().Model(&models.Link{}).Select("id").Where("deleted_at IS NOT NULL")
+    if err := r.db.Where("alias = ? AND link_id IN (?)", alias, trashed).Delete(&models.LinkAlias{}).Error; err != nil { return err }
+    return aliasConflict(r.db.Create(&models.LinkAlias{LinkID: linkID, Alias: alias}).Error)
+}
+
+func (r *GormLinkAliasRepository) Remove(linkID uint, alias string) error {
+    return r.db.Where("link_id = ? AND alias = ?", linkID, alias).Delete(&models.LinkAlias{}).Error
+}
+
+// ListByLinkID returns a link's secondary aliases, oldest first
+func (r *GormLinkAliasRepository) ListByLinkID(linkID uint) ([]models.LinkAlias, error) {
+    var aliases []models.LinkAlias
+    if err := r.db.Where("link_id = ?", linkID).Order("id").Find(&aliases).Error; err != nil { return nil, err }
+    return aliases, nil
+}
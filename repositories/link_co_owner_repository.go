@@ -0,0 +1,42 @@
+package repositories
+
+import (
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "quickr/models"
+)
+
+type LinkCoOwnerRepository interface {
+    Add(linkID, userID uint) error
+    Remove(linkID, userID uint) error
+    ListUsers(linkID uint) ([]models.User, error)
+    IsCoOwner(linkID, userID uint) (bool, error)
+}
+
+type GormLinkCoOwnerRepository struct { db *gorm.DB }
+
+func NewGormLinkCoOwnerRepository(db *gorm.DB) *GormLinkCoOwnerRepository { return &GormLinkCoOwnerRepository{db: db} }
+
+// Add is idempotent: adding an existing co-owner is a no-op
+func (r *GormLinkCoOwnerRepository) Add(linkID, userID uint) error {
+    return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).
+        Create(&models.LinkCoOwner{LinkID: linkID, UserID: userID}).Error
+}
+
+func (r *GormLinkCoOwnerRepository) Remove(linkID, userID uint) error {
+    return r.db.Where("link_id = ? AND user_id = ?", linkID, userID).Delete(&models.LinkCoOwner{}).Error
+}
+
+func (r *GormLinkCoOwnerRepository) ListUsers(linkID uint) ([]models.User, error) {
+    var users []models.User
+    err := r.db.Joins("JOIN link_co_owners ON link_co_owners.user_id = users.id").
+        Where("link_co_owners.link_id = ?", linkID).Order("users.email").Find(&users).Error
+    if err != nil { return nil, err }
+    return users, nil
+}
+
+func (r *GormLinkCoOwnerRepository) IsCoOwner(linkID, userID uint) (bool, error) {
+    var n int64
+    err := r.db.Model(&models.LinkCoOwner{}).Where("link_id = ? AND user_id = ?", linkID, userID).Count(&n).Error
+    return n > 0, err
+}
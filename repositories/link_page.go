@@ -0,0 +1,125 @@
+package repositories
+
+import (
+    "errors"
+    "strconv"
+    "time"
+
+    "quickr/domain/linkstatus"
+    "quickr/models"
+)
+
+// Sort keys accepted by ListPage
+const (
+    SortAlias   = "alias"
+    SortClicks  = "clicks"
+    SortCreated = "created"
+    SortUpdated = "updated"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+var sortColumns = map[string]string{
+    SortAlias:   "alias",
+    SortClicks:  "clicks",
+    SortCreated: "created_at",
+    SortUpdated: "updated_at",
+}
+
+func IsValidSort(sort string) bool { _, ok := sortColumns[sort]; return ok }
+
+// LinkQuery selects one page of live links. Zero fields do not filter.
+type LinkQuery struct {
+    // Search matches the alias, the URL or a secondary alias
+    Search string
+    // Creator matches the name recorded at creation or the creating user's email
+    Creator string
+    // CreatedFrom is inclusive, CreatedTo exclusive
+    CreatedFrom *time.Time
+    CreatedTo   *time.Time
+    // Status is active, scheduled or expired as of Now
+    Status string
+    Now    time.Time
+    Sort   string
+    Desc   bool
+    // After continues from the last link of the previous page
+    After *LinkCursor
+    Limit int
+}
+
+// LinkCursor is the position of a link in a sorted listing: its sort key and,
+// to break ties, its ID.
+type LinkCursor struct {
+    Value string
+    ID    uint
+}
+
+// CursorFor is the position of link when sorting by sort.
+func CursorFor(link models.Link, sort string) LinkCursor {
+    c := LinkCursor{ID: link.ID}
+    switch sort {
+    case SortAlias:
+        c.Value = link.Alias
+    case SortClicks:
+        c.Value = strconv.FormatUint(uint64(link.Clicks), 10)
+    case SortUpdated:
+        c.Value = link.UpdatedAt.Format(time.RFC3339Nano)
+    default:
+        c.Value = link.CreatedAt.Format(time.RFC3339Nano)
+    }
+    return c
+}
+
+// ListPage returns up to q.Limit links in q.Sort order using keyset
+// pagination, so later pages cost the same as the first.
+func (r *GormLinkRepository) ListPage(q LinkQuery) ([]models.Link, error) {
+    column, ok := sortColumns[q.Sort]
+    if !ok { column, q.Sort = sortColumns[SortCreated], SortCreated }
+    db := r.withDetails().Model(&models.Link{})
+    if q.Search != "" {
+        like := "%" + q.Search + "%"
+        secondary := r.db.Model(&models.LinkAlias{}).Select("link_id").Where("alias LIKE ?", like)
+        db = db.Where("alias LIKE ? OR url LIKE ? OR id IN (?)", like, like, secondary)
+    }
+    if q.Creator != "" {
+        creators := r.db.Model(&models.User{}).Select("id").Where("email = ?", q.Creator)
+        db = db.Where("creator_name = ? OR created_by IN (?)", q.Creator, creators)
+    }
+    if q.CreatedFrom != nil { db = db.Where("created_at >= ?", *q.CreatedFrom) }
+    if q.CreatedTo != nil { db = db.Where("created_at < ?", *q.CreatedTo) }
+    switch q.Status {
+    case linkstatus.Expired:
+        db = db.Where("expires_at IS NOT NULL AND expires_at <= ?", q.Now)
+    case linkstatus.Scheduled:
+        db = db.Where("(expires_at IS NULL OR expires_at > ?) AND active_from IS NOT NULL AND active_from > ?", q.Now, q.Now)
+    case linkstatus.Active:
+        db = db.Where("(expires_at IS NULL OR expires_at > ?) AND (active_from IS NULL OR active_from <= ?)", q.Now, q.Now)
+    }
+    op, dir := ">", "asc"
+    if q.Desc { op, dir = "<", "desc" }
+    if q.After != nil {
+        value, err := cursorValue(q.Sort, q.After.Value)
+        if err != nil { return nil, err }
+        db = db.Where("("+column+" "+op+" ?) OR ("+column+" = ? AND id "+op+" ?)", value, value, q.After.ID)
+    }
+    var links []models.Link
+    if err := db.Order(column + " " + dir).Order("id " + dir).Limit(q.Limit).Find(&links).Error; err != nil {
+        return nil, err
+    }
+    return links, nil
+}
+
+// cursorValue parses a cursor's sort key back into the column's type.
+func cursorValue(sort, v string) (any, error) {
+    switch sort {
+    case SortAlias:
+        return v, nil
+    case SortClicks:
+        n, err := strconv.ParseUint(v, 10, 64)
+        if err != nil { return nil, ErrInvalidCursor }
+        return n, nil
+    }
+    t, err := time.Parse(time.RFC3339Nano, v)
+    if err != nil { return nil, ErrInvalidCursor }
+    return t, nil
+}
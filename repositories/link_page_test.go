@@ -0,0 +1,111 @@
+package repositories
+
+import (
+    "fmt"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "quickr/models"
+)
+
+// openPageDB holds 25 links: clicks repeat every 5 links so sorting by
+// clicks has ties, creators alternate, and link i was created i hours ago.
+func openPageDB(t *testing.T) (*GormLinkRepository, time.Time) {
+    t.Helper()
+    db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "page.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}); err != nil { t.Fatalf("migrate: %v", err) }
+    bob := models.User{Email: "bob@example.com"}
+    db.Create(&bob)
+    now := time.Now().Truncate(time.Second)
+    for i := 1; i <= 25; i++ {
+        l := models.Link{Alias: fmt.Sprintf("link-%02d", i), URL: "https://example.com", Clicks: uint(i % 5), CreatorName: "alice", CreatedAt: now.Add(-time.Duration(i) * time.Hour)}
+        if i%2 == 0 { l.CreatorName, l.CreatedBy = "Bob", &bob.ID }
+        if err := db.Create(&l).Error; err != nil { t.Fatalf("seed: %v", err) }
+    }
+    db.Create(&models.LinkAlias{LinkID: 3, Alias: "wireguard"})
+    db.Delete(&models.Link{}, 25)
+    return NewGormLinkRepository(db), now
+}
+
+// walk pages through q three links at a time and returns the aliases in order.
+func walk(t *testing.T, repo *GormLinkRepository, q LinkQuery) []string {
+    t.Helper()
+    var aliases []string
+    q.Limit = 3
+    for pages := 0; pages < 20; pages++ {
+        links, err := repo.ListPage(q)
+        if err != nil { t.Fatalf("ListPage(%+v): %v", q, err) }
+        for _, l := range links { aliases = append(aliases, l.Alias) }
+        if len(links) < q.Limit { return aliases }
+        c := CursorFor(links[len(links)-1], q.Sort)
+        q.After = &c
+    }
+    t.Fatal("pagination did not terminate")
+    return nil
+}
+
+func TestGormLinkRepository_ListPage_Sorts(t *testing.T) {
+    repo, _ := openPageDB(t)
+    for _, tc := range []struct {
+        sort  string
+        desc  bool
+        first []string
+    }{
+        {SortCreated, true, []string{"link-01", "link-02", "link-03"}},
+        {SortCreated, false, []string{"link-24", "link-23", "link-22"}},
+        {SortAlias, false, []string{"link-01", "link-02", "link-03"}},
+        {SortAlias, true, []string{"link-24", "link-23", "link-22"}},
+        {SortClicks, true, []string{"link-24", "link-19", "link-14"}}, // 4 clicks each, ties by id
+        {SortClicks, false, []string{"link-05", "link-10", "link-15"}},
+        {SortUpdated, true, []string{"link-24", "link-23", "link-22"}},
+    } {
+        got := walk(t, repo, LinkQuery{Sort: tc.sort, Desc: tc.desc})
+        seen := map[string]bool{}
+        for _, a := range got { seen[a] = true }
+        if len(got) != 24 || len(seen) != 24 { t.Errorf("%s desc=%v: expected each of the 24 live links once, got %v", tc.sort, tc.desc, got) }
+        if fmt.Sprint(got[:3]) != fmt.Sprint(tc.first) { t.Errorf("%s desc=%v: expected %v first, got %v", tc.sort, tc.desc, tc.first, got[:3]) }
+    }
+}
+
+func TestGormLinkRepository_ListPage_Filters(t *testing.T) {
+    repo, now := openPageDB(t)
+    from, to := now.Add(-10*time.Hour), now.Add(-5*time.Hour)
+    for _, tc := range []struct {
+        name string
+        q    LinkQuery
+        want int
+    }{
+        {"search by alias", LinkQuery{Search: "link-1"}, 10},
+        {"search by secondary alias", LinkQuery{Search: "wireguard"}, 1},
+        {"creator name", LinkQuery{Creator: "alice"}, 12},
+        {"creator email", LinkQuery{Creator: "bob@example.com"}, 12},
+        {"created range", LinkQuery{CreatedFrom: &from, CreatedTo: &to}, 5},
+        {"combined", LinkQuery{Creator: "Bob", Search: "link-1", CreatedFrom: &from}, 1},
+    } {
+        tc.q.Sort = SortCreated
+        if got := walk(t, repo, tc.q); len(got) != tc.want { t.Errorf("%s: expected %d links, got %v", tc.name, tc.want, got) }
+    }
+}
+
+func TestGormLinkRepository_ListPage_Status(t *testing.T) {
+    repo, now := openPageDB(t)
+    past, future := now.Add(-time.Hour), now.Add(time.Hour)
+    repo.db.Model(&models.Link{}).Where("id = ?", 1).Update("expires_at", past)
+    repo.db.Model(&models.Link{}).Where("id = ?", 2).Update("active_from", future)
+    repo.db.Model(&models.Link{}).Where("id = ?", 3).Updates(map[string]any{"active_from": future, "expires_at": past})
+    for status, want := range map[string]int{"expired": 2, "scheduled": 1, "active": 21} {
+        if got := walk(t, repo, LinkQuery{Status: status, Now: now, Sort: SortCreated}); len(got) != want { t.Errorf("%s: expected %d links, got %v", status, want, got) }
+    }
+}
+
+func TestGormLinkRepository_ListPage_InvalidCursor(t *testing.T) {
+    repo, _ := openPageDB(t)
+    if _, err := repo.ListPage(LinkQuery{Sort: SortClicks, After: &LinkCursor{Value: "many", ID: 1}, Limit: 3}); err != ErrInvalidCursor {
+        t.Fatalf("expected ErrInvalidCursor, got %v", err)
+    }
+}
@@ -2,8 +2,10 @@ package repositories
 
 import (
     "errors"
+    "time"
 
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
     "quickr/models"
 )
 
@@ -16,19 +18,53 @@ type LinkRepository interface {
     Delete(link *models.Link) error
     Save(link *models.Link) error
     ListAll() ([]models.Link, error)
+    ListPage(q LinkQuery) ([]models.Link, error)
     Search(query string) ([]models.Link, error)
     IncrementClicks(id uint) error
+    ListExpiredUnmarked(now time.Time) ([]models.Link, error)
+    MarkExpired(id uint, at time.Time) error
+    ListDeleted() ([]models.Link, error)
+    FindDeletedByID(id string) (*models.Link, error)
+    Restore(link *models.Link) error
+    PurgeDeletedBefore(cutoff time.Time) (int64, error)
+    // Aggregates over live links, computed by the database
+    Count() (int64, error)
+    SumClicks() (int64, error)
+    CountCreators() (int64, error)
+    TopByClicks(limit int) ([]models.Link, error)
+    ListCreatedSince(since time.Time, limit int) ([]models.Link, error)
+    FindByIDs(ids []uint) ([]models.Link, error)
+    ListAliasCandidates(now time.Time) ([]AliasCandidate, error)
 }
 
+var (
+    // ErrAliasExists means the database refused an alias already held by a
+    // live link, or already used as a secondary alias.
+    ErrAliasExists = errors.New("alias already exists")
+    // ErrVersionConflict means the link was saved by someone else since it was read.
+    ErrVersionConflict = errors.New("link was changed by someone else")
+)
+
 type GormLinkRepository struct { db *gorm.DB }
 
 func NewGormLinkRepository(db *gorm.DB) *GormLinkRepository { return &GormLinkRepository{db: db} }
 
-func (r *GormLinkRepository) Create(link *models.Link) error { return r.db.Create(link).Error }
+// Create and Save never write the preloaded Creator/Updater users; the
+// CreatedBy/UpdatedBy columns are the source of truth. Both return
+// ErrAliasExists when another live link holds the alias.
+func (r *GormLinkRepository) Create(link *models.Link) error {
+    return aliasConflict(r.db.Omit(clause.Associations).Create(link).Error)
+}
 
+// FindByAlias resolves a primary alias, then a secondary alias of a live link
 func (r *GormLinkRepository) FindByAlias(alias string) (*models.Link, error) {
     var link models.Link
-    if err := r.db.Where("alias = ? AND deleted_at IS NULL", alias).First(&link).Error; err != nil {
+    err := r.db.Where("alias = ? AND deleted_at IS NULL", alias).First(&link).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        owners := r.db.Model(&models.LinkAlias{}).Select("link_id").Where("alias = ?", alias)
+        err = r.db.Where("id IN (?)", owners).First(&link).Error
+    }
+    if err != nil {
         return nil, err
     }
     return &link, nil
@@ -36,36 +72,64 @@ func (r *GormLinkRepository) FindByAlias(alias string) (*models.Link, error) {
 
 func (r *GormLinkRepository) FindByID(id string) (*models.Link, error) {
     var link models.Link
-    if err := r.db.First(&link, id).Error; err != nil {
+    if err := r.withDetails().First(&link, id).Error; err != nil {
         return nil, err
     }
     return &link, nil
 }
 
+// ExistsByAlias reports whether a live link uses alias as its primary or a secondary alias
 func (r *GormLinkRepository) ExistsByAlias(alias string) (bool, error) {
-    var link models.Link
-    err := r.db.Where("alias = ?", alias).First(&link).Error
-    if errors.Is(err, gorm.ErrRecordNotFound) {
-        return false, nil
-    }
-    return err == nil, err
+    return r.aliasTaken(alias, "")
 }
 
 func (r *GormLinkRepository) ExistsByAliasExceptID(alias string, id string) (bool, error) {
-    var link models.Link
-    err := r.db.Where("alias = ? AND id != ?", alias, id).First(&link).Error
-    if errors.Is(err, gorm.ErrRecordNotFound) {
-        return false, nil
+    return r.aliasTaken(alias, id)
+}
+
+// aliasTaken checks both alias tables, ignoring link exceptID when it is set.
+// Secondary aliases of trashed links are free to reuse. Unique indexes only
+// cover each table on its own, so a primary alias colliding with a secondary
+// one is caught here alone; callers run this check and their write in one
+// transaction, which SQLiteDSN makes take the write lock up front.
+func (r *GormLinkRepository) aliasTaken(alias, exceptID string) (bool, error) {
+    primary := r.db.Model(&models.Link{}).Where("alias = ?", alias)
+    secondary := r.db.Model(&models.LinkAlias{}).
+        Joins("JOIN links ON links.id = link_aliases.link_id AND links.deleted_at IS NULL").
+        Where("link_aliases.alias = ?", alias)
+    if exceptID != "" {
+        primary = primary.Where("id != ?", exceptID)
+        secondary = secondary.Where("link_aliases.link_id != ?", exceptID)
     }
-    return err == nil, err
+    var n int64
+    if err := primary.Count(&n).Error; err != nil || n > 0 { return n > 0, err }
+    err := secondary.Count(&n).Error
+    return n > 0, err
+}
+
+// Delete soft-deletes the link, recording link.DeletedBy alongside deleted_at
+func (r *GormLinkRepository) Delete(link *models.Link) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(link).UpdateColumn("deleted_by", link.DeletedBy).Error; err != nil { return err }
+        return tx.Delete(link).Error
+    })
 }
 
-func (r *GormLinkRepository) Delete(link *models.Link) error { return r.db.Delete(link).Error }
-func (r *GormLinkRepository) Save(link *models.Link) error   { return r.db.Save(link).Error }
+// Save writes link only if nobody saved it since it was read, bumping its
+// Version; otherwise it returns ErrVersionConflict and leaves link as it was.
+func (r *GormLinkRepository) Save(link *models.Link) error {
+    read := link.Version
+    link.Version++
+    res := r.db.Select("*").Omit(clause.Associations).Where("version = ?", read).Save(link)
+    err := aliasConflict(res.Error)
+    if err == nil && res.RowsAffected == 0 { err = ErrVersionConflict }
+    if err != nil { link.Version = read }
+    return err
+}
 
 func (r *GormLinkRepository) ListAll() ([]models.Link, error) {
     var links []models.Link
-    if err := r.db.Order("created_at desc").Find(&links).Error; err != nil {
+    if err := r.withDetails().Order("created_at desc").Find(&links).Error; err != nil {
         return nil, err
     }
     return links, nil
@@ -74,14 +138,153 @@ func (r *GormLinkRepository) ListAll() ([]models.Link, error) {
 func (r *GormLinkRepository) Search(q string) ([]models.Link, error) {
     var links []models.Link
     like := "%" + q + "%"
-    if err := r.db.Where("alias LIKE ? OR url LIKE ?", like, like).Order("created_at desc").Find(&links).Error; err != nil {
+    secondary := r.db.Model(&models.LinkAlias{}).Select("link_id").Where("alias LIKE ?", like)
+    if err := r.withDetails().Where("alias LIKE ? OR url LIKE ? OR id IN (?)", like, like, secondary).Order("created_at desc").Find(&links).Error; err != nil {
         return nil, err
     }
     return links, nil
 }
 
+// IncrementClicks leaves updated_at alone: a click is not an edit
 func (r *GormLinkRepository) IncrementClicks(id uint) error {
-    return r.db.Model(&models.Link{ID: id}).Update("clicks", gorm.Expr("clicks + ?", 1)).Error
+    return r.db.Model(&models.Link{ID: id}).UpdateColumn("clicks", gorm.Expr("clicks + ?", 1)).Error
+}
+
+// ListExpiredUnmarked returns links past their expiry that the sweeper has not stamped yet
+func (r *GormLinkRepository) ListExpiredUnmarked(now time.Time) ([]models.Link, error) {
+    var links []models.Link
+    if err := r.db.Where("expires_at IS NOT NULL AND expires_at <= ? AND expired_at IS NULL", now).Find(&links).Error; err != nil {
+        return nil, err
+    }
+    return links, nil
+}
+
+// MarkExpired stamps expired_at on link id alone, so clicks flushed since the
+// link was read and its version are left as they are.
+func (r *GormLinkRepository) MarkExpired(id uint, at time.Time) error {
+    return r.db.Model(&models.Link{}).Where("id = ?", id).UpdateColumn("expired_at", at).Error
+}
+
+// ListDeleted returns the trash, most recently deleted first
+func (r *GormLinkRepository) ListDeleted() ([]models.Link, error) {
+    var links []models.Link
+    if err := r.withDetails().Preload("Deleter").Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at desc").Find(&links).Error; err != nil {
+        return nil, err
+    }
+    return links, nil
+}
+
+func (r *GormLinkRepository) FindDeletedByID(id string) (*models.Link, error) {
+    var link models.Link
+    if err := r.withDetails().Unscoped().Where("deleted_at IS NOT NULL").First(&link, id).Error; err != nil {
+        return nil, err
+    }
+    return &link, nil
 }
 
+// Restore takes a link out of the trash, or returns ErrAliasExists when a
+// live link has taken its alias since
+func (r *GormLinkRepository) Restore(link *models.Link) error {
+    err := r.db.Unscoped().Model(link).UpdateColumns(map[string]any{"deleted_at": nil, "deleted_by": nil}).Error
+    if err != nil { return aliasConflict(err) }
+    link.DeletedAt, link.DeletedBy = gorm.DeletedAt{}, nil
+    return nil
+}
+
+// PurgeDeletedBefore hard-deletes links trashed before cutoff together with
+// their co-owners, revision history, retired and secondary aliases and click log.
+func (r *GormLinkRepository) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+    var purged int64
+    err := r.db.Transaction(func(tx *gorm.DB) error {
+        expired := tx.Unscoped().Model(&models.Link{}).Select("id").Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+        if err := tx.Where("link_id IN (?)", expired).Delete(&models.LinkCoOwner{}).Error; err != nil { return err }
+        if err := tx.Where("link_id IN (?)", expired).Delete(&models.LinkRevision{}).Error; err != nil { return err }
+        if err := tx.Where("link_id IN (?)", expired).Delete(&models.AliasHistory{}).Error; err != nil { return err }
+        if err := tx.Where("link_id IN (?)", expired).Delete(&models.LinkAlias{}).Error; err != nil { return err }
+        if err := tx.Where("link_id IN (?)", expired).Delete(&models.ClickEvent{}).Error; err != nil { return err }
+        res := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&models.Link{})
+        purged = res.RowsAffected
+        return res.Error
+    })
+    return purged, err
+}
+
+func (r *GormLinkRepository) Count() (int64, error) {
+    var n int64
+    err := r.db.Model(&models.Link{}).Count(&n).Error
+    return n, err
+}
+
+func (r *GormLinkRepository) SumClicks() (int64, error) {
+    var sum int64
+    err := r.db.Model(&models.Link{}).Select("COALESCE(SUM(clicks), 0)").Scan(&sum).Error
+    return sum, err
+}
+
+// CountCreators counts the distinct users behind live links. Links created
+// before CreatedBy existed have none and count by their creator name instead.
+func (r *GormLinkRepository) CountCreators() (int64, error) {
+    var n int64
+    err := r.db.Model(&models.Link{}).
+        Select("COUNT(DISTINCT created_by) + COUNT(DISTINCT CASE WHEN created_by IS NULL THEN creator_name END)").
+        Scan(&n).Error
+    return n, err
+}
+
+// TopByClicks returns the limit most clicked links, ties broken by newest first
+func (r *GormLinkRepository) TopByClicks(limit int) ([]models.Link, error) {
+    var links []models.Link
+    if err := r.withDetails().Order("clicks desc, created_at desc").Limit(limit).Find(&links).Error; err != nil {
+        return nil, err
+    }
+    return links, nil
+}
 
+// ListCreatedSince returns links created after since, newest first. A limit
+// of zero or less returns them all.
+func (r *GormLinkRepository) ListCreatedSince(since time.Time, limit int) ([]models.Link, error) {
+    var links []models.Link
+    q := r.withDetails().Where("created_at > ?", since).Order("created_at desc")
+    if limit > 0 { q = q.Limit(limit) }
+    if err := q.Find(&links).Error; err != nil {
+        return nil, err
+    }
+    return links, nil
+}
+
+// FindByIDs returns the live links among ids, in no particular order
+func (r *GormLinkRepository) FindByIDs(ids []uint) ([]models.Link, error) {
+    links := []models.Link{}
+    if len(ids) == 0 { return links, nil }
+    if err := r.withDetails().Where("id IN ?", ids).Find(&links).Error; err != nil {
+        return nil, err
+    }
+    return links, nil
+}
+
+// AliasCandidate is a primary or secondary alias with the target and clicks
+// of the link behind it.
+type AliasCandidate struct {
+    Alias  string
+    URL    string
+    Clicks uint
+}
+
+// ListAliasCandidates returns the primary and secondary aliases of live links
+// not expired by now, reading only the columns suggestions need.
+func (r *GormLinkRepository) ListAliasCandidates(now time.Time) ([]AliasCandidate, error) {
+    var primary, secondary []AliasCandidate
+    err := r.db.Model(&models.Link{}).Select("alias, url, clicks").
+        Where("expires_at IS NULL OR expires_at > ?", now).Scan(&primary).Error
+    if err != nil { return nil, err }
+    err = r.db.Model(&models.LinkAlias{}).Select("link_aliases.alias, links.url, links.clicks").
+        Joins("JOIN links ON links.id = link_aliases.link_id AND links.deleted_at IS NULL").
+        Where("links.expires_at IS NULL OR links.expires_at > ?", now).Scan(&secondary).Error
+    if err != nil { return nil, err }
+    return append(primary, secondary...), nil
+}
+
+// withDetails loads the authors and secondary aliases shown alongside a link
+func (r *GormLinkRepository) withDetails() *gorm.DB {
+    return r.db.Preload("Creator").Preload("Updater").Preload("Aliases", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+}
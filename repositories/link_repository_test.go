@@ -0,0 +1,185 @@
+package repositories
+
+import (
+    "errors"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "quickr/models"
+)
+
+func TestGormLinkRepository_SaveChecksVersion(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "links.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}); err != nil { t.Fatalf("migrate: %v", err) }
+    repo := NewGormLinkRepository(db)
+    if err := repo.Create(&models.Link{Alias: "vpn", URL: "https://vpn.example.com", CreatorName: "alice"}); err != nil { t.Fatalf("create: %v", err) }
+
+    mine, _ := repo.FindByID("1")
+    theirs, _ := repo.FindByID("1")
+    if mine.Version != 1 { t.Fatalf("expected a new link at version 1, got %d", mine.Version) }
+    theirs.URL = "https://theirs.example.com"
+    if err := repo.Save(theirs); err != nil || theirs.Version != 2 { t.Fatalf("expected the first save to win at version 2, got %d err=%v", theirs.Version, err) }
+
+    mine.URL = "https://mine.example.com"
+    if err := repo.Save(mine); err != ErrVersionConflict || mine.Version != 1 { t.Fatalf("expected ErrVersionConflict with the version untouched, got %d err=%v", mine.Version, err) }
+    stored, _ := repo.FindByID("1")
+    if stored.URL != "https://theirs.example.com" || stored.Version != 2 { t.Fatalf("expected their save to stand, got %+v", stored) }
+
+    var n int64
+    db.Model(&models.Link{}).Count(&n)
+    if n != 1 { t.Fatalf("expected a conflict not to insert a copy, got %d links", n) }
+}
+
+func TestGormLinkRepository_LiveAliasIsUnique(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(SQLiteDSN(filepath.Join(t.TempDir(), "links.db"))), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}); err != nil { t.Fatalf("migrate: %v", err) }
+    repo := NewGormLinkRepository(db)
+    for _, alias := range []string{"vpn", "wiki"} {
+        if err := repo.Create(&models.Link{Alias: alias, URL: "https://" + alias + ".example.com", CreatorName: "alice"}); err != nil { t.Fatalf("create %s: %v", alias, err) }
+    }
+
+    if err := repo.Create(&models.Link{Alias: "vpn", URL: "https://other.example.com", CreatorName: "bob"}); err != ErrAliasExists { t.Fatalf("expected a second live vpn to fail with ErrAliasExists, got %v", err) }
+    wiki, _ := repo.FindByID("2")
+    wiki.Alias = "vpn"
+    if err := repo.Save(wiki); err != ErrAliasExists || wiki.Version != 1 { t.Fatalf("expected a rename onto vpn to fail with ErrAliasExists, got %v (version %d)", err, wiki.Version) }
+
+    vpn, _ := repo.FindByID("1")
+    if err := repo.Delete(vpn); err != nil { t.Fatalf("delete: %v", err) }
+    if err := repo.Create(&models.Link{Alias: "vpn", URL: "https://new.example.com", CreatorName: "bob"}); err != nil { t.Fatalf("expected a trashed alias to be reusable, got %v", err) }
+    trashed, _ := repo.FindDeletedByID("1")
+    if err := repo.Restore(trashed); err != ErrAliasExists { t.Fatalf("expected restoring onto a live vpn to fail with ErrAliasExists, got %v", err) }
+}
+
+func TestGormLinkAliasRepository_SecondaryAliasIsUnique(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(SQLiteDSN(filepath.Join(t.TempDir(), "links.db"))), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}); err != nil { t.Fatalf("migrate: %v", err) }
+    links, aliases := NewGormLinkRepository(db), NewGormLinkAliasRepository(db)
+    for _, alias := range []string{"vpn", "wiki", "docs"} {
+        if err := links.Create(&models.Link{Alias: alias, URL: "https://" + alias + ".example.com", CreatorName: "alice"}); err != nil { t.Fatalf("create %s: %v", alias, err) }
+    }
+
+    if err := aliases.Add(1, "wireguard"); err != nil { t.Fatalf("add: %v", err) }
+    if err := aliases.Add(2, "wireguard"); err != ErrAliasExists { t.Fatalf("expected a second live wireguard to fail with ErrAliasExists, got %v", err) }
+
+    // a trashed link gives its secondary alias up to the next link that wants it
+    vpn, _ := links.FindByID("1")
+    if err := links.Delete(vpn); err != nil { t.Fatalf("delete: %v", err) }
+    if err := aliases.Add(2, "wireguard"); err != nil { t.Fatalf("expected a trashed link's alias to be reusable, got %v", err) }
+    if held, _ := aliases.ListByLinkID(1); len(held) != 0 { t.Fatalf("expected the trashed link to lose the alias, got %+v", held) }
+
+    // primary and secondary aliases live in different tables; only the application check spans both
+    if taken, err := links.ExistsByAlias("wireguard"); !taken || err != nil { t.Fatalf("expected a secondary alias to count as taken, got %v %v", taken, err) }
+    if taken, _ := links.ExistsByAliasExceptID("docs", "2"); !taken { t.Fatalf("expected a primary alias to count as taken for another link") }
+}
+
+func TestGormLinkRepository_ListAliasCandidates(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "links.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}); err != nil { t.Fatalf("migrate: %v", err) }
+    links, aliases := NewGormLinkRepository(db), NewGormLinkAliasRepository(db)
+    now := time.Now()
+    past, future := now.Add(-time.Hour), now.Add(time.Hour)
+    for _, l := range []models.Link{
+        {Alias: "vpn", URL: "https://vpn.example.com", Clicks: 3, CreatorName: "alice", ExpiresAt: &future},
+        {Alias: "old", URL: "https://old.example.com", CreatorName: "alice", ExpiresAt: &past},
+        {Alias: "gone", URL: "https://gone.example.com", CreatorName: "alice"},
+    } {
+        if err := links.Create(&l); err != nil { t.Fatalf("create %s: %v", l.Alias, err) }
+    }
+    aliases.Add(1, "wireguard")
+    aliases.Add(2, "legacy")
+    aliases.Add(3, "trashed")
+    gone, _ := links.FindByID("3")
+    links.Delete(gone)
+
+    got, err := links.ListAliasCandidates(now)
+    want := []AliasCandidate{{"vpn", "https://vpn.example.com", 3}, {"wireguard", "https://vpn.example.com", 3}}
+    if err != nil || !reflect.DeepEqual(got, want) { t.Fatalf("expected only the live, unexpired aliases, got %+v err=%v", got, err) }
+}
+
+func TestGormLinkRepository_CountCreators(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "links.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}); err != nil { t.Fatalf("migrate: %v", err) }
+    repo := NewGormLinkRepository(db)
+    alice, bob := uint(1), uint(2)
+    for _, l := range []models.Link{
+        // two users sharing a display name still count twice
+        {Alias: "vpn", CreatorName: "Admin", CreatedBy: &alice},
+        {Alias: "wiki", CreatorName: "Admin", CreatedBy: &bob},
+        {Alias: "docs", CreatorName: "Admin", CreatedBy: &bob},
+        // links from before CreatedBy fall back to the name
+        {Alias: "old", CreatorName: "carol"},
+        {Alias: "older", CreatorName: "carol"},
+        {Alias: "trashed", CreatorName: "dave"},
+    } {
+        l.URL = "https://" + l.Alias + ".example.com"
+        if err := repo.Create(&l); err != nil { t.Fatalf("create %s: %v", l.Alias, err) }
+    }
+    trashed, _ := repo.FindByID("6")
+    repo.Delete(trashed)
+
+    if n, err := repo.CountCreators(); n != 3 || err != nil { t.Fatalf("expected alice, bob and carol, got %d err=%v", n, err) }
+}
+
+func TestPrepareSecondaryAliasIndex(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "links.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if n, err := PrepareSecondaryAliasIndex(db); n != 0 || err != nil { t.Fatalf("expected a fresh database to need nothing, got %d %v", n, err) }
+
+    // the shape of a database from before the unique index
+    db.Exec(`CREATE TABLE links (id integer PRIMARY KEY, alias text NOT NULL, url text, deleted_at datetime)`)
+    db.Exec(`CREATE TABLE link_aliases (id integer PRIMARY KEY, link_id integer NOT NULL, alias text NOT NULL, created_at datetime)`)
+    db.Exec(`CREATE INDEX idx_link_aliases_alias ON link_aliases(alias)`)
+    db.Exec(`INSERT INTO links (id, alias, url, deleted_at) VALUES (1, 'vpn', 'a', '2024-01-01'), (2, 'wiki', 'b', NULL), (3, 'docs', 'c', NULL), (4, 'old', 'd', '2024-01-01')`)
+    db.Exec(`INSERT INTO link_aliases (id, link_id, alias) VALUES (1, 1, 'wg'), (2, 2, 'wg'), (3, 4, 'wg'), (4, 3, 'manual'), (5, 1, 'gone'), (6, 4, 'gone')`)
+    if n, err := PrepareSecondaryAliasIndex(db); n != 3 || err != nil { t.Fatalf("expected the trashed duplicates dropped, got %d %v", n, err) }
+
+    var kept []uint
+    db.Table("link_aliases").Order("id").Pluck("id", &kept)
+    if len(kept) != 3 || kept[0] != 2 || kept[1] != 4 || kept[2] != 5 { t.Fatalf("expected the live holder of wg and the oldest trashed holder of gone kept, got %v", kept) }
+    if err := db.AutoMigrate(&models.LinkAlias{}); err != nil { t.Fatalf("expected the unique index to build afterwards, got %v", err) }
+    if err := db.Exec(`INSERT INTO link_aliases (link_id, alias) VALUES (3, 'wg')`).Error; err == nil { t.Fatalf("expected the unique index in place") }
+
+    // two live links on one alias are left for the operator to settle
+    db.Exec(`DROP INDEX idx_link_aliases_unique_alias`)
+    db.Exec(`INSERT INTO link_aliases (id, link_id, alias) VALUES (7, 3, 'wg')`)
+    _, err = PrepareSecondaryAliasIndex(db)
+    var dup *DuplicateAliasesError
+    if !errors.As(err, &dup) || !reflect.DeepEqual(dup.Conflicts, []AliasConflict{{Alias: "wg", LinkIDs: []uint{2, 3}}}) { t.Fatalf("expected wg reported for links 2 and 3, got %v", err) }
+    var n int64
+    db.Table("link_aliases").Count(&n)
+    if n != 4 { t.Fatalf("expected no live alias dropped, got %d rows", n) }
+}
+
+func TestPrepareLiveAliasIndex(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "links.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := PrepareLiveAliasIndex(db); err != nil { t.Fatalf("expected a fresh database to need nothing, got %v", err) }
+
+    // the shape of a database from before the unique index
+    db.Exec(`CREATE TABLE links (id integer PRIMARY KEY, alias text NOT NULL, url text, deleted_at datetime)`)
+    db.Exec(`CREATE INDEX idx_alias_deleted ON links(alias, deleted_at)`)
+    db.Exec(`INSERT INTO links (id, alias, url, deleted_at) VALUES (1, 'vpn', 'a', NULL), (2, 'vpn', 'b', NULL), (3, 'wiki', 'c', NULL), (4, 'vpn', 'd', NULL), (5, 'wiki', 'e', '2024-01-01')`)
+    err = PrepareLiveAliasIndex(db)
+    var dup *DuplicateAliasesError
+    if !errors.As(err, &dup) || !reflect.DeepEqual(dup.Conflicts, []AliasConflict{{Alias: "vpn", LinkIDs: []uint{1, 2, 4}}}) { t.Fatalf("expected vpn reported for links 1, 2 and 4, got %v", err) }
+    if !strings.Contains(err.Error(), `"vpn" held by links [1 2 4]`) { t.Fatalf("expected the conflict named in the error, got %q", err) }
+    var live int64
+    db.Table("links").Where("deleted_at IS NULL").Count(&live)
+    if live != 4 { t.Fatalf("expected no link moved to the trash, got %d live", live) }
+
+    db.Exec(`UPDATE links SET alias = 'vpn-old' WHERE id = 2`)
+    db.Exec(`UPDATE links SET deleted_at = '2024-01-01' WHERE id = 4`)
+    if err := PrepareLiveAliasIndex(db); err != nil { t.Fatalf("expected the settled database to pass, got %v", err) }
+    if err := db.Exec(`CREATE UNIQUE INDEX idx_links_live_alias ON links(alias) WHERE deleted_at IS NULL`).Error; err != nil { t.Fatalf("expected the unique index to build afterwards, got %v", err) }
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+type LinkRevisionRepository interface {
+    Create(rev *models.LinkRevision) error
+    ListByLinkID(linkID string) ([]models.LinkRevision, error)
+    FindByID(id string) (*models.LinkRevision, error)
+}
+
+type GormLinkRevisionRepository struct { db *gorm.DB }
+
+func NewGormLinkRevisionRepository(db *gorm.DB) *GormLinkRevisionRepository { return &GormLinkRevisionRepository{db: db} }
+
+func (r *GormLinkRevisionRepository) Create(rev *models.LinkRevision) error { return r.db.Create(rev).Error }
+
+// ListByLinkID returns a link's revisions, newest first
+func (r *GormLinkRevisionRepository) ListByLinkID(linkID string) ([]models.LinkRevision, error) {
+    var revs []models.LinkRevision
+    if err := r.db.Where("link_id = ?", linkID).Order("created_at desc, id desc").Find(&revs).Error; err != nil {
+        return nil, err
+    }
+    return revs, nil
+}
+
+func (r *GormLinkRevisionRepository) FindByID(id string) (*models.LinkRevision, error) {
+    var rev models.LinkRevision
+    if err := r.db.First(&rev, id).Error; err != nil { return nil, err }
+    return &rev, nil
+}
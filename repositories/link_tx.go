@@ -0,0 +1,51 @@
+package repositories
+
+import (
+    "errors"
+
+    "github.com/mattn/go-sqlite3"
+    "gorm.io/gorm"
+)
+
+// SQLiteDSN opens the database at path so that concurrent writers queue up:
+// transactions take the write lock when they begin and wait up to five
+// seconds for it instead of failing with "database is locked".
+func SQLiteDSN(path string) string { return path + "?_busy_timeout=5000&_txlock=immediate" }
+
+// LinkStores are the link tables a multi-step change writes to, all bound to
+// the same transaction.
+type LinkStores struct {
+    Links        LinkRepository
+    Revisions    LinkRevisionRepository
+    Aliases      LinkAliasRepository
+    AliasHistory AliasHistoryRepository
+}
+
+// LinkTransactor runs fn against stores sharing one transaction, committing
+// when fn returns nil and rolling back otherwise.
+type LinkTransactor interface {
+    InLinkTx(fn func(LinkStores) error) error
+}
+
+type GormLinkTransactor struct { db *gorm.DB }
+
+func NewGormLinkTransactor(db *gorm.DB) *GormLinkTransactor { return &GormLinkTransactor{db: db} }
+
+func (t *GormLinkTransactor) InLinkTx(fn func(LinkStores) error) error {
+    return t.db.Transaction(func(tx *gorm.DB) error {
+        return fn(LinkStores{
+            Links:        NewGormLinkRepository(tx),
+            Revisions:    NewGormLinkRevisionRepository(tx),
+            Aliases:      NewGormLinkAliasRepository(tx),
+            AliasHistory: NewGormAliasHistoryRepository(tx),
+        })
+    })
+}
+
+// aliasConflict turns a unique index violation into ErrAliasExists; the only
+// unique columns written with links are their primary and secondary aliases.
+func aliasConflict(err error) error {
+    var sqliteErr sqlite3.Error
+    if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique { return ErrAliasExists }
+    return err
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+    "fmt"
+    "strings"
+
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+// BackfillLinkAuthors fills links.created_by for rows that predate user
+// references by matching the free-text creator_name against user emails, or
+// against adminName for links admins created while they were recorded under
+// the shared display name. It also seeds
+// updated_at from created_at. Safe to run on every start.
+func BackfillLinkAuthors(db *gorm.DB, adminEmail, adminName string) error {
+    if err := db.Unscoped().Model(&models.Link{}).Where("updated_at IS NULL").UpdateColumn("updated_at", gorm.Expr("created_at")).Error; err != nil {
+        return err
+    }
+    byEmail := db.Model(&models.User{}).Select("id").Where("users.email = LOWER(TRIM(links.creator_name))").Limit(1)
+    if err := db.Unscoped().Model(&models.Link{}).
+        Where("created_by IS NULL AND EXISTS (?)", byEmail).
+        UpdateColumn("created_by", byEmail).Error; err != nil {
+        return err
+    }
+    if strings.TrimSpace(adminName) == "" {
+        return nil
+    }
+    var admin models.User
+    q := db.Where("role = ?", "admin")
+    if e := strings.TrimSpace(strings.ToLower(adminEmail)); e != "" {
+        q = db.Where("email = ?", e)
+    }
+    if err := q.Order("id").First(&admin).Error; err != nil {
+        return nil // no admin account yet; retried on the next start
+    }
+    return db.Unscoped().Model(&models.Link{}).
+        Where("created_by IS NULL AND creator_name = ?", adminName).
+        UpdateColumn("created_by", admin.ID).Error
+}
+
+// AliasConflict is an alias held by more than one live link, oldest first.
+type AliasConflict struct {
+    Alias   string
+    LinkIDs []uint
+}
+
+// DuplicateAliasesError stops startup when an existing database holds live
+// duplicates a unique index cannot be built over. Which link keeps the alias
+// is left to the operator.
+type DuplicateAliasesError struct {
+    Index     string
+    Conflicts []AliasConflict
+}
+
+func (e *DuplicateAliasesError) Error() string {
+    parts := make([]string, len(e.Conflicts))
+    for i, c := range e.Conflicts { parts[i] = fmt.Sprintf("%q held by links %v", c.Alias, c.LinkIDs) }
+    return fmt.Sprintf("cannot build %s: %s; rename or delete all but one link of each, then restart", e.Index, strings.Join(parts, ", "))
+}
+
+// PrepareLiveAliasIndex readies an existing database for the unique index on
+// live aliases. Aliases used to be checked only by the application, so two
+// concurrent creates could leave two live links on one alias; those are
+// returned as a *DuplicateAliasesError rather than resolved here. Run it
+// before AutoMigrate, which cannot build the index over duplicates.
+func PrepareLiveAliasIndex(db *gorm.DB) error {
+    if !db.Migrator().HasTable(&models.Link{}) { return nil }
+    // superseded by idx_links_live_alias; NULL deleted_at values never collided in it
+    if err := db.Exec("DROP INDEX IF EXISTS idx_alias_deleted").Error; err != nil { return err }
+    conflicts, err := aliasConflicts(db, `SELECT alias, id AS link_id FROM links
+        WHERE deleted_at IS NULL AND alias IN (SELECT alias FROM links WHERE deleted_at IS NULL GROUP BY alias HAVING COUNT(*) > 1)
+        ORDER BY alias, id`)
+    if err != nil { return err }
+    if len(conflicts) > 0 { return &DuplicateAliasesError{Index: "idx_links_live_alias", Conflicts: conflicts} }
+    return nil
+}
+
+// PrepareSecondaryAliasIndex readies an existing database for the unique
+// index on secondary aliases, which used to be checked only by the
+// application. Trashed links give up an alias another row holds, as they do
+// when it is reused; of the rows where all holders are trashed the oldest is
+// kept. Duplicates among live links are returned as a *DuplicateAliasesError.
+// Run it before AutoMigrate. Returns how many aliases of trashed links were
+// dropped.
+func PrepareSecondaryAliasIndex(db *gorm.DB) (int64, error) {
+    if !db.Migrator().HasTable(&models.LinkAlias{}) { return 0, nil }
+    // superseded by idx_link_aliases_unique_alias
+    if err := db.Exec("DROP INDEX IF EXISTS idx_link_aliases_alias").Error; err != nil { return 0, err }
+    res := db.Exec(`DELETE FROM link_aliases WHERE id IN (
+        SELECT id FROM (
+            SELECT link_aliases.id, links.deleted_at IS NULL AND links.id IS NOT NULL AS live,
+                ROW_NUMBER() OVER (PARTITION BY link_aliases.alias ORDER BY links.deleted_at IS NOT NULL OR links.id IS NULL, link_aliases.id) AS n
+            FROM link_aliases LEFT JOIN links ON links.id = link_aliases.link_id
+        ) WHERE n > 1 AND NOT live)`)
+    if res.Error != nil { return 0, res.Error }
+    conflicts, err := aliasConflicts(db, `SELECT link_aliases.alias, link_aliases.link_id FROM link_aliases
+        JOIN links ON links.id = link_aliases.link_id AND links.deleted_at IS NULL
+        WHERE link_aliases.alias IN (
+            SELECT link_aliases.alias FROM link_aliases JOIN links ON links.id = link_aliases.link_id AND links.deleted_at IS NULL
+            GROUP BY link_aliases.alias HAVING COUNT(*) > 1)
+        ORDER BY link_aliases.alias, link_aliases.id`)
+    if err != nil { return res.RowsAffected, err }
+    if len(conflicts) > 0 { return res.RowsAffected, &DuplicateAliasesError{Index: "idx_link_aliases_unique_alias", Conflicts: conflicts} }
+    return res.RowsAffected, nil
+}
+
+// aliasConflicts groups the (alias, link_id) rows query returns, ordered by
+// alias, into one conflict per alias.
+func aliasConflicts(db *gorm.DB, query string) ([]AliasConflict, error) {
+    var rows []struct {
+        Alias  string
+        LinkID uint
+    }
+    if err := db.Raw(query).Scan(&rows).Error; err != nil { return nil, err }
+    var out []AliasConflict
+    for _, r := range rows {
+        if n := len(out); n > 0 && out[n-1].Alias == r.Alias {
+            out[n-1].LinkIDs = append(out[n-1].LinkIDs, r.LinkID)
+            continue
+        }
+        out = append(out, AliasConflict{Alias: r.Alias, LinkIDs: []uint{r.LinkID}})
+    }
+    return out, nil
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+type SessionRepository interface {
+    Create(s *models.Session) error
+    FindByID(id string) (*models.Session, error)
+    ListByUser(userID uint, now time.Time) ([]models.Session, error)
+    Delete(userID uint, id string) error
+    DeleteByID(id string) error
+    DeleteByUser(userID uint) error
+    DeleteExpired(now time.Time) error
+    TouchLastSeen(id string, at time.Time) error
+}
+
+type GormSessionRepository struct { db *gorm.DB }
+
+func NewGormSessionRepository(db *gorm.DB) *GormSessionRepository { return &GormSessionRepository{db: db} }
+
+func (r *GormSessionRepository) Create(s *models.Session) error { return r.db.Create(s).Error }
+
+func (r *GormSessionRepository) FindByID(id string) (*models.Session, error) {
+    var s models.Session
+    if err := r.db.Where("id = ?", id).First(&s).Error; err != nil { return nil, err }
+    return &s, nil
+}
+
+// ListByUser returns a user's unexpired sessions, most recently seen first
+func (r *GormSessionRepository) ListByUser(userID uint, now time.Time) ([]models.Session, error) {
+    var sessions []models.Session
+    if err := r.db.Where("user_id = ? AND expires_at > ?", userID, now).Order("last_seen_at desc, created_at desc").Find(&sessions).Error; err != nil { return nil, err }
+    return sessions, nil
+}
+
+// Delete removes one of userID's sessions; ErrNotFound when userID has no such session
+func (r *GormSessionRepository) Delete(userID uint, id string) error {
+    res := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Session{})
+    if res.Error != nil { return res.Error }
+    if res.RowsAffected == 0 { return ErrNotFound }
+    return nil
+}
+
+func (r *GormSessionRepository) DeleteByID(id string) error {
+    return r.db.Where("id = ?", id).Delete(&models.Session{}).Error
+}
+
+func (r *GormSessionRepository) DeleteByUser(userID uint) error {
+    return r.db.Where("user_id = ?", userID).Delete(&models.Session{}).Error
+}
+
+func (r *GormSessionRepository) DeleteExpired(now time.Time) error {
+    return r.db.Where("expires_at <= ?", now).Delete(&models.Session{}).Error
+}
+
+func (r *GormSessionRepository) TouchLastSeen(id string, at time.Time) error {
+    return r.db.Model(&models.Session{}).Where("id = ?", id).UpdateColumn("last_seen_at", at).Error
+}
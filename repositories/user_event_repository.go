@@ -0,0 +1,26 @@
+package repositories
+
+import (
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+type UserEventRepository interface {
+    Create(event *models.UserEvent) error
+    List(limit int) ([]models.UserEvent, error)
+}
+
+type GormUserEventRepository struct { db *gorm.DB }
+
+func NewGormUserEventRepository(db *gorm.DB) *GormUserEventRepository { return &GormUserEventRepository{db: db} }
+
+func (r *GormUserEventRepository) Create(event *models.UserEvent) error { return r.db.Create(event).Error }
+
+// List returns the most recent events first
+func (r *GormUserEventRepository) List(limit int) ([]models.UserEvent, error) {
+    var events []models.UserEvent
+    tx := r.db.Order("created_at desc, id desc")
+    if limit > 0 { tx = tx.Limit(limit) }
+    if err := tx.Find(&events).Error; err != nil { return nil, err }
+    return events, nil
+}
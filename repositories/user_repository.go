@@ -1,6 +1,7 @@
 package repositories
 
 import (
+    "errors"
     "strings"
 
     "gorm.io/gorm"
@@ -12,8 +13,18 @@ type UserRepository interface {
     Save(user *models.User) error
     Create(user *models.User) error
     ListByEmails(emails []string) ([]models.User, error)
+    HasAdmin() (bool, error)
+    FindByID(id uint) (*models.User, error)
+    List() ([]models.User, error)
+    SetRole(id uint, role string) error
+    SetDisabled(id uint, disabled bool) error
+    Delete(id uint, reassignTo *uint) error
+    BumpSessionGeneration(id uint) error
 }
 
+// ErrLastAdmin is returned when a change would leave no enabled admin.
+var ErrLastAdmin = errors.New("at least one admin must remain")
+
 type GormUserRepository struct { db *gorm.DB }
 
 func NewGormUserRepository(db *gorm.DB) *GormUserRepository { return &GormUserRepository{db: db} }
@@ -35,6 +46,106 @@ func (r *GormUserRepository) ListByEmails(emails []string) ([]models.User, error
     return users, nil
 }
 
+func (r *GormUserRepository) HasAdmin() (bool, error) {
+    var n int64
+    err := r.db.Model(&models.User{}).Where("role = ?", "admin").Count(&n).Error
+    return n > 0, err
+}
+
+func (r *GormUserRepository) FindByID(id uint) (*models.User, error) {
+    var u models.User
+    if err := r.db.First(&u, id).Error; err != nil { return nil, err }
+    return &u, nil
+}
+
+// List returns every user, admins first, then by email.
+func (r *GormUserRepository) List() ([]models.User, error) {
+    var users []models.User
+    err := r.db.Order("role = 'admin' DESC, email").Find(&users).Error
+    return users, err
+}
+
+// SetRole changes a user's role. Taking admin away fails with ErrLastAdmin
+// unless another enabled admin remains; the check is part of the update, so
+// two admins demoting each other at once cannot both succeed.
+func (r *GormUserRepository) SetRole(id uint, role string) error {
+    q := r.db.Model(&models.User{}).Where("id = ?", id)
+    if role != "admin" { q = q.Where(keepsAnAdmin(r.db, id)) }
+    return r.guarded(id, q.Update("role", role))
+}
+
+// SetDisabled disables or re-enables a user. Disabling deletes their stored
+// sessions and fails with ErrLastAdmin like demoting does.
+func (r *GormUserRepository) SetDisabled(id uint, disabled bool) error {
+    if !disabled { return r.guarded(id, r.db.Model(&models.User{}).Where("id = ?", id).Update("disabled", false)) }
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        repo := &GormUserRepository{db: tx}
+        if err := repo.guarded(id, tx.Model(&models.User{}).Where("id = ?", id).Where(keepsAnAdmin(tx, id)).Update("disabled", true)); err != nil { return err }
+        return tx.Where("user_id = ?", id).Delete(&models.Session{}).Error
+    })
+}
+
+// Delete removes a user along with their co-ownerships, API tokens and sessions. Their
+// links are handed to reassignTo or, when it is nil, kept without an owner
+// account: they still show the recorded creator name and only admins can
+// change them. Revisions and alias history keep the user's name but lose the
+// reference. Deleting the last enabled admin fails with ErrLastAdmin.
+func (r *GormUserRepository) Delete(id uint, reassignTo *uint) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        repo := &GormUserRepository{db: tx}
+        if err := repo.guarded(id, tx.Where("id = ?", id).Where(keepsAnAdmin(tx, id)).Delete(&models.User{})); err != nil { return err }
+        // checked after the delete so a user cannot be handed their own links
+        if reassignTo != nil {
+            if _, err := repo.FindByID(*reassignTo); err != nil { return err }
+        }
+        links := tx.Unscoped().Model(&models.Link{})
+        if err := links.Where("created_by = ?", id).UpdateColumn("created_by", reassignTo).Error; err != nil { return err }
+        for _, ref := range []struct {
+            model  any
+            column string
+        }{
+            {&models.Link{}, "updated_by"},
+            {&models.Link{}, "deleted_by"},
+            {&models.LinkRevision{}, "actor_id"},
+            {&models.AliasHistory{}, "retired_by"},
+        } {
+            if err := tx.Unscoped().Model(ref.model).Where(ref.column+" = ?", id).UpdateColumn(ref.column, nil).Error; err != nil { return err }
+        }
+        if err := tx.Where("user_id = ?", id).Delete(&models.LinkCoOwner{}).Error; err != nil { return err }
+        if err := tx.Where("user_id = ?", id).Delete(&models.Session{}).Error; err != nil { return err }
+        return tx.Where("user_id = ?", id).Delete(&models.APIToken{}).Error
+    })
+}
+
+// BumpSessionGeneration invalidates every session cookie issued to the user
+// so far and deletes their stored sessions.
+func (r *GormUserRepository) BumpSessionGeneration(id uint) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        res := tx.Model(&models.User{}).Where("id = ?", id).UpdateColumn("session_generation", gorm.Expr("session_generation + 1"))
+        if res.Error == nil && res.RowsAffected == 0 { return ErrNotFound }
+        if res.Error != nil { return res.Error }
+        return tx.Where("user_id = ?", id).Delete(&models.Session{}).Error
+    })
+}
+
+// keepsAnAdmin limits a change to user id to when they are not an enabled
+// admin or another enabled admin remains.
+func keepsAnAdmin(db *gorm.DB, id uint) *gorm.DB {
+    others := db.Model(&models.User{}).Select("COUNT(*)").Where("role = ? AND disabled = ? AND id <> ?", "admin", false, id)
+    return db.Where("role <> ? OR disabled = ? OR (?) > 0", "admin", true, others)
+}
+
+// guarded reports why a change to user id guarded by keepsAnAdmin touched
+// no row: the user does not exist or is the last admin.
+func (r *GormUserRepository) guarded(id uint, res *gorm.DB) error {
+    if res.Error != nil { return res.Error }
+    if res.RowsAffected == 0 {
+        if _, err := r.FindByID(id); err != nil { return err }
+        return ErrLastAdmin
+    }
+    return nil
+}
+
 var ErrNotFound = gorm.ErrRecordNotFound
 
 
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+    "path/filepath"
+    "sync"
+    "testing"
+
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "quickr/models"
+)
+
+func TestGormUserRepository_SetRoleKeepsAnAdmin(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(SQLiteDSN(filepath.Join(t.TempDir(), "users.db"))), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}); err != nil { t.Fatalf("migrate: %v", err) }
+    repo := NewGormUserRepository(db)
+    for _, u := range []models.User{
+        {Email: "alice@example.com", Role: "admin"},
+        {Email: "bob@example.com", Role: "user"},
+        {Email: "carol@example.com", Role: "admin", Disabled: true},
+    } {
+        if err := repo.Create(&u); err != nil { t.Fatalf("seed: %v", err) }
+    }
+
+    if err := repo.SetRole(1, "user"); err != ErrLastAdmin { t.Fatalf("expected the only enabled admin to stay, got %v", err) }
+    if err := repo.SetRole(2, "user"); err != nil { t.Fatalf("expected demoting a non-admin to be a no-op, got %v", err) }
+    if err := repo.SetRole(9, "user"); err != ErrNotFound { t.Fatalf("expected ErrNotFound for an unknown user, got %v", err) }
+    if err := repo.SetRole(2, "admin"); err != nil { t.Fatalf("promote: %v", err) }
+
+    // with two admins demoting each other at once, one must remain
+    var wg sync.WaitGroup
+    errs := make([]error, 2)
+    for i, id := range []uint{1, 2} {
+        wg.Add(1)
+        go func(i int, id uint) { defer wg.Done(); errs[i] = repo.SetRole(id, "user") }(i, id)
+    }
+    wg.Wait()
+    if (errs[0] == nil) == (errs[1] == nil) { t.Fatalf("expected exactly one demotion to succeed, got %v", errs) }
+    users, _ := repo.List()
+    if users[0].Role != "admin" || users[0].Disabled { t.Fatalf("expected an enabled admin listed first, got %+v", users) }
+}
+
+func TestGormUserRepository_Delete(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkRevision{}, &models.LinkCoOwner{}, &models.AliasHistory{}, &models.APIToken{}, &models.Session{}); err != nil { t.Fatalf("migrate: %v", err) }
+    repo := NewGormUserRepository(db)
+    for _, email := range []string{"root@example.com", "bob@example.com", "carol@example.com"} {
+        role := "user"
+        if email == "root@example.com" { role = "admin" }
+        if err := repo.Create(&models.User{Email: email, Role: role}); err != nil { t.Fatalf("seed: %v", err) }
+    }
+    bob, carol := uint(2), uint(3)
+    db.Create(&models.Link{Alias: "vpn", URL: "https://vpn.example.com", CreatorName: "bob@example.com", CreatedBy: &bob, UpdatedBy: &bob})
+    db.Create(&models.Link{Alias: "wiki", URL: "https://wiki.example.com", CreatorName: "carol@example.com", CreatedBy: &carol, UpdatedBy: &bob})
+    db.Create(&models.LinkRevision{LinkID: 1, Action: "create", Actor: "bob@example.com", ActorID: &bob})
+    db.Create(&models.LinkCoOwner{LinkID: 2, UserID: bob})
+    db.Create(&models.APIToken{UserID: bob, Name: "cli", TokenHash: "h", Prefix: "qk_h"})
+    db.Create(&models.Session{ID: "s1", UserID: bob})
+
+    if err := repo.Delete(1, nil); err != ErrLastAdmin { t.Fatalf("expected the last admin to stay, got %v", err) }
+    if err := repo.Delete(bob, &bob); err != ErrNotFound { t.Fatalf("expected bob's links not to go to bob, got %v", err) }
+    if err := repo.Delete(bob, nil); err != nil { t.Fatalf("delete bob: %v", err) }
+    if _, err := repo.FindByID(bob); err != ErrNotFound { t.Fatalf("expected bob deleted, got %v", err) }
+
+    var vpn, wiki models.Link
+    db.First(&vpn, 1)
+    db.First(&wiki, 2)
+    if vpn.CreatedBy != nil || vpn.CreatorName != "bob@example.com" { t.Fatalf("expected bob's link kept under his name without an owner, got %+v", vpn) }
+    if vpn.UpdatedBy != nil || wiki.UpdatedBy != nil || wiki.CreatedBy == nil || *wiki.CreatedBy != carol { t.Fatalf("expected only references to bob cleared, got %+v %+v", vpn, wiki) }
+    var rev models.LinkRevision
+    db.First(&rev)
+    if rev.ActorID != nil || rev.Actor != "bob@example.com" { t.Fatalf("expected the revision to keep the name but lose the reference, got %+v", rev) }
+    var coOwners, tokens, sessions int64
+    db.Model(&models.LinkCoOwner{}).Count(&coOwners)
+    db.Model(&models.APIToken{}).Count(&tokens)
+    db.Model(&models.Session{}).Count(&sessions)
+    if coOwners != 0 || tokens != 0 || sessions != 0 { t.Fatalf("expected bob's co-ownerships, tokens and sessions gone, got %d %d %d", coOwners, tokens, sessions) }
+
+    root := uint(1)
+    if err := repo.Delete(carol, &root); err != nil { t.Fatalf("delete carol: %v", err) }
+    db.First(&wiki, 2)
+    if wiki.CreatedBy == nil || *wiki.CreatedBy != root || wiki.CreatorName != "carol@example.com" { t.Fatalf("expected carol's link handed to root, got %+v", wiki) }
+    if err := repo.Delete(9, &root); err != ErrNotFound { t.Fatalf("expected ErrNotFound, got %v", err) }
+    dave := &models.User{Email: "dave@example.com", Role: "user"}
+    repo.Create(dave)
+    if err := repo.Delete(dave.ID, &carol); err != ErrNotFound { t.Fatalf("expected an unknown target to be refused, got %v", err) }
+    if _, err := repo.FindByID(dave.ID); err != nil { t.Fatalf("expected the refused delete rolled back, got %v", err) }
+}
@@ -0,0 +1,33 @@
+package services
+
+import "quickr/models"
+
+// Actor is who performs a change: the user account behind it, if any, the
+// name recorded on the link and in revision history (the user's email), and
+// whether it is an admin.
+type Actor struct {
+    UserID *uint
+    Name   string
+    Admin  bool
+}
+
+// sweeperActor attributes background expiry deletes
+var sweeperActor = Actor{Name: "expiry sweeper"}
+
+// WithAdminName shows admin accounts under this name instead of their email.
+func WithAdminName(name string) LinkServiceOption {
+    return func(s *LinkService) { s.adminName = name }
+}
+
+// actorName is how actor is shown right after a change, before the link is
+// read back with its users.
+func (s *LinkService) actorName(actor Actor) string {
+    if actor.Admin && s.adminName != "" { return s.adminName }
+    return actor.Name
+}
+
+func (s *LinkService) displayName(u *models.User, fallback string) string {
+    if u == nil { return fallback }
+    if u.Role == "admin" && s.adminName != "" { return s.adminName }
+    return u.Email
+}
@@ -0,0 +1,31 @@
+package services
+
+import "quickr/repositories"
+
+// AliasCache is a lookup cache in front of the link repository. LinkService
+// invalidates it on every change that moves an alias.
+type AliasCache interface {
+    Invalidate(aliases ...string)
+    InvalidateLink(id uint)
+    Stats() repositories.AliasCacheStats
+}
+
+// WithAliasCache keeps cache in sync with link changes. The cache itself must
+// sit in front of the repository passed to NewLinkService.
+func WithAliasCache(cache AliasCache) LinkServiceOption {
+    return func(s *LinkService) { s.aliasCache = cache }
+}
+
+// AliasCacheStats reports the cache's hit counters; ok is false without a cache.
+func (s *LinkService) AliasCacheStats() (stats repositories.AliasCacheStats, ok bool) {
+    if s.aliasCache == nil { return stats, false }
+    return s.aliasCache.Stats(), true
+}
+
+// forget drops the cached lookups that resolved to link id, and any cached
+// answer for aliases, which link id may just have claimed.
+func (s *LinkService) forget(id uint, aliases ...string) {
+    if s.aliasCache == nil { return }
+    s.aliasCache.InvalidateLink(id)
+    s.aliasCache.Invalidate(aliases...)
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+    "testing"
+    "time"
+
+    "gorm.io/gorm"
+    "quickr/models"
+    "quickr/repositories"
+)
+
+// Redirects answered from the cache must follow every create, rename, alias
+// change and delete straight away.
+func TestAliasCache_InvalidatedByLinkChanges(t *testing.T) {
+    stored := &models.Link{}
+    deleted := false
+    aliases := &fakeLinkAliasRepo{}
+    repo := aliasedLinkRepo(stored, aliases)
+    repo.FindByAliasFunc = func(alias string) (*models.Link, error) {
+        if deleted || stored.ID == 0 || (alias != stored.Alias && !hasAlias(aliases.aliases, alias)) { return nil, gorm.ErrRecordNotFound }
+        cp := *stored
+        return &cp, nil
+    }
+    repo.DeleteFunc = func(link *models.Link) error { deleted = true; return nil }
+    cache := repositories.NewCachedLinkRepository(repo, 100, time.Hour)
+    svc := NewLinkService(cache, WithLinkAliases(aliases), WithAliasCache(cache))
+
+    resolves := func(alias string) bool {
+        _, _, err := svc.ResolveTarget(alias, nil, "")
+        return err == nil
+    }
+    if resolves("vpn") || resolves("wireguard") { t.Fatal("expected unknown aliases not to resolve") }
+    if _, err := svc.CreateLink("vpn", "https://vpn.example.com", alice); err != nil { t.Fatalf("create: %v", err) }
+    if !resolves("vpn") || !resolves("vpn") { t.Fatal("expected a cached miss to be dropped on create") }
+
+    if _, err := svc.EditLink("7", "wireguard", "", LinkOptions{}, alice); err != nil { t.Fatalf("rename: %v", err) }
+    if resolves("vpn") || !resolves("wireguard") { t.Fatal("expected the rename to move the cached alias") }
+
+    if _, err := svc.AddAlias("7", "vpn-setup", alice); err != nil { t.Fatalf("add alias: %v", err) }
+    if !resolves("vpn-setup") { t.Fatal("expected a new secondary alias to resolve") }
+    if _, err := svc.RemoveAlias("7", "vpn-setup", alice); err != nil { t.Fatalf("remove alias: %v", err) }
+    if resolves("vpn-setup") { t.Fatal("expected a removed secondary alias to stop resolving") }
+
+    if _, err := svc.DeleteLink("7", alice); err != nil { t.Fatalf("delete: %v", err) }
+    if resolves("wireguard") { t.Fatal("expected a deleted link to stop resolving") }
+
+    stats, ok := svc.AliasCacheStats()
+    if !ok || stats.Hits == 0 || stats.Misses == 0 { t.Fatalf("expected hits and misses counted, got %+v ok=%v", stats, ok) }
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+    "errors"
+    "log"
+    "strconv"
+    "time"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+var ErrAliasRetired = errors.New("alias still forwards to a renamed link")
+
+// WithAliasHistory keeps renamed aliases forwarding to their link for ttl;
+// a zero ttl forwards forever.
+func WithAliasHistory(history repositories.AliasHistoryRepository, ttl time.Duration) LinkServiceOption {
+    return func(s *LinkService) {
+        s.aliasHistory = history
+        s.aliasForwardTTL = ttl
+    }
+}
+
+// FindRetiredAlias returns the live link a retired alias still forwards to.
+func (s *LinkService) FindRetiredAlias(alias string) (*models.Link, error) {
+    if s.aliasHistory == nil { return nil, ErrLinkNotFound }
+    entry, err := s.aliasHistory.FindActive(alias, time.Now())
+    if err != nil { return nil, ErrLinkNotFound }
+    link, err := s.repo.FindByID(strconv.FormatUint(uint64(entry.LinkID), 10))
+    if err != nil { return nil, ErrLinkNotFound }
+    return link, nil
+}
+
+// ReleaseRetiredAlias stops a retired alias from forwarding so a new link can take it.
+func (s *LinkService) ReleaseRetiredAlias(alias string) error {
+    if s.aliasHistory == nil { return nil }
+    return s.aliasHistory.DeleteByAlias(alias)
+}
+
+// retireAlias keeps oldAlias forwarding to link after a rename. Like revisions
+// this is best effort: a failed write is logged, not returned.
+func (s *LinkService) retireAlias(oldAlias string, link *models.Link, by Actor) {
+    if s.aliasHistory == nil { return }
+    entry := &models.AliasHistory{LinkID: link.ID, Alias: oldAlias, RetiredBy: by.UserID}
+    if s.aliasForwardTTL > 0 {
+        until := time.Now().Add(s.aliasForwardTTL)
+        entry.ExpiresAt = &until
+    }
+    if err := s.aliasHistory.Create(entry); err != nil {
+        log.Printf("[ALIASES] failed to retire %q for link %d: %v", oldAlias, link.ID, err)
+    }
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "quickr/models"
+    "quickr/repositories"
+)
+
+func TestAliasHistory_RenameKeepsOldAliasForwarding(t *testing.T) {
+    stored := &models.Link{ID: 7, Alias: "standup", URL: "https://meet.example.com/standup", CreatedBy: &aliceID}
+    repo := memLinkRepo(stored)
+    repo.FindByAliasFunc = func(alias string) (*models.Link, error) {
+        if alias == stored.Alias { cp := *stored; return &cp, nil }
+        return nil, errors.New("not found")
+    }
+    history := &fakeAliasHistoryRepo{}
+    svc := NewLinkService(repo, WithAliasHistory(history, 0))
+
+    if _, err := svc.UpdateLink("7", "daily", stored.URL, alice); err != nil { t.Fatalf("rename failed: %v", err) }
+    if len(history.entries) != 1 || history.entries[0].Alias != "standup" || *history.entries[0].RetiredBy != aliceID || history.entries[0].ExpiresAt != nil {
+        t.Fatalf("expected standup to be retired by alice forever, got %+v", history.entries)
+    }
+    link, target, err := svc.ResolveTarget("standup", nil, "")
+    if err != nil || link.Alias != "daily" || target != stored.URL { t.Fatalf("expected old alias to forward, got %+v %q err=%v", link, target, err) }
+
+    // renaming back releases the alias instead of forwarding it to itself
+    if _, err := svc.UpdateLink("7", "standup", stored.URL, alice); err != nil { t.Fatalf("rename back failed: %v", err) }
+    if len(history.entries) != 1 || history.entries[0].Alias != "daily" { t.Fatalf("expected only daily to be retired, got %+v", history.entries) }
+}
+
+func TestAliasHistory_ExpiresAfterTTL(t *testing.T) {
+    stored := &models.Link{ID: 7, Alias: "standup", URL: "https://example.com", CreatedBy: &aliceID}
+    repo := memLinkRepo(stored)
+    repo.FindByAliasFunc = func(alias string) (*models.Link, error) { return nil, errors.New("not found") }
+    history := &fakeAliasHistoryRepo{}
+    svc := NewLinkService(repo, WithAliasHistory(history, 24*time.Hour))
+
+    if _, err := svc.UpdateLink("7", "daily", stored.URL, alice); err != nil { t.Fatalf("rename failed: %v", err) }
+    if history.entries[0].ExpiresAt == nil { t.Fatal("expected forwarding to expire") }
+    past := time.Now().Add(-time.Minute)
+    history.entries[0].ExpiresAt = &past
+    if _, _, err := svc.ResolveTarget("standup", nil, ""); !errors.Is(err, ErrLinkNotFound) { t.Fatalf("expected expired forwarding to be gone, got %v", err) }
+}
+
+func TestAliasHistory_CreateOnRetiredAlias(t *testing.T) {
+    stored := &models.Link{ID: 7, Alias: "daily", URL: "https://example.com"}
+    history := &fakeAliasHistoryRepo{entries: []models.AliasHistory{{ID: 1, LinkID: 7, Alias: "standup"}}}
+    var created *models.Link
+    repo := &fakeRepo{
+        ExistsByAliasFunc: func(alias string) (bool, error) { return false, nil },
+        FindByIDFunc:      func(id string) (*models.Link, error) { cp := *stored; return &cp, nil },
+        CreateFunc:        func(link *models.Link) error { created = link; return nil },
+    }
+    svc := NewLinkService(repo, WithAliasHistory(history, 0))
+
+    if _, err := svc.CreateLink("standup", "https://other.example.com", alice); !errors.Is(err, ErrAliasRetired) || created != nil {
+        t.Fatalf("expected ErrAliasRetired without creating, got %v", err)
+    }
+    if link, err := svc.FindRetiredAlias("standup"); err != nil || link.Alias != "daily" { t.Fatalf("expected forwarding to daily, got %+v err=%v", link, err) }
+    if err := svc.ReleaseRetiredAlias("standup"); err != nil { t.Fatal(err) }
+    if _, err := svc.CreateLink("standup", "https://other.example.com", alice); err != nil || created == nil { t.Fatalf("expected create after release, got %v", err) }
+}
+
+// failingCreates lets a transaction run until the link insert, which fails.
+type failingCreates struct{ repositories.LinkTransactor }
+
+type failingCreateRepo struct{ repositories.LinkRepository }
+
+func (failingCreateRepo) Create(*models.Link) error { return errors.New("disk full") }
+
+func (f failingCreates) InLinkTx(fn func(repositories.LinkStores) error) error {
+    return f.LinkTransactor.InLinkTx(func(st repositories.LinkStores) error {
+        st.Links = failingCreateRepo{st.Links}
+        return fn(st)
+    })
+}
+
+func TestAliasHistory_TakeRetiredAliasOnlyWithTheCreate(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(repositories.SQLiteDSN(filepath.Join(t.TempDir(), "links.db"))), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}, &models.AliasHistory{}); err != nil { t.Fatalf("migrate: %v", err) }
+    newService := func(tx repositories.LinkTransactor) *LinkService {
+        return NewLinkService(repositories.NewGormLinkRepository(db),
+            WithAliasHistory(repositories.NewGormAliasHistoryRepository(db), 0),
+            WithTransactions(tx))
+    }
+    svc := newService(repositories.NewGormLinkTransactor(db))
+    daily, err := svc.CreateLink("standup", "https://meet.example.com", alice)
+    if err != nil { t.Fatalf("create: %v", err) }
+    if _, err := svc.UpdateLink("1", "daily", "", alice); err != nil { t.Fatalf("rename: %v", err) }
+
+    take := LinkOptions{TakeRetiredAlias: true}
+    if _, err := newService(failingCreates{repositories.NewGormLinkTransactor(db)}).CreateLinkWithOptions("standup", "https://other.example.com", alice, take); err == nil { t.Fatalf("expected the failing insert to fail the create") }
+    if link, err := svc.FindRetiredAlias("standup"); err != nil || link.ID != daily.ID { t.Fatalf("expected standup to keep forwarding after the failed create, got %+v %v", link, err) }
+
+    link, err := svc.CreateLinkWithOptions("standup", "https://other.example.com", alice, take)
+    if err != nil || link.Alias != "standup" { t.Fatalf("expected the create to take standup over, got %+v %v", link, err) }
+    if _, err := svc.FindRetiredAlias("standup"); err == nil { t.Fatalf("expected standup to stop forwarding once taken") }
+}
+
+// failingReleases lets a transaction run until a retired alias is released, which fails.
+type failingReleases struct{ repositories.LinkTransactor }
+
+type failingReleaseRepo struct{ repositories.AliasHistoryRepository }
+
+func (failingReleaseRepo) DeleteByAlias(string) error { return errors.New("disk full") }
+
+func (f failingReleases) InLinkTx(fn func(repositories.LinkStores) error) error {
+    return f.LinkTransactor.InLinkTx(func(st repositories.LinkStores) error {
+        st.AliasHistory = failingReleaseRepo{st.AliasHistory}
+        return fn(st)
+    })
+}
+
+func TestAliasHistory_RenameOntoRetiredAliasRollsBackIfNotReleased(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(repositories.SQLiteDSN(filepath.Join(t.TempDir(), "links.db"))), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}, &models.AliasHistory{}); err != nil { t.Fatalf("migrate: %v", err) }
+    newService := func(tx repositories.LinkTransactor) *LinkService {
+        return NewLinkService(repositories.NewGormLinkRepository(db),
+            WithAliasHistory(repositories.NewGormAliasHistoryRepository(db), 0),
+            WithTransactions(tx))
+    }
+    svc := newService(repositories.NewGormLinkTransactor(db))
+    daily, _ := svc.CreateLink("standup", "https://meet.example.com", alice)
+    if _, err := svc.UpdateLink(fmt.Sprint(daily.ID), "daily", "", alice); err != nil { t.Fatalf("rename: %v", err) }
+    other, _ := svc.CreateLink("other", "https://other.example.com", alice)
+
+    if _, err := newService(failingReleases{repositories.NewGormLinkTransactor(db)}).UpdateLink(fmt.Sprint(other.ID), "standup", "", alice); err == nil {
+        t.Fatalf("expected the rename to fail when standup cannot be released")
+    }
+    if link, _ := svc.GetLinkByID(fmt.Sprint(other.ID)); link.Alias != "other" { t.Fatalf("expected the rename rolled back, got %q", link.Alias) }
+    if link, err := svc.FindRetiredAlias("standup"); err != nil || link.ID != daily.ID { t.Fatalf("expected standup to keep forwarding to daily, got %+v %v", link, err) }
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "log"
+    "strings"
+    "time"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+var (
+    ErrInvalidToken      = errors.New("invalid or expired token")
+    ErrTokenNotFound     = errors.New("token not found")
+    ErrTokenNameRequired = errors.New("token name is required")
+    ErrInvalidScope      = errors.New("scopes must be read, write or both")
+    ErrInvalidExpiry     = errors.New("expiry must be in the future")
+)
+
+// Token scopes: read covers GET requests, write everything else.
+const (
+    ScopeRead  = "read"
+    ScopeWrite = "write"
+)
+
+const (
+    // apiTokenPrefix marks quickr tokens so they are recognisable in scripts and secret scanners
+    apiTokenPrefix = "qk_"
+    // tokenTouchInterval limits LastUsedAt writes to one a minute per token
+    tokenTouchInterval = time.Minute
+)
+
+type TokenService struct {
+    tokens repositories.APITokenRepository
+    now    func() time.Time
+}
+
+func NewTokenService(tokens repositories.APITokenRepository) *TokenService {
+    return &TokenService{tokens: tokens, now: time.Now}
+}
+
+// CreateToken issues a token for userID. The plain token is returned only
+// here; the database keeps its hash.
+func (s *TokenService) CreateToken(userID uint, name string, scopes []string, expiresAt *time.Time) (string, *models.APIToken, error) {
+    name = strings.TrimSpace(name)
+    if name == "" { return "", nil, ErrTokenNameRequired }
+    scopeList, err := normalizeScopes(scopes)
+    if err != nil { return "", nil, err }
+    if expiresAt != nil && !expiresAt.After(s.now()) { return "", nil, ErrInvalidExpiry }
+    secret := make([]byte, 32)
+    if _, err := rand.Read(secret); err != nil { return "", nil, err }
+    plain := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+    token := &models.APIToken{UserID: userID, Name: name, TokenHash: hashToken(plain), Prefix: plain[:len(apiTokenPrefix)+4], Scopes: scopeList, ExpiresAt: expiresAt}
+    if err := s.tokens.Create(token); err != nil { return "", nil, err }
+    return plain, token, nil
+}
+
+func (s *TokenService) ListTokens(userID uint) ([]models.APIToken, error) { return s.tokens.ListByUser(userID) }
+
+// RevokeToken deletes one of userID's tokens.
+func (s *TokenService) RevokeToken(userID, id uint) error {
+    if err := s.tokens.Delete(userID, id); err != nil {
+        if errors.Is(err, repositories.ErrNotFound) { return ErrTokenNotFound }
+        return err
+    }
+    return nil
+}
+
+// Authenticate resolves a plain token to its record, owner preloaded. Expired
+// tokens and tokens of disabled users are rejected.
+func (s *TokenService) Authenticate(plain string) (*models.APIToken, error) {
+    if !strings.HasPrefix(plain, apiTokenPrefix) { return nil, ErrInvalidToken }
+    token, err := s.tokens.FindByHash(hashToken(plain))
+    if err != nil { return nil, ErrInvalidToken }
+    now := s.now()
+    if token.ExpiresAt != nil && !token.ExpiresAt.After(now) { return nil, ErrInvalidToken }
+    if token.User.Disabled { return nil, ErrInvalidToken }
+    if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= tokenTouchInterval {
+        if err := s.tokens.TouchLastUsed(token.ID, now); err != nil {
+            log.Printf("[TOKENS] failed to record use of token %d: %v", token.ID, err)
+        } else {
+            token.LastUsedAt = &now
+        }
+    }
+    return token, nil
+}
+
+// HasScope reports whether token grants scope.
+func HasScope(token *models.APIToken, scope string) bool {
+    for _, s := range strings.Split(token.Scopes, ",") {
+        if s == scope { return true }
+    }
+    return false
+}
+
+// normalizeScopes validates scopes and joins them in a fixed order.
+func normalizeScopes(scopes []string) (string, error) {
+    var read, write bool
+    for _, s := range scopes {
+        switch strings.TrimSpace(strings.ToLower(s)) {
+        case ScopeRead:
+            read = true
+        case ScopeWrite:
+            write = true
+        default:
+            return "", ErrInvalidScope
+        }
+    }
+    switch {
+    case read && write:
+        return ScopeRead + "," + ScopeWrite, nil
+    case read:
+        return ScopeRead, nil
+    case write:
+        return ScopeWrite, nil
+    }
+    return "", ErrInvalidScope
+}
+
+func hashToken(plain string) string {
+    sum := sha256.Sum256([]byte(plain))
+    return hex.EncodeToString(sum[:])
+}
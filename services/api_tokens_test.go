@@ -0,0 +1,130 @@
+package services
+
+import (
+    "errors"
+    "strings"
+    "testing"
+    "time"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+// fakeAPITokenRepo keeps tokens in memory; users maps owner IDs to their accounts.
+type fakeAPITokenRepo struct {
+    tokens  []models.APIToken
+    users   map[uint]models.User
+    touches int
+}
+
+func (f *fakeAPITokenRepo) Create(token *models.APIToken) error {
+    token.ID = uint(len(f.tokens) + 1)
+    f.tokens = append(f.tokens, *token)
+    return nil
+}
+
+func (f *fakeAPITokenRepo) ListByUser(userID uint) ([]models.APIToken, error) {
+    out := []models.APIToken{}
+    for _, t := range f.tokens {
+        if t.UserID == userID { out = append(out, t) }
+    }
+    return out, nil
+}
+
+func (f *fakeAPITokenRepo) FindByHash(hash string) (*models.APIToken, error) {
+    for _, t := range f.tokens {
+        if t.TokenHash == hash {
+            t.User = f.users[t.UserID]
+            return &t, nil
+        }
+    }
+    return nil, repositories.ErrNotFound
+}
+
+func (f *fakeAPITokenRepo) Delete(userID, id uint) error {
+    for i, t := range f.tokens {
+        if t.ID == id && t.UserID == userID {
+            f.tokens = append(f.tokens[:i], f.tokens[i+1:]...)
+            return nil
+        }
+    }
+    return repositories.ErrNotFound
+}
+
+func (f *fakeAPITokenRepo) TouchLastUsed(id uint, at time.Time) error {
+    f.touches++
+    for i := range f.tokens {
+        if f.tokens[i].ID == id { f.tokens[i].LastUsedAt = &at }
+    }
+    return nil
+}
+
+func TestTokenService_CreateToken(t *testing.T) {
+    repo := &fakeAPITokenRepo{}
+    svc := NewTokenService(repo)
+
+    plain, token, err := svc.CreateToken(1, " laptop ", []string{"write", "read", "read"}, nil)
+    if err != nil { t.Fatalf("unexpected err: %v", err) }
+    if !strings.HasPrefix(plain, "qk_") || len(plain) < 40 || !strings.HasPrefix(plain, token.Prefix) {
+        t.Fatalf("unexpected token %q with prefix %q", plain, token.Prefix)
+    }
+    stored := repo.tokens[0]
+    if stored.TokenHash == plain || strings.Contains(stored.TokenHash, plain[3:]) || stored.Name != "laptop" || stored.Scopes != "read,write" {
+        t.Fatalf("expected only a hash and normalised fields to be stored, got %+v", stored)
+    }
+    if other, _, _ := svc.CreateToken(1, "other", []string{"read"}, nil); other == plain { t.Fatal("expected distinct tokens") }
+
+    past := time.Now().Add(-time.Hour)
+    cases := []struct {
+        name   string
+        scopes []string
+        expiry *time.Time
+        want   error
+    }{
+        {"", []string{"read"}, nil, ErrTokenNameRequired},
+        {"ci", nil, nil, ErrInvalidScope},
+        {"ci", []string{"admin"}, nil, ErrInvalidScope},
+        {"ci", []string{"read"}, &past, ErrInvalidExpiry},
+    }
+    for _, tc := range cases {
+        if _, _, err := svc.CreateToken(1, tc.name, tc.scopes, tc.expiry); !errors.Is(err, tc.want) {
+            t.Errorf("CreateToken(%q, %v): expected %v, got %v", tc.name, tc.scopes, tc.want, err)
+        }
+    }
+}
+
+func TestTokenService_Authenticate(t *testing.T) {
+    repo := &fakeAPITokenRepo{users: map[uint]models.User{1: {ID: 1, Email: "alice@example.com"}, 2: {ID: 2, Email: "mallory@example.com", Disabled: true}}}
+    svc := NewTokenService(repo)
+    now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+    svc.now = func() time.Time { return now }
+
+    soon := now.Add(time.Hour)
+    plain, _, _ := svc.CreateToken(1, "ci", []string{"read"}, &soon)
+    disabled, _, _ := svc.CreateToken(2, "ci", []string{"read"}, nil)
+
+    token, err := svc.Authenticate(plain)
+    if err != nil || token.User.Email != "alice@example.com" || token.LastUsedAt == nil || !token.LastUsedAt.Equal(now) {
+        t.Fatalf("expected alice's token with its use recorded, got %+v err=%v", token, err)
+    }
+    now = now.Add(30 * time.Second)
+    svc.Authenticate(plain)
+    if repo.touches != 1 { t.Fatalf("expected last use to be written at most once a minute, got %d writes", repo.touches) }
+
+    for name, candidate := range map[string]string{"unknown": "qk_nope", "unprefixed": plain[3:], "disabled user": disabled} {
+        if _, err := svc.Authenticate(candidate); !errors.Is(err, ErrInvalidToken) { t.Errorf("%s: expected ErrInvalidToken, got %v", name, err) }
+    }
+    now = soon
+    if _, err := svc.Authenticate(plain); !errors.Is(err, ErrInvalidToken) { t.Fatalf("expected an expired token to be rejected, got %v", err) }
+}
+
+func TestTokenService_RevokeToken(t *testing.T) {
+    repo := &fakeAPITokenRepo{users: map[uint]models.User{1: {ID: 1}}}
+    svc := NewTokenService(repo)
+    plain, token, _ := svc.CreateToken(1, "ci", []string{"read", "write"}, nil)
+    if !HasScope(token, ScopeRead) || !HasScope(token, ScopeWrite) { t.Fatalf("expected both scopes, got %q", token.Scopes) }
+
+    if err := svc.RevokeToken(2, token.ID); !errors.Is(err, ErrTokenNotFound) { t.Fatalf("expected another user's revoke to fail, got %v", err) }
+    if err := svc.RevokeToken(1, token.ID); err != nil { t.Fatalf("unexpected err: %v", err) }
+    if _, err := svc.Authenticate(plain); !errors.Is(err, ErrInvalidToken) { t.Fatalf("expected a revoked token to be rejected, got %v", err) }
+}
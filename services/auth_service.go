@@ -12,6 +12,11 @@ import (
     "quickr/repositories"
 )
 
+var (
+    ErrAccountRevoked = errors.New("account revoked")
+    ErrSessionRevoked = errors.New("session expired; sign in again")
+)
+
 type Mailer interface { SendMagicLink(email, link string) error }
 
 type AuthService struct {
@@ -29,17 +34,6 @@ func NewAuthService(users repositories.UserRepository, invites repositories.Invi
     return &AuthService{users: users, invites: invites, mailer: mailer, appBaseURL: appBaseURL, buildURL: buildLink}
 }
 
-func (a *AuthService) EnsureAdmin(email string) error {
-    e := strings.TrimSpace(strings.ToLower(email))
-    u, err := a.users.FindByEmail(e)
-    if err != nil {
-        u = &models.User{Email: e, Role: "admin"}
-    }
-    u.Role = "admin"
-    u.LastLogin = time.Now()
-    return a.users.Save(u)
-}
-
 func (a *AuthService) generateToken(n int) (string, error) {
     b := make([]byte, n)
     if _, err := rand.Read(b); err != nil { return "", err }
@@ -61,31 +55,83 @@ func (a *AuthService) CreateMagicLinkInvite(email, resolvedBaseURL string) (stri
     return token, nil
 }
 
-// RequireAndSendMagicLink sends a link only if a previous invite exists
+// RequireAndSendMagicLink sends a link only if a previous invite exists or
+// the address belongs to an enabled account, which may never have been
+// invited (the first admin) or had its invitations revoked and been re-enabled
 func (a *AuthService) RequireAndSendMagicLink(email, resolvedBaseURL string) error {
-    if _, err := a.invites.FindLatestActiveByEmail(email); err != nil { return errors.New("email not invited") }
+    if _, err := a.invites.FindLatestActiveByEmail(email); err != nil && !a.isEnabledUser(email) { return errors.New("email not invited") }
     _, err := a.CreateMagicLinkInvite(email, resolvedBaseURL)
     return err
 }
 
+func (a *AuthService) isEnabledUser(email string) bool {
+    u, err := a.users.FindByEmail(email)
+    return err == nil && !u.Disabled
+}
+
+// SeedsFirstAdmin reports whether signing in as email makes the first admin:
+// email is adminEmail (ADMIN_EMAIL) and no admin exists yet. Once one does,
+// admins are promoted from the dashboard and adminEmail has no special rights.
+func (a *AuthService) SeedsFirstAdmin(email, adminEmail string) bool {
+    e := strings.TrimSpace(strings.ToLower(adminEmail))
+    if e == "" || !strings.EqualFold(strings.TrimSpace(email), e) { return false }
+    exists, err := a.users.HasAdmin()
+    return err == nil && !exists
+}
+
+// SendAdminMagicLink mails a magic link to the address that seeds the first
+// admin. Unlike RequireAndSendMagicLink it needs no previous invite: the admin
+// is invited by configuration, but still has to prove the mailbox is theirs.
+func (a *AuthService) SendAdminMagicLink(adminEmail, resolvedBaseURL string) error {
+    _, err := a.CreateMagicLinkInvite(adminEmail, resolvedBaseURL)
+    return err
+}
+
+// AdminSetupLink returns a one-time sign-in link for adminEmail while no admin
+// account exists, so the first admin can sign in before mail is set up. The
+// link is not mailed, replaces any earlier setup link and expires after a day.
+// Once an admin exists it returns "".
+func (a *AuthService) AdminSetupLink(adminEmail, resolvedBaseURL string) (string, error) {
+    e := strings.TrimSpace(strings.ToLower(adminEmail))
+    if e == "" { return "", nil }
+    if exists, err := a.users.HasAdmin(); err != nil || exists { return "", err }
+    if err := a.invites.RevokePendingAndSent(e); err != nil { return "", err }
+    token, err := a.generateToken(32)
+    if err != nil { return "", err }
+    inv := &models.Invitation{Email: e, Token: token, Status: "pending", ExpiresAt: time.Now().Add(24 * time.Hour)}
+    if err := a.invites.Create(inv); err != nil { return "", err }
+    return a.buildURL(resolvedBaseURL, token), nil
+}
+
 // RedeemMagicToken validates an invitation token, upserts user, marks invite used
-func (a *AuthService) RedeemMagicToken(token string, assignAdmin func(email string) bool) (email string, role string, err error) {
+func (a *AuthService) RedeemMagicToken(token string, assignAdmin func(email string) bool) (*models.User, error) {
     inv, err := a.invites.FindByToken(token)
-    if err != nil { return "", "", errors.New("invalid token") }
+    if err != nil { return nil, errors.New("invalid token") }
     if inv.Status == "used" || inv.Status == "revoked" || inv.ExpiresAt.Before(time.Now()) {
-        return "", "", errors.New("token expired or used")
+        return nil, errors.New("token expired or used")
     }
     // Lookup or create user
     u, err := a.users.FindByEmail(inv.Email)
     if err != nil { u = &models.User{Email: inv.Email, Role: "user"} }
+    if u.Disabled { return nil, ErrAccountRevoked }
     if assignAdmin != nil && assignAdmin(u.Email) { u.Role = "admin" }
     u.LastLogin = time.Now()
-    if err := a.users.Save(u); err != nil { return "", "", err }
+    if err := a.users.Save(u); err != nil { return nil, err }
     now := time.Now()
     inv.Status = "used"
     inv.UsedAt = &now
     _ = a.invites.Save(inv)
-    return u.Email, u.Role, nil
+    return u, nil
+}
+
+// SessionUser loads the account behind a session cookie. Sessions end when
+// the user is deleted or disabled, or when an admin signs them out
+// everywhere, which moves the account past the cookie's generation.
+func (a *AuthService) SessionUser(email string, generation uint) (*models.User, error) {
+    u, err := a.GetUserByEmail(email)
+    if err != nil || u.SessionGeneration != generation { return nil, ErrSessionRevoked }
+    if u.Disabled { return nil, ErrAccountRevoked }
+    return u, nil
 }
 
 func (a *AuthService) GetUserByEmail(email string) (*models.User, error) {
@@ -130,34 +176,28 @@ func (a *AuthService) RevokeInvitationByID(id string) (*models.Invitation, error
 }
 func (a *AuthService) RevokeAllForEmail(email string) error { return a.invites.RevokeAllByEmail(strings.ToLower(strings.TrimSpace(email))) }
 
-func (a *AuthService) DisableUser(email string) error {
-    e := strings.ToLower(strings.TrimSpace(email))
-    u, err := a.users.FindByEmail(e)
-    if err != nil {
-        // create disabled user if not exists
-        u = &models.User{Email: e, Role: "user", Disabled: true}
-        return a.users.Create(u)
-    }
-    if u.Disabled {
-        return errors.New("user already revoked")
-    }
-    u.Disabled = true
-    return a.users.Save(u)
+// InviteState is an invitation with the state of the account registered
+// under its email; UserID is 0 when there is none.
+type InviteState struct {
+    Inv          models.Invitation
+    UserID       uint
+    UserDisabled bool
 }
 
-// AnnotateInvites joins invitations with user disabled state for UI rendering
-func (a *AuthService) AnnotateInvitesWithUserDisabled(invites []models.Invitation) ([]struct{ Inv models.Invitation; UserDisabled bool }, error) {
+// AnnotateInvites joins invitations with the account state for UI rendering
+func (a *AuthService) AnnotateInvites(invites []models.Invitation) ([]InviteState, error) {
     emailSet := map[string]struct{}{}
     for _, inv := range invites { emailSet[inv.Email] = struct{}{} }
     emails := make([]string, 0, len(emailSet))
     for e := range emailSet { emails = append(emails, e) }
     users, err := a.users.ListByEmails(emails)
     if err != nil { return nil, err }
-    disabled := map[string]bool{}
-    for _, u := range users { disabled[u.Email] = u.Disabled }
-    out := make([]struct{ Inv models.Invitation; UserDisabled bool }, 0, len(invites))
-    for _, inv := range invites { out = append(out, struct{ Inv models.Invitation; UserDisabled bool }{Inv: inv, UserDisabled: disabled[inv.Email]}) }
+    byEmail := map[string]models.User{}
+    for _, u := range users { byEmail[u.Email] = u }
+    out := make([]InviteState, 0, len(invites))
+    for _, inv := range invites {
+        u := byEmail[inv.Email]
+        out = append(out, InviteState{Inv: inv, UserID: u.ID, UserDisabled: u.Disabled})
+    }
     return out, nil
 }
-
-
@@ -0,0 +1,170 @@
+package services
+
+import (
+    "context"
+    "log"
+    "sync"
+    "time"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+// ClickBuffer takes click counting and the click log off the redirect path.
+// Clicks are gathered in memory and written in one transaction every flush
+// interval, or sooner once batchSize events are waiting.
+//
+// Counter increments are folded per link, so they never pile up; click log
+// events are capped at maxPending and dropped (and counted) beyond that, which
+// only happens when the database falls far behind.
+type ClickBuffer struct {
+    store      repositories.ClickBatchRepository
+    interval   time.Duration
+    batchSize  int
+    maxPending int
+    full       chan struct{}
+
+    mu      sync.Mutex
+    counts  map[uint]int64
+    events  []models.ClickEvent
+    metrics ClickBufferMetrics
+
+    // flushMu keeps flushes sequential so requeued clicks keep their order
+    flushMu sync.Mutex
+}
+
+// ClickBufferMetrics tells whether the buffer keeps up with the redirects.
+type ClickBufferMetrics struct {
+    PendingClicks     int64         `json:"pending_clicks"`
+    PendingEvents     int           `json:"pending_events"`
+    MaxPendingEvents  int           `json:"max_pending_events"`
+    RecordedClicks    int64         `json:"recorded_clicks"`
+    FlushedClicks     int64         `json:"flushed_clicks"`
+    FlushedEvents     int64         `json:"flushed_events"`
+    DroppedEvents     int64         `json:"dropped_events"`
+    Flushes           int64         `json:"flushes"`
+    FlushErrors       int64         `json:"flush_errors"`
+    LastFlushAt       time.Time     `json:"last_flush_at"`
+    LastFlushDuration time.Duration `json:"last_flush_duration_ns"`
+}
+
+// NewClickBuffer flushes into store every interval or once batchSize events
+// are waiting, holding at most ten batches of events.
+func NewClickBuffer(store repositories.ClickBatchRepository, interval time.Duration, batchSize int) *ClickBuffer {
+    if batchSize <= 0 { batchSize = 1 }
+    return &ClickBuffer{
+        store:      store,
+        interval:   interval,
+        batchSize:  batchSize,
+        maxPending: 10 * batchSize,
+        full:       make(chan struct{}, 1),
+        counts:     map[uint]int64{},
+    }
+}
+
+// WithBufferedClicks makes IncrementClicks queue the increment in buf.
+func WithBufferedClicks(buf *ClickBuffer) LinkServiceOption {
+    return func(s *LinkService) { s.clickBuffer = buf }
+}
+
+// WithBufferedClickEvents makes RecordClick queue click log events in buf.
+func WithBufferedClickEvents(buf *ClickBuffer) StatsServiceOption {
+    return func(s *StatsService) { s.clickBuffer = buf }
+}
+
+// AddClick queues one click on linkID. It never blocks on the database.
+func (b *ClickBuffer) AddClick(linkID uint) {
+    b.mu.Lock()
+    b.counts[linkID]++
+    b.metrics.RecordedClicks++
+    b.mu.Unlock()
+}
+
+// AddEvent queues a click log event, dropping it when the buffer is full.
+func (b *ClickBuffer) AddEvent(event models.ClickEvent) {
+    b.mu.Lock()
+    if len(b.events) >= b.maxPending {
+        b.metrics.DroppedEvents++
+        b.mu.Unlock()
+        return
+    }
+    b.events = append(b.events, event)
+    reached := len(b.events) >= b.batchSize
+    b.mu.Unlock()
+    if reached {
+        select {
+        case b.full <- struct{}{}:
+        default:
+        }
+    }
+}
+
+// Flush writes everything queued so far. On failure the clicks go back into
+// the buffer for the next attempt.
+func (b *ClickBuffer) Flush() error {
+    b.flushMu.Lock()
+    defer b.flushMu.Unlock()
+
+    b.mu.Lock()
+    counts, events := b.counts, b.events
+    b.counts, b.events = map[uint]int64{}, nil
+    b.mu.Unlock()
+    if len(counts) == 0 && len(events) == 0 { return nil }
+
+    started := time.Now()
+    err := b.store.ApplyClicks(counts, events)
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.metrics.Flushes++
+    b.metrics.LastFlushAt, b.metrics.LastFlushDuration = started, time.Since(started)
+    if err != nil {
+        b.metrics.FlushErrors++
+        b.requeue(counts, events)
+        return err
+    }
+    for _, n := range counts { b.metrics.FlushedClicks += n }
+    b.metrics.FlushedEvents += int64(len(events))
+    return nil
+}
+
+// requeue puts a failed batch back in front of what arrived meanwhile,
+// keeping the newest events when that overflows the buffer. Callers hold b.mu.
+func (b *ClickBuffer) requeue(counts map[uint]int64, events []models.ClickEvent) {
+    for id, n := range counts { b.counts[id] += n }
+    merged := append(events, b.events...)
+    if over := len(merged) - b.maxPending; over > 0 {
+        b.metrics.DroppedEvents += int64(over)
+        merged = merged[over:]
+    }
+    b.events = merged
+}
+
+// Metrics returns a snapshot of the buffer's counters.
+func (b *ClickBuffer) Metrics() ClickBufferMetrics {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    m := b.metrics
+    for _, n := range b.counts { m.PendingClicks += n }
+    m.PendingEvents, m.MaxPendingEvents = len(b.events), b.maxPending
+    return m
+}
+
+// Run flushes on every tick and whenever a batch fills up. Once ctx is
+// cancelled it flushes one last time and returns.
+func (b *ClickBuffer) Run(ctx context.Context) {
+    ticker := time.NewTicker(b.interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            if err := b.Flush(); err != nil {
+                log.Printf("[CLICKS] final flush failed, %d click(s) lost: %v", b.Metrics().PendingClicks, err)
+            }
+            return
+        case <-ticker.C:
+        case <-b.full:
+        }
+        if err := b.Flush(); err != nil { log.Printf("[CLICKS] flush failed: %v", err) }
+    }
+}
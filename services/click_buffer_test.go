@@ -0,0 +1,141 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "quickr/models"
+)
+
+// fakeClickBatchRepo totals what it is asked to write; Err fails every write.
+type fakeClickBatchRepo struct {
+    mu      sync.Mutex
+    counts  map[uint]int64
+    events  []models.ClickEvent
+    batches int
+    Err     error
+}
+
+func (f *fakeClickBatchRepo) ApplyClicks(counts map[uint]int64, events []models.ClickEvent) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if f.Err != nil { return f.Err }
+    if f.counts == nil { f.counts = map[uint]int64{} }
+    for id, n := range counts { f.counts[id] += n }
+    f.events = append(f.events, events...)
+    f.batches++
+    return nil
+}
+
+func (f *fakeClickBatchRepo) total() (int64, int) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    var n int64
+    for _, c := range f.counts { n += c }
+    return n, len(f.events)
+}
+
+func TestClickBuffer_FlushFoldsClicksPerLink(t *testing.T) {
+    batch := &fakeClickBatchRepo{}
+    buf := NewClickBuffer(batch, time.Hour, 100)
+    // fakeRepo panics on IncrementClicks: buffered clicks must not touch the link table
+    links := NewLinkService(&fakeRepo{}, WithBufferedClicks(buf))
+    stats := NewStatsService(links, WithClickEvents(&fakeClickEventRepo{}, "salt"), WithBufferedClickEvents(buf))
+    for i := 0; i < 3; i++ {
+        if err := links.IncrementClicks(4); err != nil { t.Fatalf("unexpected err: %v", err) }
+        if err := stats.RecordClick(4, "203.0.113.7", "curl/8.4.0", ""); err != nil { t.Fatalf("unexpected err: %v", err) }
+    }
+    links.IncrementClicks(5)
+    if m := buf.Metrics(); m.PendingClicks != 4 || m.PendingEvents != 3 || m.RecordedClicks != 4 {
+        t.Fatalf("unexpected pending metrics %+v", m)
+    }
+
+    if err := buf.Flush(); err != nil { t.Fatalf("unexpected err: %v", err) }
+    if batch.batches != 1 || batch.counts[4] != 3 || batch.counts[5] != 1 || len(batch.events) != 3 || batch.events[0].UAFamily != "curl" {
+        t.Fatalf("expected one batch with folded counts, got %+v", batch)
+    }
+    if m := buf.Metrics(); m.PendingClicks != 0 || m.PendingEvents != 0 || m.FlushedClicks != 4 || m.FlushedEvents != 3 || m.Flushes != 1 {
+        t.Fatalf("unexpected metrics after flush %+v", m)
+    }
+    if err := buf.Flush(); err != nil || batch.batches != 1 { t.Fatalf("expected an empty flush to skip the database, got %d batches err=%v", batch.batches, err) }
+}
+
+func TestClickBuffer_FailedFlushKeepsClicks(t *testing.T) {
+    batch := &fakeClickBatchRepo{Err: errors.New("database is locked")}
+    buf := NewClickBuffer(batch, time.Hour, 100)
+    buf.AddClick(4)
+    buf.AddEvent(models.ClickEvent{LinkID: 4})
+    if err := buf.Flush(); err == nil { t.Fatalf("expected the store error") }
+    buf.AddClick(4)
+
+    batch.Err = nil
+    if err := buf.Flush(); err != nil { t.Fatalf("unexpected err: %v", err) }
+    if batch.counts[4] != 2 || len(batch.events) != 1 { t.Fatalf("expected the failed batch to be retried, got %+v", batch) }
+    if m := buf.Metrics(); m.FlushErrors != 1 || m.Flushes != 2 || m.DroppedEvents != 0 { t.Fatalf("unexpected metrics %+v", m) }
+}
+
+func TestClickBuffer_DropsEventsWhenFull(t *testing.T) {
+    batch := &fakeClickBatchRepo{}
+    buf := NewClickBuffer(batch, time.Hour, 2)
+    for i := 0; i < 25; i++ {
+        buf.AddClick(4)
+        buf.AddEvent(models.ClickEvent{LinkID: 4})
+    }
+    m := buf.Metrics()
+    if m.PendingEvents != 20 || m.MaxPendingEvents != 20 || m.DroppedEvents != 5 || m.PendingClicks != 25 {
+        t.Fatalf("expected events capped at ten batches and every click kept, got %+v", m)
+    }
+}
+
+func TestClickBuffer_RunFlushesOnBatchSizeAndShutdown(t *testing.T) {
+    batch := &fakeClickBatchRepo{}
+    buf := NewClickBuffer(batch, time.Hour, 10)
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan struct{})
+    go func() { buf.Run(ctx); close(done) }()
+
+    for i := 0; i < 10; i++ { buf.AddEvent(models.ClickEvent{LinkID: 4}) }
+    deadline := time.Now().Add(2 * time.Second)
+    for _, events := batch.total(); events != 10; _, events = batch.total() {
+        if time.Now().After(deadline) { t.Fatalf("expected a full batch to flush before the interval, got %d events", events) }
+        time.Sleep(5 * time.Millisecond)
+    }
+
+    buf.AddClick(4)
+    buf.AddEvent(models.ClickEvent{LinkID: 4})
+    cancel()
+    <-done
+    if clicks, events := batch.total(); clicks != 1 || events != 11 { t.Fatalf("expected shutdown to flush the rest, got %d clicks %d events", clicks, events) }
+}
+
+func TestClickBuffer_ConcurrentClicks(t *testing.T) {
+    batch := &fakeClickBatchRepo{}
+    buf := NewClickBuffer(batch, time.Millisecond, 50)
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan struct{})
+    go func() { buf.Run(ctx); close(done) }()
+
+    var wg sync.WaitGroup
+    for g := 0; g < 20; g++ {
+        wg.Add(1)
+        go func(id uint) {
+            defer wg.Done()
+            for i := 0; i < 100; i++ {
+                buf.AddClick(id)
+                buf.AddEvent(models.ClickEvent{LinkID: id})
+                _ = buf.Metrics()
+            }
+        }(uint(g % 4))
+    }
+    wg.Wait()
+    cancel()
+    <-done
+    m := buf.Metrics()
+    clicks, events := batch.total()
+    if clicks != 2000 || int64(events)+m.DroppedEvents != 2000 || m.FlushedClicks != 2000 {
+        t.Fatalf("expected every click written, got %d clicks %d events %+v", clicks, events, m)
+    }
+}
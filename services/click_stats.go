@@ -0,0 +1,78 @@
+package services
+
+import (
+    "time"
+
+    "quickr/domain/clickmeta"
+    "quickr/models"
+    "quickr/repositories"
+)
+
+// WithClickEvents logs every redirect in clicks. Client IPs are hashed with
+// ipSalt before they are stored.
+func WithClickEvents(clicks repositories.ClickEventRepository, ipSalt string) StatsServiceOption {
+    return func(s *StatsService) {
+        s.clicks = clicks
+        s.ipSalt = ipSalt
+    }
+}
+
+// ClickPoint is the click count of one day or hour, starting at Time (UTC).
+type ClickPoint struct {
+    Time   time.Time
+    Clicks int64
+}
+
+// RecordClick logs one redirect through a link with pseudonymised client
+// details, queueing the event when clicks are buffered.
+func (s *StatsService) RecordClick(linkID uint, clientIP, userAgent, referrer string) error {
+    if s.clicks == nil { return nil }
+    event := models.ClickEvent{
+        LinkID:       linkID,
+        CreatedAt:    time.Now().UTC(),
+        IPHash:       clickmeta.HashIP(clientIP, s.ipSalt),
+        UAFamily:     clickmeta.UAFamily(userAgent),
+        ReferrerHost: clickmeta.ReferrerHost(referrer),
+    }
+    if s.clickBuffer != nil {
+        s.clickBuffer.AddEvent(event)
+        return nil
+    }
+    return s.clicks.Create(&event)
+}
+
+// ClicksByDay returns a link's clicks for each of the last days UTC days up to
+// and including now's, oldest first, with quiet days as zero.
+func (s *StatsService) ClicksByDay(linkID uint, days int, now time.Time) ([]ClickPoint, error) {
+    end := now.UTC().Truncate(24 * time.Hour)
+    return s.series(linkID, end, days, 24*time.Hour, "2006-01-02")
+}
+
+// ClicksByHour is ClicksByDay per hour for the last hours hours.
+func (s *StatsService) ClicksByHour(linkID uint, hours int, now time.Time) ([]ClickPoint, error) {
+    end := now.UTC().Truncate(time.Hour)
+    return s.series(linkID, end, hours, time.Hour, "2006-01-02 15:00")
+}
+
+// series fills n buckets of width step ending with the one starting at last.
+func (s *StatsService) series(linkID uint, last time.Time, n int, step time.Duration, layout string) ([]ClickPoint, error) {
+    if n <= 0 { return []ClickPoint{}, nil }
+    start := last.Add(-time.Duration(n-1) * step)
+    points := make([]ClickPoint, n)
+    for i := range points { points[i].Time = start.Add(time.Duration(i) * step) }
+    if s.clicks == nil { return points, nil }
+    var buckets []repositories.ClickBucket
+    var err error
+    if step == time.Hour {
+        buckets, err = s.clicks.CountByHour(linkID, start)
+    } else {
+        buckets, err = s.clicks.CountByDay(linkID, start)
+    }
+    if err != nil { return nil, err }
+    for _, b := range buckets {
+        t, err := time.Parse(layout, b.Bucket)
+        if err != nil { continue }
+        if i := int(t.Sub(start) / step); i >= 0 && i < n { points[i].Clicks += b.Clicks }
+    }
+    return points, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+    "testing"
+    "time"
+
+    "quickr/models"
+)
+
+func TestRecordClick(t *testing.T) {
+    clicks := &fakeClickEventRepo{}
+    ss := NewStatsService(nil, WithClickEvents(clicks, "salt"))
+    err := ss.RecordClick(4, "203.0.113.7", "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", "https://mail.example.com/inbox?id=42")
+    if err != nil || len(clicks.events) != 1 { t.Fatalf("expected one event, got %+v err=%v", clicks.events, err) }
+    e := clicks.events[0]
+    if e.LinkID != 4 || e.IPHash == "" || e.IPHash == "203.0.113.7" || e.UAFamily != "Firefox" || e.ReferrerHost != "mail.example.com" {
+        t.Fatalf("unexpected event %+v", e)
+    }
+    if e.CreatedAt.Location() != time.UTC { t.Fatalf("expected a UTC timestamp, got %v", e.CreatedAt) }
+    if err := NewStatsService(nil).RecordClick(4, "", "", ""); err != nil { t.Fatalf("expected no-op without a click log, got %v", err) }
+}
+
+func TestClicksByDayAndHour(t *testing.T) {
+    now := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+    clicks := &fakeClickEventRepo{}
+    ss := NewStatsService(nil, WithClickEvents(clicks, ""))
+    for _, ago := range []time.Duration{0, 10 * time.Minute, time.Hour, 26 * time.Hour, 3 * 24 * time.Hour} {
+        clicks.Create(&models.ClickEvent{LinkID: 4, CreatedAt: now.Add(-ago)})
+    }
+    clicks.Create(&models.ClickEvent{LinkID: 5, CreatedAt: now})
+
+    days, err := ss.ClicksByDay(4, 3, now)
+    if err != nil { t.Fatal(err) }
+    want := []int64{0, 1, 3}
+    if len(days) != 3 || !days[0].Time.Equal(time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)) { t.Fatalf("unexpected days %+v", days) }
+    for i, p := range days {
+        if p.Clicks != want[i] { t.Fatalf("day %d: expected %d clicks, got %d (%+v)", i, want[i], p.Clicks, days) }
+    }
+
+    hours, err := ss.ClicksByHour(4, 24, now)
+    if err != nil || len(hours) != 24 { t.Fatalf("expected 24 hours, got %d err=%v", len(hours), err) }
+    if hours[23].Clicks != 2 || hours[22].Clicks != 1 || !hours[23].Time.Equal(time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)) {
+        t.Fatalf("unexpected last hours %+v %+v", hours[22], hours[23])
+    }
+}
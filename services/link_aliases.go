@@ -0,0 +1,120 @@
+package services
+
+import (
+    "errors"
+    "strings"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+var ErrAliasNotFound = errors.New("alias not found")
+
+// WithLinkAliases lets a link answer on secondary aliases besides its primary one.
+func WithLinkAliases(aliases repositories.LinkAliasRepository) LinkServiceOption {
+    return func(s *LinkService) { s.linkAliases = aliases }
+}
+
+// ListAliases returns a link's secondary aliases, oldest first.
+func (s *LinkService) ListAliases(linkID string) ([]models.LinkAlias, error) {
+    link, err := s.repo.FindByID(linkID)
+    if err != nil { return nil, ErrLinkNotFound }
+    if s.linkAliases == nil { return []models.LinkAlias{}, nil }
+    return s.linkAliases.ListByLinkID(link.ID)
+}
+
+// AddAlias gives a link another alias. It passes the same reserved and
+// uniqueness checks as a primary alias; a retired alias of this same link is
+// taken back, one still forwarding to another link is refused.
+func (s *LinkService) AddAlias(linkID, alias string, actor Actor) ([]models.LinkAlias, error) {
+    alias = strings.TrimSpace(alias)
+    return withTx(s, func(tx *LinkService) ([]models.LinkAlias, error) { return tx.addAlias(linkID, alias, actor) })
+}
+
+func (s *LinkService) addAlias(linkID, alias string, actor Actor) ([]models.LinkAlias, error) {
+    link, err := s.repo.FindByID(linkID)
+    if err != nil { return nil, ErrLinkNotFound }
+    if err := s.authorize(link, actor); err != nil { return nil, err }
+    if s.linkAliases == nil { return nil, errors.New("secondary aliases are not enabled") }
+    if alias == "" { return nil, errors.New("alias is required") }
+    if s.IsAliasReserved(alias) { return nil, ErrAliasReserved }
+    if alias == link.Alias { return nil, ErrAliasExists }
+    if exists, err := s.repo.ExistsByAlias(alias); err != nil { return nil, err } else if exists { return nil, ErrAliasExists }
+    if retired, err := s.FindRetiredAlias(alias); err == nil {
+        if retired.ID != link.ID { return nil, ErrAliasRetired }
+        if err := s.ReleaseRetiredAlias(alias); err != nil { return nil, err }
+    }
+    before := *link
+    if err := s.linkAliases.Add(link.ID, alias); err != nil { return nil, err }
+    return s.aliasesChanged(&before, link, actor)
+}
+
+// RemoveAlias drops one of a link's secondary aliases. Unlike a rename the
+// removed alias stops redirecting straight away.
+func (s *LinkService) RemoveAlias(linkID, alias string, actor Actor) ([]models.LinkAlias, error) {
+    return withTx(s, func(tx *LinkService) ([]models.LinkAlias, error) { return tx.removeAlias(linkID, alias, actor) })
+}
+
+func (s *LinkService) removeAlias(linkID, alias string, actor Actor) ([]models.LinkAlias, error) {
+    link, err := s.repo.FindByID(linkID)
+    if err != nil { return nil, ErrLinkNotFound }
+    if err := s.authorize(link, actor); err != nil { return nil, err }
+    if !hasAlias(link.Aliases, alias) { return nil, ErrAliasNotFound }
+    before := *link
+    if err := s.linkAliases.Remove(link.ID, alias); err != nil { return nil, err }
+    return s.aliasesChanged(&before, link, actor)
+}
+
+// aliasesChanged stamps the editor on link, reloads its aliases and records
+// the change in the link's history.
+func (s *LinkService) aliasesChanged(before, link *models.Link, actor Actor) ([]models.LinkAlias, error) {
+    aliases, err := s.linkAliases.ListByLinkID(link.ID)
+    if err != nil { return nil, err }
+    link.Aliases = aliases
+    link.UpdatedBy, link.Updater, link.UpdatedByName = actor.UserID, nil, s.actorName(actor)
+    if err := s.repo.Save(link); err != nil { return nil, err }
+    s.forget(link.ID, aliasNames(aliases)...)
+    s.recordRevision(RevisionUpdate, before, link, actor)
+    return aliases, nil
+}
+
+// promoteAlias removes alias from link's secondary aliases once it became the primary one.
+func (s *LinkService) promoteAlias(link *models.Link, alias string) error {
+    if s.linkAliases == nil || !hasAlias(link.Aliases, alias) { return nil }
+    if err := s.linkAliases.Remove(link.ID, alias); err != nil { return err }
+    kept := []models.LinkAlias{}
+    for _, a := range link.Aliases {
+        if a.Alias != alias { kept = append(kept, a) }
+    }
+    link.Aliases = kept
+    return nil
+}
+
+func hasAlias(aliases []models.LinkAlias, alias string) bool {
+    for _, a := range aliases {
+        if a.Alias == alias { return true }
+    }
+    return false
+}
+
+func aliasNames(aliases []models.LinkAlias) []string {
+    names := make([]string, 0, len(aliases))
+    for _, a := range aliases { names = append(names, a.Alias) }
+    return names
+}
+
+// ReservedAliasesInUse lists live aliases, primary or secondary, that a page
+// added after they were created now reserves. The root path serves the page,
+// so these links only answer under /go/ until someone renames them.
+func (s *LinkService) ReservedAliasesInUse() ([]string, error) {
+    links, err := s.repo.ListAll()
+    if err != nil { return nil, err }
+    var taken []string
+    for _, l := range links {
+        if s.IsAliasReserved(l.Alias) { taken = append(taken, l.Alias) }
+        for _, a := range l.Aliases {
+            if s.IsAliasReserved(a.Alias) { taken = append(taken, a.Alias) }
+        }
+    }
+    return taken, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+    "errors"
+    "testing"
+
+    "quickr/models"
+)
+
+// aliasedLinkRepo is memLinkRepo whose lookups also see the link's secondary aliases.
+func aliasedLinkRepo(stored *models.Link, aliases *fakeLinkAliasRepo) *fakeRepo {
+    repo := memLinkRepo(stored)
+    repo.FindByIDFunc = func(id string) (*models.Link, error) {
+        cp := *stored
+        cp.Aliases, _ = aliases.ListByLinkID(cp.ID)
+        return &cp, nil
+    }
+    repo.ExistsByAliasFunc = func(alias string) (bool, error) {
+        return alias == "taken" || alias == stored.Alias || hasAlias(aliases.aliases, alias), nil
+    }
+    return repo
+}
+
+func TestAddAlias(t *testing.T) {
+    stored := &models.Link{ID: 7, Alias: "vpn", URL: "https://vpn.example.com", CreatedBy: &aliceID}
+    aliases := &fakeLinkAliasRepo{}
+    revs := &fakeRevisionRepo{}
+    svc := NewLinkService(aliasedLinkRepo(stored, aliases), WithLinkAliases(aliases), WithRevisions(revs))
+
+    got, err := svc.AddAlias("7", " wireguard ", alice)
+    if err != nil || len(got) != 1 || got[0].Alias != "wireguard" { t.Fatalf("unexpected aliases %+v err=%v", got, err) }
+    if len(revs.revs) != 1 || revs.revs[0].Action != RevisionUpdate { t.Fatalf("expected one update revision, got %+v", revs.revs) }
+    if rev := decodeRevision(&revs.revs[0]); len(rev.Changes) != 1 || rev.Changes[0].Field != "aliases" || rev.Changes[0].To != "wireguard" {
+        t.Fatalf("expected an aliases change, got %+v", rev.Changes)
+    }
+    if stored.UpdatedBy == nil || *stored.UpdatedBy != aliceID { t.Fatal("expected alice to be recorded as last editor") }
+
+    cases := map[string]error{"vpn": ErrAliasExists, "wireguard": ErrAliasExists, "taken": ErrAliasExists, "api": ErrAliasReserved}
+    for alias, want := range cases {
+        if _, err := svc.AddAlias("7", alias, alice); !errors.Is(err, want) { t.Errorf("AddAlias(%q): expected %v, got %v", alias, want, err) }
+    }
+    if _, err := svc.AddAlias("7", "vpn-setup", Actor{UserID: new(uint)}); !errors.Is(err, ErrForbidden) { t.Fatalf("expected ErrForbidden, got %v", err) }
+}
+
+func TestAddAlias_RetiredAlias(t *testing.T) {
+    stored := &models.Link{ID: 7, Alias: "vpn", CreatedBy: &aliceID}
+    aliases := &fakeLinkAliasRepo{}
+    history := &fakeAliasHistoryRepo{entries: []models.AliasHistory{{ID: 1, LinkID: 7, Alias: "old-vpn"}, {ID: 2, LinkID: 9, Alias: "other"}}}
+    repo := aliasedLinkRepo(stored, aliases)
+    repo.FindByIDFunc = func(id string) (*models.Link, error) {
+        if id == "9" { return &models.Link{ID: 9, Alias: "elsewhere"}, nil }
+        cp := *stored
+        cp.Aliases, _ = aliases.ListByLinkID(cp.ID)
+        return &cp, nil
+    }
+    svc := NewLinkService(repo, WithLinkAliases(aliases), WithAliasHistory(history, 0))
+
+    if _, err := svc.AddAlias("7", "other", alice); !errors.Is(err, ErrAliasRetired) { t.Fatalf("expected ErrAliasRetired, got %v", err) }
+    if _, err := svc.AddAlias("7", "old-vpn", alice); err != nil { t.Fatalf("expected the link's own retired alias to come back, got %v", err) }
+    if len(history.entries) != 1 || history.entries[0].Alias != "other" { t.Fatalf("expected old-vpn to stop forwarding, got %+v", history.entries) }
+}
+
+func TestRemoveAlias(t *testing.T) {
+    stored := &models.Link{ID: 7, Alias: "vpn", CreatedBy: &aliceID}
+    aliases := &fakeLinkAliasRepo{aliases: []models.LinkAlias{{ID: 1, LinkID: 7, Alias: "wireguard"}}}
+    svc := NewLinkService(aliasedLinkRepo(stored, aliases), WithLinkAliases(aliases))
+
+    if _, err := svc.RemoveAlias("7", "missing", alice); !errors.Is(err, ErrAliasNotFound) { t.Fatalf("expected ErrAliasNotFound, got %v", err) }
+    got, err := svc.RemoveAlias("7", "wireguard", alice)
+    if err != nil || len(got) != 0 { t.Fatalf("expected no aliases left, got %+v err=%v", got, err) }
+}
+
+func TestRename_PromotesSecondaryAlias(t *testing.T) {
+    stored := &models.Link{ID: 7, Alias: "vpn", URL: "https://vpn.example.com", CreatedBy: &aliceID}
+    aliases := &fakeLinkAliasRepo{aliases: []models.LinkAlias{{ID: 1, LinkID: 7, Alias: "wireguard"}, {ID: 2, LinkID: 7, Alias: "vpn-setup"}}}
+    svc := NewLinkService(aliasedLinkRepo(stored, aliases), WithLinkAliases(aliases))
+
+    link, err := svc.UpdateLink("7", "wireguard", "", alice)
+    if err != nil || link.Alias != "wireguard" { t.Fatalf("rename failed: %+v err=%v", link, err) }
+    if names := aliasNames(link.Aliases); len(names) != 1 || names[0] != "vpn-setup" { t.Fatalf("expected wireguard to leave the secondary aliases, got %v", names) }
+    if left, _ := aliases.ListByLinkID(7); len(left) != 1 { t.Fatalf("expected promoted alias to be removed, got %+v", left) }
+}
+
+func TestReservedAliasesInUse(t *testing.T) {
+    repo := &fakeRepo{ListAllFunc: func() ([]models.Link, error) {
+        return []models.Link{
+            {ID: 1, Alias: "trash", Aliases: []models.LinkAlias{{Alias: "bin"}}},
+            {ID: 2, Alias: "vpn", Aliases: []models.LinkAlias{{Alias: "Settings"}}},
+        }, nil
+    }}
+    got, err := NewLinkService(repo).ReservedAliasesInUse()
+    if err != nil || len(got) != 2 || got[0] != "trash" || got[1] != "Settings" { t.Fatalf("expected trash and Settings, got %v err=%v", got, err) }
+}
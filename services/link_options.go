@@ -0,0 +1,55 @@
+package services
+
+import (
+    "time"
+
+    "quickr/domain/passthrough"
+    "quickr/models"
+)
+
+// LinkOptions carries the optional settings of a create or edit. Nil fields
+// leave the link's current value untouched.
+type LinkOptions struct {
+    Passthrough *bool
+    QueryMerge  string // used with Passthrough; empty means passthrough.MergeKeepTarget
+    Schedule    *Schedule
+    // IfVersion makes an edit conditional on the version the editor loaded; 0 skips the check
+    IfVersion uint
+    // TakeRetiredAlias lets a create take over an alias that still forwards to
+    // a renamed link instead of failing with ErrAliasRetired
+    TakeRetiredAlias bool
+}
+
+// Schedule is a link's activation window; either end may be left open.
+type Schedule struct {
+    ActiveFrom *time.Time
+    ExpiresAt  *time.Time
+}
+
+func (s *LinkService) IsValidQueryMerge(merge string) bool { return merge == "" || passthrough.IsValidMerge(merge) }
+
+func (s *LinkService) IsValidSchedule(activeFrom, expiresAt *time.Time) bool {
+    return activeFrom == nil || expiresAt == nil || expiresAt.After(*activeFrom)
+}
+
+// ValidateOptions checks options without touching storage.
+func (s *LinkService) ValidateOptions(o LinkOptions) error {
+    if o.Passthrough != nil && !s.IsValidQueryMerge(o.QueryMerge) { return ErrInvalidMerge }
+    if o.Schedule != nil && !s.IsValidSchedule(o.Schedule.ActiveFrom, o.Schedule.ExpiresAt) { return ErrInvalidSchedule }
+    return nil
+}
+
+// applyOptions copies validated options onto link. Moving the expiry into the
+// future revives a link the sweeper had already stamped as expired.
+func applyOptions(link *models.Link, o LinkOptions) {
+    if o.Passthrough != nil {
+        link.Passthrough = *o.Passthrough
+        link.QueryMerge = o.QueryMerge
+        if link.QueryMerge == "" { link.QueryMerge = passthrough.MergeKeepTarget }
+    }
+    if o.Schedule != nil {
+        link.ActiveFrom = o.Schedule.ActiveFrom
+        link.ExpiresAt = o.Schedule.ExpiresAt
+        if link.ExpiresAt == nil || link.ExpiresAt.After(time.Now()) { link.ExpiredAt = nil }
+    }
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+    "errors"
+    "strings"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+var (
+    ErrForbidden    = errors.New("only the link's owner, its co-owners or an admin can change it")
+    ErrUserNotFound = errors.New("user not found")
+)
+
+// WithCoOwners lets link owners share edit rights with other users.
+func WithCoOwners(coOwners repositories.LinkCoOwnerRepository) LinkServiceOption {
+    return func(s *LinkService) { s.coOwners = coOwners }
+}
+
+// WithUsers resolves co-owner emails to user accounts.
+func WithUsers(users repositories.UserRepository) LinkServiceOption {
+    return func(s *LinkService) { s.users = users }
+}
+
+// CanEdit reports whether actor may edit or delete link: admins always can,
+// otherwise only the creator and the link's co-owners.
+func (s *LinkService) CanEdit(link *models.Link, actor Actor) (bool, error) {
+    if actor.Admin { return true, nil }
+    if actor.UserID == nil { return false, nil }
+    if link.CreatedBy != nil && *link.CreatedBy == *actor.UserID { return true, nil }
+    if s.coOwners == nil { return false, nil }
+    return s.coOwners.IsCoOwner(link.ID, *actor.UserID)
+}
+
+func (s *LinkService) authorize(link *models.Link, actor Actor) error {
+    ok, err := s.CanEdit(link, actor)
+    if err != nil { return err }
+    if !ok { return ErrForbidden }
+    return nil
+}
+
+// ListCoOwners returns the users sharing edit rights on a link.
+func (s *LinkService) ListCoOwners(linkID string) ([]models.User, error) {
+    link, err := s.repo.FindByID(linkID)
+    if err != nil { return nil, ErrLinkNotFound }
+    if s.coOwners == nil { return []models.User{}, nil }
+    return s.coOwners.ListUsers(link.ID)
+}
+
+// AddCoOwner shares a link with the user registered under email. Adding the
+// creator or an existing co-owner changes nothing.
+func (s *LinkService) AddCoOwner(linkID, email string, actor Actor) ([]models.User, error) {
+    link, err := s.repo.FindByID(linkID)
+    if err != nil { return nil, ErrLinkNotFound }
+    if err := s.authorize(link, actor); err != nil { return nil, err }
+    if s.coOwners == nil || s.users == nil { return nil, ErrUserNotFound }
+    user, err := s.users.FindByEmail(strings.ToLower(strings.TrimSpace(email)))
+    if err != nil { return nil, ErrUserNotFound }
+    if link.CreatedBy == nil || *link.CreatedBy != user.ID {
+        if err := s.coOwners.Add(link.ID, user.ID); err != nil { return nil, err }
+    }
+    return s.coOwners.ListUsers(link.ID)
+}
+
+// RemoveCoOwner revokes a co-owner's rights on a link.
+func (s *LinkService) RemoveCoOwner(linkID string, userID uint, actor Actor) ([]models.User, error) {
+    link, err := s.repo.FindByID(linkID)
+    if err != nil { return nil, ErrLinkNotFound }
+    if err := s.authorize(link, actor); err != nil { return nil, err }
+    if s.coOwners == nil { return []models.User{}, nil }
+    if err := s.coOwners.Remove(link.ID, userID); err != nil { return nil, err }
+    return s.coOwners.ListUsers(link.ID)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+    "errors"
+    "testing"
+
+    "quickr/models"
+)
+
+func TestLinkPermissions(t *testing.T) {
+    ownerID, coOwnerID, strangerID := uint(1), uint(2), uint(3)
+    owner := Actor{UserID: &ownerID, Name: "owner@example.com"}
+    coOwner := Actor{UserID: &coOwnerID, Name: "co@example.com"}
+    stranger := Actor{UserID: &strangerID, Name: "stranger@example.com"}
+    anonymous := Actor{Name: "nobody"}
+
+    newSvc := func() *LinkService {
+        repo := &fakeRepo{
+            FindByIDFunc:              func(id string) (*models.Link, error) { return &models.Link{ID: 9, Alias: "vpn", URL: "https://vpn.example.com", CreatedBy: &ownerID}, nil },
+            ExistsByAliasExceptIDFunc: func(alias, id string) (bool, error) { return false, nil },
+            SaveFunc:                  func(link *models.Link) error { return nil },
+            DeleteFunc:                func(link *models.Link) error { return nil },
+        }
+        coOwners := &fakeCoOwnerRepo{}
+        coOwners.Add(9, coOwnerID)
+        return NewLinkService(repo, WithCoOwners(coOwners), WithRevisions(&fakeRevisionRepo{}))
+    }
+
+    mutations := map[string]func(svc *LinkService, a Actor) error{
+        "update": func(svc *LinkService, a Actor) error { _, err := svc.UpdateLink("9", "", "https://new.example.com", a); return err },
+        "edit options": func(svc *LinkService, a Actor) error {
+            on := true
+            _, err := svc.EditLink("9", "", "", LinkOptions{Passthrough: &on}, a)
+            return err
+        },
+        "delete":          func(svc *LinkService, a Actor) error { _, err := svc.DeleteLink("9", a); return err },
+        "remove co-owner": func(svc *LinkService, a Actor) error { _, err := svc.RemoveCoOwner("9", 42, a); return err },
+    }
+    for name, mutate := range mutations {
+        for _, a := range []Actor{owner, coOwner, testAdmin} {
+            if err := mutate(newSvc(), a); err != nil { t.Errorf("%s by %s: unexpected error %v", name, a.Name, err) }
+        }
+        for _, a := range []Actor{stranger, anonymous} {
+            if err := mutate(newSvc(), a); !errors.Is(err, ErrForbidden) { t.Errorf("%s by %s: expected ErrForbidden, got %v", name, a.Name, err) }
+        }
+    }
+}
+
+func TestRestoreRevision_Forbidden(t *testing.T) {
+    ownerID, strangerID := uint(1), uint(3)
+    stored := &models.Link{}
+    revs := &fakeRevisionRepo{}
+    svc := NewLinkService(memLinkRepo(stored), WithRevisions(revs))
+    svc.CreateLink("oncall", "https://wiki.example.com/oncall", Actor{UserID: &ownerID, Name: "owner"})
+    svc.UpdateLink("7", "", "https://wrong.example.com", Actor{UserID: &ownerID, Name: "owner"})
+
+    if _, err := svc.RestoreRevision("7", "1", Actor{UserID: &strangerID, Name: "stranger"}); !errors.Is(err, ErrForbidden) {
+        t.Fatalf("expected ErrForbidden, got %v", err)
+    }
+    if stored.URL != "https://wrong.example.com" { t.Fatalf("forbidden restore changed the link: %q", stored.URL) }
+}
+
+func TestAddCoOwner(t *testing.T) {
+    ownerID := uint(1)
+    owner := Actor{UserID: &ownerID, Name: "owner@example.com"}
+    repo := &fakeRepo{ FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 9, CreatedBy: &ownerID}, nil } }
+    coOwners := &fakeCoOwnerRepo{}
+    users := &fakeUserRepo{users: []models.User{{ID: 1, Email: "owner@example.com"}, {ID: 2, Email: "co@example.com"}}}
+    svc := NewLinkService(repo, WithCoOwners(coOwners), WithUsers(users))
+
+    list, err := svc.AddCoOwner("9", "  CO@example.com ", owner)
+    if err != nil || len(list) != 1 || list[0].ID != 2 { t.Fatalf("unexpected co-owners %v err=%v", list, err) }
+
+    coID := uint(2)
+    if ok, _ := svc.CanEdit(&models.Link{ID: 9, CreatedBy: &ownerID}, Actor{UserID: &coID}); !ok {
+        t.Fatalf("expected co-owner to be able to edit")
+    }
+    if list, _ := svc.AddCoOwner("9", "owner@example.com", owner); len(list) != 1 {
+        t.Fatalf("adding the owner should not make them a co-owner: %v", list)
+    }
+    if _, err := svc.AddCoOwner("9", "ghost@example.com", owner); !errors.Is(err, ErrUserNotFound) {
+        t.Fatalf("expected ErrUserNotFound, got %v", err)
+    }
+    if _, err := svc.AddCoOwner("9", "co@example.com", Actor{UserID: new(uint)}); !errors.Is(err, ErrForbidden) {
+        t.Fatalf("expected ErrForbidden, got %v", err)
+    }
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "time"
+
+    "quickr/domain/linkstatus"
+    "quickr/models"
+    "quickr/repositories"
+)
+
+var (
+    ErrInvalidSort      = errors.New("sort must be alias, clicks, created or updated, and order asc or desc")
+    ErrInvalidCursor    = repositories.ErrInvalidCursor
+    ErrInvalidDateRange = errors.New("created_to must be after created_from")
+)
+
+const (
+    DefaultPageSize = 50
+    MaxPageSize     = 200
+)
+
+// LinkListOptions filters, sorts and pages the link listing. Zero fields do
+// not filter. A cursor is only valid with the sort and order it came from.
+type LinkListOptions struct {
+    Search      string
+    Creator     string
+    CreatedFrom *time.Time
+    CreatedTo   *time.Time
+    Status      string
+    // Sort is alias, clicks, created or updated; empty means created
+    Sort string
+    // Order is asc or desc; empty sorts aliases A to Z and everything else newest or highest first
+    Order  string
+    Cursor string
+    // Limit defaults to DefaultPageSize and is capped at MaxPageSize
+    Limit int
+}
+
+// LinkPage is one page of links. NextCursor fetches the next one and is
+// empty on the last page.
+type LinkPage struct {
+    Links      []models.Link
+    NextCursor string
+}
+
+// pageCursor is what an opaque cursor string decodes to.
+type pageCursor struct {
+    Sort  string `json:"s"`
+    Desc  bool   `json:"d"`
+    Value string `json:"v"`
+    ID    uint   `json:"id"`
+}
+
+// ListLinksPage returns one page of live links.
+func (s *LinkService) ListLinksPage(o LinkListOptions) (*LinkPage, error) {
+    if o.Status != "" && !linkstatus.IsValid(o.Status) { return nil, ErrInvalidStatus }
+    if o.Sort == "" { o.Sort = repositories.SortCreated }
+    if !repositories.IsValidSort(o.Sort) || (o.Order != "" && o.Order != "asc" && o.Order != "desc") { return nil, ErrInvalidSort }
+    if o.CreatedFrom != nil && o.CreatedTo != nil && !o.CreatedTo.After(*o.CreatedFrom) { return nil, ErrInvalidDateRange }
+    desc := o.Order == "desc" || (o.Order == "" && o.Sort != repositories.SortAlias)
+    limit := o.Limit
+    if limit <= 0 { limit = DefaultPageSize }
+    if limit > MaxPageSize { limit = MaxPageSize }
+
+    q := repositories.LinkQuery{
+        Search: o.Search, Creator: o.Creator, CreatedFrom: o.CreatedFrom, CreatedTo: o.CreatedTo,
+        Status: o.Status, Now: time.Now(), Sort: o.Sort, Desc: desc, Limit: limit + 1,
+    }
+    if o.Cursor != "" {
+        c, err := decodeCursor(o.Cursor)
+        if err != nil || c.Sort != o.Sort || c.Desc != desc { return nil, ErrInvalidCursor }
+        q.After = &repositories.LinkCursor{Value: c.Value, ID: c.ID}
+    }
+    links, err := s.repo.ListPage(q)
+    if err != nil { return nil, err }
+    page := &LinkPage{Links: links}
+    if len(links) > limit {
+        page.Links = links[:limit]
+        last := repositories.CursorFor(page.Links[limit-1], o.Sort)
+        page.NextCursor = encodeCursor(pageCursor{Sort: o.Sort, Desc: desc, Value: last.Value, ID: last.ID})
+    }
+    s.annotateAll(page.Links)
+    return page, nil
+}
+
+func encodeCursor(c pageCursor) string {
+    raw, _ := json.Marshal(c)
+    return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+    var c pageCursor
+    raw, err := base64.RawURLEncoding.DecodeString(s)
+    if err != nil { return c, ErrInvalidCursor }
+    if err := json.Unmarshal(raw, &c); err != nil || c.ID == 0 { return c, ErrInvalidCursor }
+    return c, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+func TestListLinksPage(t *testing.T) {
+    var seen []repositories.LinkQuery
+    repo := &fakeRepo{ ListPageFunc: func(q repositories.LinkQuery) ([]models.Link, error) {
+        seen = append(seen, q)
+        links := make([]models.Link, 0, q.Limit)
+        for i := 0; i < q.Limit && i < 3; i++ { links = append(links, models.Link{ID: uint(10 - i), Alias: "a", Clicks: 7}) }
+        return links, nil
+    } }
+    svc := NewLinkService(repo)
+
+    page, err := svc.ListLinksPage(LinkListOptions{Sort: "clicks", Limit: 2})
+    if err != nil || len(page.Links) != 2 || page.NextCursor == "" { t.Fatalf("expected a full page with a cursor, got %+v err=%v", page, err) }
+    if q := seen[0]; q.Limit != 3 || !q.Desc || q.After != nil { t.Fatalf("expected limit+1 descending with no cursor, got %+v", q) }
+
+    if _, err := svc.ListLinksPage(LinkListOptions{Sort: "clicks", Limit: 2, Cursor: page.NextCursor}); err != nil { t.Fatalf("unexpected error: %v", err) }
+    if a := seen[1].After; a == nil || a.Value != "7" || a.ID != 9 { t.Fatalf("expected the cursor to resume after link 9, got %+v", a) }
+
+    page, err = svc.ListLinksPage(LinkListOptions{Sort: "alias"})
+    if err != nil || len(page.Links) != 3 || page.NextCursor != "" { t.Fatalf("expected a last page without a cursor, got %+v err=%v", page, err) }
+    if q := seen[2]; q.Desc || q.Limit != DefaultPageSize+1 { t.Fatalf("expected aliases ascending by default, got %+v", q) }
+
+    if _, err := svc.ListLinksPage(LinkListOptions{Limit: 1000}); err != nil || seen[3].Limit != MaxPageSize+1 { t.Fatalf("expected the limit to be capped, got %+v", seen[3]) }
+}
+
+func TestListLinksPage_Rejects(t *testing.T) {
+    repo := &fakeRepo{ ListPageFunc: func(q repositories.LinkQuery) ([]models.Link, error) {
+        return []models.Link{{ID: 2}, {ID: 1}}, nil
+    } }
+    svc := NewLinkService(repo)
+    page, _ := svc.ListLinksPage(LinkListOptions{Limit: 1})
+    day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+    for name, tc := range map[string]struct {
+        opts LinkListOptions
+        want error
+    }{
+        "unknown sort":       {LinkListOptions{Sort: "url"}, ErrInvalidSort},
+        "unknown order":      {LinkListOptions{Order: "up"}, ErrInvalidSort},
+        "unknown status":     {LinkListOptions{Status: "gone"}, ErrInvalidStatus},
+        "empty date range":   {LinkListOptions{CreatedFrom: &day, CreatedTo: &day}, ErrInvalidDateRange},
+        "garbage cursor":     {LinkListOptions{Cursor: "not-a-cursor"}, ErrInvalidCursor},
+        "cursor other sort":  {LinkListOptions{Sort: "alias", Cursor: page.NextCursor}, ErrInvalidCursor},
+        "cursor other order": {LinkListOptions{Order: "asc", Cursor: page.NextCursor}, ErrInvalidCursor},
+    } {
+        if _, err := svc.ListLinksPage(tc.opts); !errors.Is(err, tc.want) { t.Errorf("%s: expected %v, got %v", name, tc.want, err) }
+    }
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "strconv"
+    "strings"
+    "time"
+
+    "quickr/models"
+)
+
+const (
+    RevisionCreate  = "create"
+    RevisionUpdate  = "update"
+    RevisionDelete  = "delete"
+    RevisionRestore = "restore"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// LinkSnapshot is the user-editable state of a link at one point in time.
+type LinkSnapshot struct {
+    Alias       string     `json:"alias"`
+    URL         string     `json:"url"`
+    Passthrough bool       `json:"passthrough"`
+    QueryMerge  string     `json:"query_merge"`
+    ActiveFrom  *time.Time `json:"active_from"`
+    ExpiresAt   *time.Time `json:"expires_at"`
+    Aliases     []string   `json:"aliases,omitempty"`
+}
+
+// FieldChange is one field that differs between a revision's before and after.
+type FieldChange struct {
+    Field string `json:"field"`
+    From  string `json:"from"`
+    To    string `json:"to"`
+}
+
+// Revision is a decoded models.LinkRevision. Before is nil for creates and
+// After is nil for deletes.
+type Revision struct {
+    ID        uint          `json:"id"`
+    LinkID    uint          `json:"link_id"`
+    Action    string        `json:"action"`
+    Actor     string        `json:"actor"`
+    ActorID   *uint         `json:"actor_id"`
+    CreatedAt time.Time     `json:"created_at"`
+    Before    *LinkSnapshot `json:"before,omitempty"`
+    After     *LinkSnapshot `json:"after,omitempty"`
+    Changes   []FieldChange `json:"changes"`
+}
+
+// ListRevisions returns a link's history, newest first. It also works for
+// deleted links since revisions outlive the row they describe.
+func (s *LinkService) ListRevisions(linkID string) ([]Revision, error) {
+    if s.revisions == nil { return []Revision{}, nil }
+    rows, err := s.revisions.ListByLinkID(linkID)
+    if err != nil { return nil, err }
+    revs := make([]Revision, 0, len(rows))
+    names := map[uint]string{}
+    for i := range rows {
+        rev := decodeRevision(&rows[i])
+        rev.Actor = s.revisionActorName(rev, names)
+        revs = append(revs, rev)
+    }
+    return revs, nil
+}
+
+// revisionActorName shows admins under the admin name; everyone else keeps
+// the email recorded with the revision. names caches users already looked up.
+func (s *LinkService) revisionActorName(rev Revision, names map[uint]string) string {
+    if rev.ActorID == nil || s.users == nil || s.adminName == "" { return rev.Actor }
+    name, ok := names[*rev.ActorID]
+    if !ok {
+        name = rev.Actor
+        if u, err := s.users.FindByID(*rev.ActorID); err == nil { name = s.displayName(u, rev.Actor) }
+        names[*rev.ActorID] = name
+    }
+    return name
+}
+
+// RestoreRevision puts a link back to the state recorded by a revision. It
+// goes through the same validation as an edit and is recorded as a restore.
+// Secondary aliases are left as they are.
+func (s *LinkService) RestoreRevision(linkID, revisionID string, actor Actor) (*models.Link, error) {
+    if s.revisions == nil { return nil, ErrRevisionNotFound }
+    row, err := s.revisions.FindByID(revisionID)
+    if err != nil || strconv.FormatUint(uint64(row.LinkID), 10) != linkID { return nil, ErrRevisionNotFound }
+    rev := decodeRevision(row)
+    snap := rev.After
+    if snap == nil { snap = rev.Before }
+    if snap == nil { return nil, ErrRevisionNotFound }
+    passthroughOn := snap.Passthrough
+    opts := LinkOptions{
+        Passthrough: &passthroughOn,
+        QueryMerge:  snap.QueryMerge,
+        Schedule:    &Schedule{ActiveFrom: snap.ActiveFrom, ExpiresAt: snap.ExpiresAt},
+    }
+    return s.edit(linkID, snap.Alias, snap.URL, opts, actor, RevisionRestore)
+}
+
+// recordRevision stores one history entry. History is best effort: a failed
+// write is logged rather than failing the change it describes.
+func (s *LinkService) recordRevision(action string, before, after *models.Link, actor Actor) {
+    if s.revisions == nil { return }
+    rev := &models.LinkRevision{Action: action, Actor: actor.Name, ActorID: actor.UserID}
+    if before != nil {
+        rev.LinkID = before.ID
+        rev.BeforeState = encodeSnapshot(before)
+    }
+    if after != nil {
+        rev.LinkID = after.ID
+        rev.AfterState = encodeSnapshot(after)
+    }
+    if err := s.revisions.Create(rev); err != nil {
+        log.Printf("[REVISIONS] failed to record %s of link %d: %v", action, rev.LinkID, err)
+    }
+}
+
+func snapshotOf(link *models.Link) LinkSnapshot {
+    return LinkSnapshot{
+        Alias:       link.Alias,
+        URL:         link.URL,
+        Passthrough: link.Passthrough,
+        QueryMerge:  link.QueryMerge,
+        ActiveFrom:  link.ActiveFrom,
+        ExpiresAt:   link.ExpiresAt,
+        Aliases:     aliasNames(link.Aliases),
+    }
+}
+
+func encodeSnapshot(link *models.Link) string {
+    b, _ := json.Marshal(snapshotOf(link))
+    return string(b)
+}
+
+func decodeSnapshot(state string) *LinkSnapshot {
+    if state == "" { return nil }
+    var snap LinkSnapshot
+    if err := json.Unmarshal([]byte(state), &snap); err != nil { return nil }
+    return &snap
+}
+
+func decodeRevision(row *models.LinkRevision) Revision {
+    rev := Revision{
+        ID:        row.ID,
+        LinkID:    row.LinkID,
+        Action:    row.Action,
+        Actor:     row.Actor,
+        ActorID:   row.ActorID,
+        CreatedAt: row.CreatedAt,
+        Before:    decodeSnapshot(row.BeforeState),
+        After:     decodeSnapshot(row.AfterState),
+    }
+    rev.Changes = diffSnapshots(rev.Before, rev.After)
+    return rev
+}
+
+// diffSnapshots lists the fields that differ; a missing side compares as empty.
+func diffSnapshots(before, after *LinkSnapshot) []FieldChange {
+    var b, a LinkSnapshot
+    if before != nil { b = *before }
+    if after != nil { a = *after }
+    pairs := []struct{ field, from, to string }{
+        {"alias", b.Alias, a.Alias},
+        {"url", b.URL, a.URL},
+        {"passthrough", boolField(before, b.Passthrough), boolField(after, a.Passthrough)},
+        {"query_merge", b.QueryMerge, a.QueryMerge},
+        {"active_from", timeField(b.ActiveFrom), timeField(a.ActiveFrom)},
+        {"expires_at", timeField(b.ExpiresAt), timeField(a.ExpiresAt)},
+        {"aliases", strings.Join(b.Aliases, ", "), strings.Join(a.Aliases, ", ")},
+    }
+    changes := []FieldChange{}
+    for _, p := range pairs {
+        if p.from != p.to { changes = append(changes, FieldChange{Field: p.field, From: p.from, To: p.to}) }
+    }
+    return changes
+}
+
+func boolField(snap *LinkSnapshot, v bool) string {
+    if snap == nil { return "" }
+    return fmt.Sprint(v)
+}
+
+func timeField(t *time.Time) string {
+    if t == nil { return "" }
+    return t.Format("2006-01-02 15:04")
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+    "errors"
+    "testing"
+
+    "quickr/models"
+)
+
+// memLinkRepo backs a fakeRepo with a single stored link so edits stick.
+func memLinkRepo(stored *models.Link) *fakeRepo {
+    return &fakeRepo{
+        CreateFunc:                func(link *models.Link) error { link.ID = 7; *stored = *link; return nil },
+        FindByIDFunc:              func(id string) (*models.Link, error) { cp := *stored; return &cp, nil },
+        ExistsByAliasFunc:         func(alias string) (bool, error) { return false, nil },
+        ExistsByAliasExceptIDFunc: func(alias, id string) (bool, error) { return alias == "taken", nil },
+        SaveFunc:                  func(link *models.Link) error { *stored = *link; return nil },
+        DeleteFunc:                func(link *models.Link) error { return nil },
+    }
+}
+
+var (
+    aliceID = uint(1)
+    alice   = Actor{UserID: &aliceID, Name: "alice"}
+    bob     = Actor{Name: "bob", Admin: true}
+    carol   = Actor{Name: "carol", Admin: true}
+)
+
+func TestRevisions_RecordedForEachChange(t *testing.T) {
+    stored := &models.Link{}
+    revs := &fakeRevisionRepo{}
+    svc := NewLinkService(memLinkRepo(stored), WithRevisions(revs))
+
+    if _, err := svc.CreateLink("oncall", "https://wiki.example.com/oncall", alice); err != nil { t.Fatalf("create: %v", err) }
+    if _, err := svc.UpdateLink("7", "", "https://wrong.example.com", bob); err != nil { t.Fatalf("update: %v", err) }
+    if _, err := svc.DeleteLink("7", carol); err != nil { t.Fatalf("delete: %v", err) }
+
+    history, err := svc.ListRevisions("7")
+    if err != nil || len(history) != 3 { t.Fatalf("expected 3 revisions, got %d err=%v", len(history), err) }
+    del, upd, crt := history[0], history[1], history[2]
+    if crt.Action != RevisionCreate || crt.Actor != "alice" || crt.Before != nil || crt.After.URL != "https://wiki.example.com/oncall" {
+        t.Fatalf("unexpected create revision: %+v", crt)
+    }
+    if upd.Action != RevisionUpdate || upd.Actor != "bob" || len(upd.Changes) != 1 || upd.Changes[0].Field != "url" ||
+        upd.Changes[0].From != "https://wiki.example.com/oncall" || upd.Changes[0].To != "https://wrong.example.com" {
+        t.Fatalf("unexpected update revision: %+v", upd)
+    }
+    if del.Action != RevisionDelete || del.Actor != "carol" || del.After != nil || del.Before.Alias != "oncall" {
+        t.Fatalf("unexpected delete revision: %+v", del)
+    }
+}
+
+func TestRevisions_RecordFailureDoesNotFailChange(t *testing.T) {
+    stored := &models.Link{}
+    svc := NewLinkService(memLinkRepo(stored), WithRevisions(&fakeRevisionRepo{CreateErr: errors.New("db down")}))
+    if _, err := svc.CreateLink("a", "https://a.com", alice); err != nil { t.Fatalf("unexpected error: %v", err) }
+}
+
+func TestRestoreRevision(t *testing.T) {
+    stored := &models.Link{}
+    revs := &fakeRevisionRepo{}
+    svc := NewLinkService(memLinkRepo(stored), WithRevisions(revs))
+    svc.CreateLink("oncall", "https://wiki.example.com/oncall", alice)
+    svc.UpdateLink("7", "", "https://wrong.example.com", bob)
+
+    link, err := svc.RestoreRevision("7", "1", carol)
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+    if link.URL != "https://wiki.example.com/oncall" || stored.URL != link.URL {
+        t.Fatalf("expected URL restored, got %+v", link)
+    }
+    history, _ := svc.ListRevisions("7")
+    if history[0].Action != RevisionRestore || history[0].Actor != "carol" {
+        t.Fatalf("expected restore to be recorded, got %+v", history[0])
+    }
+
+    if _, err := svc.RestoreRevision("8", "1", carol); !errors.Is(err, ErrRevisionNotFound) {
+        t.Fatalf("expected ErrRevisionNotFound for another link's revision, got %v", err)
+    }
+    if _, err := svc.RestoreRevision("7", "99", carol); !errors.Is(err, ErrRevisionNotFound) {
+        t.Fatalf("expected ErrRevisionNotFound, got %v", err)
+    }
+
+    // restoring goes through normal validation
+    svc.UpdateLink("7", "taken-later", "", bob)
+    stored.Alias = "renamed"
+    revs.revs[len(revs.revs)-1].AfterState = `{"alias":"taken","url":"https://wiki.example.com/oncall"}`
+    if _, err := svc.RestoreRevision("7", "4", carol); !errors.Is(err, ErrAliasExists) {
+        t.Fatalf("expected ErrAliasExists, got %v", err)
+    }
+}
+
+func TestListRevisions_WithoutRepo(t *testing.T) {
+    svc := NewLinkService(&fakeRepo{})
+    if revs, err := svc.ListRevisions("1"); err != nil || len(revs) != 0 {
+        t.Fatalf("expected empty history, got %v err=%v", revs, err)
+    }
+}
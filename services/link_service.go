@@ -3,7 +3,11 @@ package services
 import (
     "errors"
     "strings"
+    "time"
 
+    "quickr/domain/linkstatus"
+    "quickr/domain/linktemplate"
+    "quickr/domain/passthrough"
     "quickr/domain/reserved"
     "quickr/domain/validation"
     "quickr/models"
@@ -11,21 +15,74 @@ import (
 )
 
 var (
-    ErrAliasReserved = errors.New("alias is reserved")
-    ErrInvalidURL    = errors.New("invalid url format")
-    ErrAliasExists   = errors.New("alias already exists")
-    ErrLinkNotFound  = errors.New("link not found")
+    ErrAliasReserved   = errors.New("alias is reserved")
+    ErrInvalidURL      = errors.New("invalid url format")
+    ErrAliasExists     = repositories.ErrAliasExists
+    ErrLinkNotFound    = errors.New("link not found")
+    ErrMissingArgument = linktemplate.ErrMissingArgument
+    ErrInvalidMerge    = errors.New("invalid query merge strategy")
+    ErrLinkExpired     = errors.New("link has expired")
+    ErrLinkNotActive   = errors.New("link is not active yet")
+    ErrUnexpectedPath  = errors.New("link takes no extra path")
+    ErrInvalidSchedule = errors.New("expiry must be after activation")
+    ErrInvalidStatus   = errors.New("invalid link status")
+    // ErrVersionConflict means someone else saved the link since the editor loaded it
+    ErrVersionConflict = repositories.ErrVersionConflict
+    // ErrInvalidPlaceholder means a link saved before placeholders were validated uses one like {0}
+    ErrInvalidPlaceholder = linktemplate.ErrInvalidPlaceholder
 )
 
-type LinkService struct { repo repositories.LinkRepository }
+type LinkService struct {
+    repo      repositories.LinkRepository
+    revisions repositories.LinkRevisionRepository
+    coOwners  repositories.LinkCoOwnerRepository
+    users     repositories.UserRepository
+    adminName string
 
-func NewLinkService(repo repositories.LinkRepository) *LinkService { return &LinkService{repo: repo} }
+    linkAliases     repositories.LinkAliasRepository
+    aliasHistory    repositories.AliasHistoryRepository
+    aliasForwardTTL time.Duration
 
-func (s *LinkService) ValidateURL(urlStr string) bool { return validation.IsValidHTTPURL(urlStr) }
+    clickBuffer *ClickBuffer
+    aliasCache  AliasCache
+    tx          repositories.LinkTransactor
+}
+
+// LinkServiceOption plugs an optional collaborator into a LinkService.
+type LinkServiceOption func(*LinkService)
+
+// WithRevisions records every create, update, delete and restore in revs.
+func WithRevisions(revs repositories.LinkRevisionRepository) LinkServiceOption {
+    return func(s *LinkService) { s.revisions = revs }
+}
+
+func NewLinkService(repo repositories.LinkRepository, opts ...LinkServiceOption) *LinkService {
+    s := &LinkService{repo: repo}
+    for _, opt := range opts { opt(s) }
+    return s
+}
+
+// ValidateURL accepts plain http(s) URLs and template URLs whose placeholders
+// are numbered from {1} and still produce a valid URL once substituted.
+func (s *LinkService) ValidateURL(urlStr string) bool {
+    if s.IsTemplate(urlStr) {
+        if linktemplate.Validate(urlStr) != nil { return false }
+        return validation.IsValidHTTPURL(linktemplate.Sample(urlStr))
+    }
+    return validation.IsValidHTTPURL(urlStr)
+}
+
+// IsTemplate reports whether a target URL takes path arguments ({1}, {*}, %s).
+func (s *LinkService) IsTemplate(urlStr string) bool { return linktemplate.IsTemplate(urlStr) }
 
 func (s *LinkService) IsAliasReserved(alias string) bool { return reserved.IsReservedAlias(alias) }
 
-func (s *LinkService) CreateLink(alias, targetURL, creator string) (*models.Link, error) {
+func (s *LinkService) CreateLink(alias, targetURL string, creator Actor) (*models.Link, error) {
+    return s.CreateLinkWithOptions(alias, targetURL, creator, LinkOptions{})
+}
+
+// CreateLinkWithOptions creates a link with its optional settings in a single insert.
+func (s *LinkService) CreateLinkWithOptions(alias, targetURL string, creator Actor, opts LinkOptions) (*models.Link, error) {
     alias = strings.TrimSpace(alias)
     targetURL = strings.TrimSpace(targetURL)
     if alias == "" || targetURL == "" {
@@ -37,15 +94,50 @@ func (s *LinkService) CreateLink(alias, targetURL, creator string) (*models.Link
     if !s.ValidateURL(targetURL) {
         return nil, ErrInvalidURL
     }
+    if err := s.ValidateOptions(opts); err != nil { return nil, err }
+    return withTx(s, func(tx *LinkService) (*models.Link, error) { return tx.create(alias, targetURL, creator, opts) })
+}
+
+// create inserts a validated link. The database rejects a live alias taken
+// since the check with ErrAliasExists as well. A retired alias taken over with
+// opts.TakeRetiredAlias only stops forwarding if the insert commits.
+func (s *LinkService) create(alias, targetURL string, creator Actor, opts LinkOptions) (*models.Link, error) {
     if exists, err := s.repo.ExistsByAlias(alias); err != nil { return nil, err } else if exists { return nil, ErrAliasExists }
-    link := &models.Link{Alias: alias, URL: targetURL, CreatorName: creator}
+    if _, err := s.FindRetiredAlias(alias); err == nil {
+        if !opts.TakeRetiredAlias { return nil, ErrAliasRetired }
+        if err := s.ReleaseRetiredAlias(alias); err != nil { return nil, err }
+    }
+    link := &models.Link{Alias: alias, URL: targetURL, CreatorName: creator.Name, CreatedBy: creator.UserID, CreatedByName: s.actorName(creator), QueryMerge: passthrough.MergeKeepTarget}
+    applyOptions(link, opts)
     if err := s.repo.Create(link); err != nil { return nil, err }
-    return link, nil
+    s.forget(link.ID, link.Alias)
+    s.recordRevision(RevisionCreate, nil, link, creator)
+    return s.annotate(link), nil
+}
+
+func (s *LinkService) UpdateLink(id string, newAlias, newURL string, editor Actor) (*models.Link, error) {
+    return s.EditLink(id, newAlias, newURL, LinkOptions{}, editor)
+}
+
+// EditLink changes alias, URL and options in one save, producing a single
+// revision. An empty alias or URL keeps the current value. With
+// opts.IfVersion set, the edit fails with ErrVersionConflict once anyone
+// else has saved the link since that version.
+func (s *LinkService) EditLink(id string, newAlias, newURL string, opts LinkOptions, editor Actor) (*models.Link, error) {
+    return s.edit(id, newAlias, newURL, opts, editor, RevisionUpdate)
 }
 
-func (s *LinkService) UpdateLink(id string, newAlias, newURL, editor string) (*models.Link, error) {
+func (s *LinkService) edit(id string, newAlias, newURL string, opts LinkOptions, editor Actor, action string) (*models.Link, error) {
+    if err := s.ValidateOptions(opts); err != nil { return nil, err }
+    return withTx(s, func(tx *LinkService) (*models.Link, error) { return tx.applyEdit(id, newAlias, newURL, opts, editor, action) })
+}
+
+func (s *LinkService) applyEdit(id string, newAlias, newURL string, opts LinkOptions, editor Actor, action string) (*models.Link, error) {
     link, err := s.repo.FindByID(id)
     if err != nil { return nil, ErrLinkNotFound }
+    if err := s.authorize(link, editor); err != nil { return nil, err }
+    if opts.IfVersion != 0 && opts.IfVersion != link.Version { return nil, ErrVersionConflict }
+    before := *link
     if newAlias != "" {
         if s.IsAliasReserved(newAlias) {
             return nil, ErrAliasReserved
@@ -59,26 +151,58 @@ func (s *LinkService) UpdateLink(id string, newAlias, newURL, editor string) (*m
         }
         link.URL = newURL
     }
-    if editor != "" {
-        link.CreatorName = editor
+    link.UpdatedBy, link.Updater, link.UpdatedByName = editor.UserID, nil, s.actorName(editor)
+    applyOptions(link, opts)
+    if link.Alias != before.Alias {
+        if err := s.promoteAlias(link, link.Alias); err != nil { return nil, err }
     }
     if err := s.repo.Save(link); err != nil { return nil, err }
-    return link, nil
+    s.forget(link.ID, link.Alias)
+    if link.Alias != before.Alias {
+        // the new alias stops forwarding elsewhere; the old one now forwards here
+        if err := s.ReleaseRetiredAlias(link.Alias); err != nil { return nil, err }
+        s.retireAlias(before.Alias, link, editor)
+    }
+    s.recordRevision(action, &before, link, editor)
+    return s.annotate(link), nil
+}
+
+func (s *LinkService) DeleteLink(id string, actor Actor) (*models.Link, error) {
+    return withTx(s, func(tx *LinkService) (*models.Link, error) { return tx.delete(id, actor) })
 }
 
-func (s *LinkService) DeleteLink(id string) (*models.Link, error) {
+func (s *LinkService) delete(id string, actor Actor) (*models.Link, error) {
     link, err := s.repo.FindByID(id)
     if err != nil { return nil, ErrLinkNotFound }
+    if err := s.authorize(link, actor); err != nil { return nil, err }
+    link.DeletedBy = actor.UserID
     if err := s.repo.Delete(link); err != nil { return nil, err }
+    s.forget(link.ID)
+    s.recordRevision(RevisionDelete, link, nil, actor)
     return link, nil
 }
 
 func (s *LinkService) ListLinks() ([]models.Link, error) {
-    return s.repo.ListAll()
+    links, err := s.repo.ListAll()
+    return s.annotateAll(links), err
+}
+
+// ListLinksByStatus narrows ListLinks to active, scheduled or expired links.
+// An empty status returns every link.
+func (s *LinkService) ListLinksByStatus(status string) ([]models.Link, error) {
+    if status != "" && !linkstatus.IsValid(status) { return nil, ErrInvalidStatus }
+    links, err := s.ListLinks()
+    if err != nil || status == "" { return links, err }
+    filtered := make([]models.Link, 0, len(links))
+    for _, l := range links {
+        if l.Status == status { filtered = append(filtered, l) }
+    }
+    return filtered, nil
 }
 
 func (s *LinkService) SearchLinks(query string) ([]models.Link, error) {
-    return s.repo.Search(query)
+    links, err := s.repo.Search(query)
+    return s.annotateAll(links), err
 }
 
 func (s *LinkService) FindByAlias(alias string) (*models.Link, error) {
@@ -87,12 +211,62 @@ func (s *LinkService) FindByAlias(alias string) (*models.Link, error) {
     return link, nil
 }
 
-func (s *LinkService) IncrementClicks(id uint) error { return s.repo.IncrementClicks(id) }
+// ResolveTarget finds the link behind alias, or behind a retired alias of a
+// renamed link, and computes the redirect target.
+// Template links consume the extra path segments; other links only accept
+// them when passthrough is enabled. Passthrough links also merge rawQuery.
+func (s *LinkService) ResolveTarget(alias string, args []string, rawQuery string) (*models.Link, string, error) {
+    link, err := s.repo.FindByAlias(alias)
+    if err != nil {
+        // a renamed link keeps answering on its retired aliases
+        if link, err = s.FindRetiredAlias(alias); err != nil { return nil, "", ErrLinkNotFound }
+    }
+    switch s.annotate(link).Status {
+    case linkstatus.Expired:
+        return link, "", ErrLinkExpired
+    case linkstatus.Scheduled:
+        return link, "", ErrLinkNotActive
+    }
+    target := link.URL
+    if s.IsTemplate(link.URL) {
+        if target, err = linktemplate.Expand(link.URL, args); err != nil { return link, "", err }
+        args = nil
+    }
+    if !link.Passthrough {
+        if len(args) > 0 { return link, "", ErrUnexpectedPath }
+        return link, target, nil
+    }
+    target, err = passthrough.Apply(target, args, rawQuery, link.QueryMerge)
+    if err != nil { return nil, "", ErrInvalidURL }
+    return link, target, nil
+}
+
+// IncrementClicks counts a redirect, queueing it when clicks are buffered.
+func (s *LinkService) IncrementClicks(id uint) error {
+    if s.clickBuffer != nil {
+        s.clickBuffer.AddClick(id)
+        return nil
+    }
+    return s.repo.IncrementClicks(id)
+}
 
 func (s *LinkService) GetLinkByID(id string) (*models.Link, error) {
 	link, err := s.repo.FindByID(id)
 	if err != nil { return nil, errors.New("link not found") }
-	return link, nil
+	return s.annotate(link), nil
 }
 
+// annotate fills the derived Status and author display names. Links saved
+// before user references existed fall back to CreatorName.
+func (s *LinkService) annotate(link *models.Link) *models.Link {
+    link.Status = linkstatus.Of(link.ActiveFrom, link.ExpiresAt, time.Now())
+    if link.CreatedByName == "" { link.CreatedByName = s.displayName(link.Creator, link.CreatorName) }
+    if link.UpdatedBy != nil && link.UpdatedByName == "" { link.UpdatedByName = s.displayName(link.Updater, "") }
+    if link.DeletedAt.Valid && link.DeletedByName == "" { link.DeletedByName = s.displayName(link.Deleter, trashDeleterFallback) }
+    return link
+}
 
+func (s *LinkService) annotateAll(links []models.Link) []models.Link {
+    for i := range links { s.annotate(&links[i]) }
+    return links
+}
@@ -3,6 +3,7 @@ package services
 import (
     "errors"
     "testing"
+    "time"
 
     "quickr/models"
 )
@@ -17,7 +18,7 @@ func TestCreateLink_Success(t *testing.T) {
     }
     svc := NewLinkService(repo)
 
-    link, err := svc.CreateLink("foo", "https://example.com", "alice@example.com")
+    link, err := svc.CreateLink("foo", "https://example.com", Actor{Name: "alice@example.com"})
     if err != nil { t.Fatalf("unexpected error: %v", err) }
     if link == nil { t.Fatalf("expected link, got nil") }
     if link.Alias != "foo" { t.Errorf("alias mismatch: %q", link.Alias) }
@@ -40,16 +41,16 @@ func TestCreateLink_TrimsInputs(t *testing.T) {
     }
     svc := NewLinkService(repo)
 
-    _, err := svc.CreateLink("  foo  ", "  https://example.com  ", "alice")
+    _, err := svc.CreateLink("  foo  ", "  https://example.com  ", Actor{Name: "alice"})
     if err != nil { t.Fatalf("unexpected error: %v", err) }
 }
 
 func TestCreateLink_MissingFields(t *testing.T) {
     svc := NewLinkService(&fakeRepo{})
-    if _, err := svc.CreateLink("", "https://example.com", "alice"); err == nil {
+    if _, err := svc.CreateLink("", "https://example.com", Actor{Name: "alice"}); err == nil {
         t.Fatalf("expected error for missing alias")
     }
-    if _, err := svc.CreateLink("foo", "", "alice"); err == nil {
+    if _, err := svc.CreateLink("foo", "", Actor{Name: "alice"}); err == nil {
         t.Fatalf("expected error for missing url")
     }
 }
@@ -57,7 +58,7 @@ func TestCreateLink_MissingFields(t *testing.T) {
 func TestCreateLink_ReservedAlias(t *testing.T) {
     // 'admin' is reserved per domain/reserved
     svc := NewLinkService(&fakeRepo{})
-    _, err := svc.CreateLink("admin", "https://example.com", "alice")
+    _, err := svc.CreateLink("admin", "https://example.com", Actor{Name: "alice"})
     if !errors.Is(err, ErrAliasReserved) {
         t.Fatalf("expected ErrAliasReserved, got %v", err)
     }
@@ -65,7 +66,7 @@ func TestCreateLink_ReservedAlias(t *testing.T) {
 
 func TestCreateLink_InvalidURL(t *testing.T) {
     svc := NewLinkService(&fakeRepo{})
-    _, err := svc.CreateLink("foo", "nota-valid-url", "alice")
+    _, err := svc.CreateLink("foo", "nota-valid-url", Actor{Name: "alice"})
     if !errors.Is(err, ErrInvalidURL) {
         t.Fatalf("expected ErrInvalidURL, got %v", err)
     }
@@ -76,7 +77,7 @@ func TestCreateLink_AliasExists(t *testing.T) {
         ExistsByAliasFunc: func(alias string) (bool, error) { return true, nil },
     }
     svc := NewLinkService(repo)
-    _, err := svc.CreateLink("foo", "https://example.com", "alice")
+    _, err := svc.CreateLink("foo", "https://example.com", Actor{Name: "alice"})
     if !errors.Is(err, ErrAliasExists) {
         t.Fatalf("expected ErrAliasExists, got %v", err)
     }
@@ -89,14 +90,14 @@ func TestCreateLink_RepoErrorsPropagate(t *testing.T) {
         CreateFunc: func(link *models.Link) error { return someErr },
     }
     svc := NewLinkService(repo)
-    _, err := svc.CreateLink("foo", "https://example.com", "alice")
+    _, err := svc.CreateLink("foo", "https://example.com", Actor{Name: "alice"})
     if !errors.Is(err, someErr) {
         t.Fatalf("expected repo error propagated, got %v", err)
     }
 
     repo2 := &fakeRepo{ ExistsByAliasFunc: func(alias string) (bool, error) { return false, someErr } }
     svc2 := NewLinkService(repo2)
-    _, err = svc2.CreateLink("foo", "https://example.com", "alice")
+    _, err = svc2.CreateLink("foo", "https://example.com", Actor{Name: "alice"})
     if !errors.Is(err, someErr) {
         t.Fatalf("expected exists error propagated, got %v", err)
     }
@@ -117,16 +118,18 @@ func TestUpdateLink_Success_AllFields(t *testing.T) {
             saved = true
             if link.Alias != "bar" { t.Fatalf("alias not updated: %q", link.Alias) }
             if link.URL != "https://new.com" { t.Fatalf("url not updated: %q", link.URL) }
-            if link.CreatorName != "bob" { t.Fatalf("editor not updated: %q", link.CreatorName) }
+            if link.CreatorName != "alice" { t.Fatalf("creator overwritten: %q", link.CreatorName) }
+            if link.UpdatedBy == nil || *link.UpdatedBy != 2 { t.Fatalf("editor not recorded: %v", link.UpdatedBy) }
             return nil
         },
     }
     svc := NewLinkService(repo)
 
-    link, err := svc.UpdateLink("1", "bar", "https://new.com", "bob")
+    bob := uint(2)
+    link, err := svc.UpdateLink("1", "bar", "https://new.com", Actor{UserID: &bob, Name: "bob", Admin: true})
     if err != nil { t.Fatalf("unexpected error: %v", err) }
     if !saved { t.Fatalf("expected Save to be called") }
-    if link.Alias != "bar" || link.URL != "https://new.com" || link.CreatorName != "bob" {
+    if link.Alias != "bar" || link.URL != "https://new.com" || link.CreatedByName != "alice" || link.UpdatedByName != "bob" {
         t.Fatalf("returned link not updated correctly")
     }
 }
@@ -138,7 +141,7 @@ func TestUpdateLink_NoChanges(t *testing.T) {
     }
     svc := NewLinkService(repo)
 
-    link, err := svc.UpdateLink("2", "", "", "")
+    link, err := svc.UpdateLink("2", "", "", testAdmin)
     if err != nil { t.Fatalf("unexpected error: %v", err) }
     if link.Alias != "keep" || link.URL != "https://same.com" || link.CreatorName != "alice" {
         t.Fatalf("link should be unchanged when no inputs provided")
@@ -148,7 +151,7 @@ func TestUpdateLink_NoChanges(t *testing.T) {
 func TestUpdateLink_ReservedAlias(t *testing.T) {
     repo := &fakeRepo{ FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 3, Alias: "foo", URL: "https://x", CreatorName: "a"}, nil } }
     svc := NewLinkService(repo)
-    _, err := svc.UpdateLink("3", "admin", "", "") // reserved
+    _, err := svc.UpdateLink("3", "admin", "", testAdmin) // reserved
     if !errors.Is(err, ErrAliasReserved) {
         t.Fatalf("expected ErrAliasReserved, got %v", err)
     }
@@ -160,7 +163,7 @@ func TestUpdateLink_AliasExists(t *testing.T) {
         ExistsByAliasExceptIDFunc: func(alias string, id string) (bool, error) { return true, nil },
     }
     svc := NewLinkService(repo)
-    _, err := svc.UpdateLink("4", "taken", "", "")
+    _, err := svc.UpdateLink("4", "taken", "", testAdmin)
     if !errors.Is(err, ErrAliasExists) {
         t.Fatalf("expected ErrAliasExists, got %v", err)
     }
@@ -169,7 +172,7 @@ func TestUpdateLink_AliasExists(t *testing.T) {
 func TestUpdateLink_InvalidURL(t *testing.T) {
     repo := &fakeRepo{ FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 5, Alias: "foo", URL: "https://x", CreatorName: "a"}, nil } }
     svc := NewLinkService(repo)
-    _, err := svc.UpdateLink("5", "", "notaurl", "")
+    _, err := svc.UpdateLink("5", "", "notaurl", testAdmin)
     if !errors.Is(err, ErrInvalidURL) {
         t.Fatalf("expected ErrInvalidURL, got %v", err)
     }
@@ -179,7 +182,7 @@ func TestUpdateLink_FindOrSaveErrors(t *testing.T) {
     findErr := errors.New("missing")
     repo := &fakeRepo{ FindByIDFunc: func(id string) (*models.Link, error) { return nil, findErr } }
     svc := NewLinkService(repo)
-    if _, err := svc.UpdateLink("404", "", "", ""); !errors.Is(err, ErrLinkNotFound) {
+    if _, err := svc.UpdateLink("404", "", "", testAdmin); !errors.Is(err, ErrLinkNotFound) {
         t.Fatalf("expected ErrLinkNotFound, got %v", err)
     }
 
@@ -189,11 +192,27 @@ func TestUpdateLink_FindOrSaveErrors(t *testing.T) {
         SaveFunc: func(link *models.Link) error { return saveErr },
     }
     svc2 := NewLinkService(repo2)
-    if _, err := svc2.UpdateLink("6", "", "", ""); !errors.Is(err, saveErr) {
+    if _, err := svc2.UpdateLink("6", "", "", testAdmin); !errors.Is(err, saveErr) {
         t.Fatalf("expected save error to propagate, got %v", err)
     }
 }
 
+func TestEditLink_IfVersion(t *testing.T) {
+    saves := 0
+    repo := &fakeRepo{
+        FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 7, Alias: "a", URL: "https://x", CreatorName: "a", Version: 3}, nil },
+        SaveFunc:     func(link *models.Link) error { saves++; return nil },
+    }
+    svc := NewLinkService(repo)
+    if _, err := svc.EditLink("7", "", "https://y.example.com", LinkOptions{IfVersion: 2}, testAdmin); !errors.Is(err, ErrVersionConflict) || saves != 0 {
+        t.Fatalf("expected ErrVersionConflict without saving, got %v after %d saves", err, saves)
+    }
+    if _, err := svc.EditLink("7", "", "https://y.example.com", LinkOptions{IfVersion: 3}, testAdmin); err != nil || saves != 1 { t.Fatalf("expected the current version to save, got %v", err) }
+
+    repo.SaveFunc = func(link *models.Link) error { return ErrVersionConflict } // someone saved in between
+    if _, err := svc.EditLink("7", "", "https://y.example.com", LinkOptions{}, testAdmin); !errors.Is(err, ErrVersionConflict) { t.Fatalf("expected a racing save to conflict, got %v", err) }
+}
+
 func TestDeleteLink_Success(t *testing.T) {
     toDelete := &models.Link{ID: 10, Alias: "foo"}
     deleted := false
@@ -202,7 +221,7 @@ func TestDeleteLink_Success(t *testing.T) {
         DeleteFunc:    func(link *models.Link) error { if link != toDelete { t.Fatalf("unexpected link in delete") }; deleted = true; return nil },
     }
     svc := NewLinkService(repo)
-    link, err := svc.DeleteLink("10")
+    link, err := svc.DeleteLink("10", Actor{Name: "alice", Admin: true})
     if err != nil { t.Fatalf("unexpected error: %v", err) }
     if link != toDelete { t.Fatalf("expected returned link to be deleted one") }
     if !deleted { t.Fatalf("expected Delete to be called") }
@@ -211,7 +230,7 @@ func TestDeleteLink_Success(t *testing.T) {
 func TestDeleteLink_Errors(t *testing.T) {
     repo := &fakeRepo{ FindByIDFunc: func(id string) (*models.Link, error) { return nil, errors.New("nope") } }
     svc := NewLinkService(repo)
-    if _, err := svc.DeleteLink("x"); !errors.Is(err, ErrLinkNotFound) {
+    if _, err := svc.DeleteLink("x", Actor{Name: "alice", Admin: true}); !errors.Is(err, ErrLinkNotFound) {
         t.Fatalf("expected ErrLinkNotFound, got %v", err)
     }
 
@@ -220,7 +239,7 @@ func TestDeleteLink_Errors(t *testing.T) {
         DeleteFunc:    func(link *models.Link) error { return errors.New("db down") },
     }
     svc2 := NewLinkService(repo2)
-    if _, err := svc2.DeleteLink("11"); err == nil {
+    if _, err := svc2.DeleteLink("11", Actor{Name: "alice", Admin: true}); err == nil {
         t.Fatalf("expected delete error")
     }
 }
@@ -272,3 +291,248 @@ func TestIncrementClicks_And_GetLinkByID(t *testing.T) {
 }
 
 
+
+func TestCreateLink_TemplateURL(t *testing.T) {
+    repo := &fakeRepo{
+        ExistsByAliasFunc: func(alias string) (bool, error) { return false, nil },
+        CreateFunc:        func(link *models.Link) error { return nil },
+    }
+    svc := NewLinkService(repo)
+    if _, err := svc.CreateLink("jira", "https://jira.example.com/browse/{1}", Actor{Name: "alice"}); err != nil {
+        t.Fatalf("unexpected error for template url: %v", err)
+    }
+    if _, err := svc.CreateLink("bad", "{1}", Actor{Name: "alice"}); !errors.Is(err, ErrInvalidURL) {
+        t.Fatalf("expected ErrInvalidURL for template without host, got %v", err)
+    }
+    for _, u := range []string{"https://x.com/{0}", "https://x.com/{99999999999999999999}"} {
+        if _, err := svc.CreateLink("bad", u, Actor{Name: "alice"}); !errors.Is(err, ErrInvalidURL) {
+            t.Fatalf("expected ErrInvalidURL for %q, got %v", u, err)
+        }
+    }
+}
+
+func TestUpdateLink_TemplateURL(t *testing.T) {
+    repo := &fakeRepo{
+        FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 8, Alias: "gh", URL: "https://github.com"}, nil },
+        SaveFunc:     func(link *models.Link) error { return nil },
+    }
+    svc := NewLinkService(repo)
+    link, err := svc.UpdateLink("8", "", "https://github.com/org/{*}", testAdmin)
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+    if link.URL != "https://github.com/org/{*}" { t.Fatalf("url not updated: %q", link.URL) }
+    for _, u := range []string{"ftp://{1}", "https://github.com/{0}"} {
+        if _, err := svc.UpdateLink("8", "", u, testAdmin); !errors.Is(err, ErrInvalidURL) {
+            t.Fatalf("expected ErrInvalidURL for %q, got %v", u, err)
+        }
+    }
+}
+
+func TestResolveTarget(t *testing.T) {
+    links := map[string]*models.Link{
+        "docs": {ID: 1, Alias: "docs", URL: "https://docs.example.com"},
+        "jira": {ID: 2, Alias: "jira", URL: "https://jira.example.com/browse/{1}"},
+    }
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) {
+        if l, ok := links[alias]; ok { return l, nil }
+        return nil, errors.New("not found")
+    } }
+    svc := NewLinkService(repo)
+
+    if _, target, err := svc.ResolveTarget("docs", nil, ""); err != nil || target != "https://docs.example.com" {
+        t.Fatalf("plain link: target=%q err=%v", target, err)
+    }
+    if link, _, err := svc.ResolveTarget("docs", []string{"extra"}, ""); !errors.Is(err, ErrUnexpectedPath) || link == nil {
+        t.Fatalf("expected ErrUnexpectedPath with the link for extra segments on plain link, got %v", err)
+    }
+    if _, target, err := svc.ResolveTarget("jira", []string{"PROJ-123"}, ""); err != nil || target != "https://jira.example.com/browse/PROJ-123" {
+        t.Fatalf("template link: target=%q err=%v", target, err)
+    }
+    if link, _, err := svc.ResolveTarget("jira", nil, ""); !errors.Is(err, ErrMissingArgument) || link == nil {
+        t.Fatalf("expected ErrMissingArgument with link, got link=%v err=%v", link, err)
+    }
+    if _, _, err := svc.ResolveTarget("missing", nil, ""); !errors.Is(err, ErrLinkNotFound) {
+        t.Fatalf("expected ErrLinkNotFound, got %v", err)
+    }
+}
+
+func TestResolveTarget_Passthrough(t *testing.T) {
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) {
+        switch alias {
+        case "docs":
+            return &models.Link{ID: 1, Alias: "docs", URL: "https://docs.example.com/?lang=en", Passthrough: true, QueryMerge: "target"}, nil
+        case "search":
+            return &models.Link{ID: 2, Alias: "search", URL: "https://example.com/search?q=%s", Passthrough: true, QueryMerge: "request"}, nil
+        }
+        return nil, errors.New("not found")
+    } }
+    svc := NewLinkService(repo)
+
+    _, target, err := svc.ResolveTarget("docs", []string{"guides", "setup"}, "tab=2&lang=fr")
+    if err != nil || target != "https://docs.example.com/guides/setup?lang=en&tab=2" {
+        t.Fatalf("unexpected passthrough target=%q err=%v", target, err)
+    }
+    // Template links keep consuming the path; only the query is merged.
+    _, target, err = svc.ResolveTarget("search", []string{"golang"}, "hl=fr")
+    if err != nil || target != "https://example.com/search?q=golang&hl=fr" {
+        t.Fatalf("unexpected template passthrough target=%q err=%v", target, err)
+    }
+}
+
+func TestEditLink_Passthrough(t *testing.T) {
+    var saved *models.Link
+    repo := &fakeRepo{
+        FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 3, Alias: "docs", URL: "https://docs.example.com"}, nil },
+        SaveFunc:     func(link *models.Link) error { saved = link; return nil },
+    }
+    svc := NewLinkService(repo)
+
+    on := true
+    link, err := svc.EditLink("3", "", "", LinkOptions{Passthrough: &on}, testAdmin)
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+    if saved != link || !link.Passthrough || link.QueryMerge != "target" {
+        t.Fatalf("passthrough not saved with default strategy: %+v", link)
+    }
+    if _, err := svc.EditLink("3", "", "", LinkOptions{Passthrough: &on, QueryMerge: "bogus"}, testAdmin); !errors.Is(err, ErrInvalidMerge) {
+        t.Fatalf("expected ErrInvalidMerge, got %v", err)
+    }
+}
+
+func TestResolveTarget_Schedule(t *testing.T) {
+    past := time.Now().Add(-time.Hour)
+    future := time.Now().Add(time.Hour)
+    links := map[string]*models.Link{
+        "old":  {ID: 1, Alias: "old", URL: "https://example.com", ExpiresAt: &past},
+        "soon": {ID: 2, Alias: "soon", URL: "https://example.com", ActiveFrom: &future},
+        "live": {ID: 3, Alias: "live", URL: "https://example.com", ActiveFrom: &past, ExpiresAt: &future},
+    }
+    repo := &fakeRepo{ FindByAliasFunc: func(alias string) (*models.Link, error) { return links[alias], nil } }
+    svc := NewLinkService(repo)
+
+    if link, _, err := svc.ResolveTarget("old", nil, ""); !errors.Is(err, ErrLinkExpired) || link.Status != "expired" {
+        t.Fatalf("expected ErrLinkExpired, got link=%+v err=%v", link, err)
+    }
+    if link, _, err := svc.ResolveTarget("soon", nil, ""); !errors.Is(err, ErrLinkNotActive) || link.Status != "scheduled" {
+        t.Fatalf("expected ErrLinkNotActive, got link=%+v err=%v", link, err)
+    }
+    if _, target, err := svc.ResolveTarget("live", nil, ""); err != nil || target != "https://example.com" {
+        t.Fatalf("expected live link to redirect, got target=%q err=%v", target, err)
+    }
+}
+
+func TestEditLink_Schedule(t *testing.T) {
+    stamped := time.Now().Add(-time.Minute)
+    repo := &fakeRepo{
+        FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 4, Alias: "war-room", URL: "https://x.com", ExpiredAt: &stamped}, nil },
+        SaveFunc:     func(link *models.Link) error { return nil },
+    }
+    svc := NewLinkService(repo)
+
+    from := time.Now().Add(time.Hour)
+    until := from.Add(24 * time.Hour)
+    link, err := svc.EditLink("4", "", "", LinkOptions{Schedule: &Schedule{ActiveFrom: &from, ExpiresAt: &until}}, testAdmin)
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+    if link.Status != "scheduled" || link.ExpiredAt != nil {
+        t.Fatalf("expected scheduled link with cleared expiry stamp, got %+v", link)
+    }
+    if _, err := svc.EditLink("4", "", "", LinkOptions{Schedule: &Schedule{ActiveFrom: &until, ExpiresAt: &from}}, testAdmin); !errors.Is(err, ErrInvalidSchedule) {
+        t.Fatalf("expected ErrInvalidSchedule, got %v", err)
+    }
+}
+
+func TestListLinksByStatus(t *testing.T) {
+    past := time.Now().Add(-time.Hour)
+    repo := &fakeRepo{ ListAllFunc: func() ([]models.Link, error) {
+        return []models.Link{{Alias: "a"}, {Alias: "b", ExpiresAt: &past}}, nil
+    } }
+    svc := NewLinkService(repo)
+
+    expired, err := svc.ListLinksByStatus("expired")
+    if err != nil || len(expired) != 1 || expired[0].Alias != "b" { t.Fatalf("unexpected expired links: %+v err=%v", expired, err) }
+    all, err := svc.ListLinksByStatus("")
+    if err != nil || len(all) != 2 || all[0].Status != "active" { t.Fatalf("unexpected links: %+v err=%v", all, err) }
+    if _, err := svc.ListLinksByStatus("bogus"); !errors.Is(err, ErrInvalidStatus) {
+        t.Fatalf("expected ErrInvalidStatus, got %v", err)
+    }
+}
+
+func TestSweepExpired(t *testing.T) {
+    now := time.Now()
+    past := now.Add(-time.Hour)
+    var saved []uint
+    var deleted []string
+    newRepo := func() *fakeRepo {
+        saved, deleted = nil, nil
+        return &fakeRepo{
+            ListExpiredUnmarkedFunc: func(at time.Time) ([]models.Link, error) {
+                return []models.Link{{ID: 1, Alias: "a", ExpiresAt: &past}, {ID: 2, Alias: "b", ExpiresAt: &past}}, nil
+            },
+            MarkExpiredFunc: func(id uint, at time.Time) error {
+                if !at.Equal(now) { t.Fatalf("expected ExpiredAt stamp, got %v", at) }
+                saved = append(saved, id)
+                return nil
+            },
+            DeleteFunc: func(link *models.Link) error { deleted = append(deleted, link.Alias); return nil },
+        }
+    }
+
+    n, err := NewLinkService(newRepo()).SweepExpired(now, false)
+    if err != nil || n != 2 || len(saved) != 2 || len(deleted) != 0 {
+        t.Fatalf("unexpected sweep: n=%d saved=%v deleted=%v err=%v", n, saved, deleted, err)
+    }
+    n, err = NewLinkService(newRepo()).SweepExpired(now, true)
+    if err != nil || n != 2 || len(deleted) != 2 {
+        t.Fatalf("expected aliases to be freed: n=%d deleted=%v err=%v", n, deleted, err)
+    }
+}
+
+func TestLinkAuthors(t *testing.T) {
+    var created *models.Link
+    alice := uint(1)
+    repo := &fakeRepo{
+        ExistsByAliasFunc: func(alias string) (bool, error) { return false, nil },
+        CreateFunc:        func(link *models.Link) error { created = link; return nil },
+        ListAllFunc: func() ([]models.Link, error) {
+            return []models.Link{
+                {Alias: "a", CreatorName: "old-name", CreatedBy: &alice, Creator: &models.User{ID: 1, Email: "alice@example.com"},
+                    UpdatedBy: new(uint), Updater: &models.User{Email: "root@example.com", Role: "admin"}},
+                {Alias: "legacy", CreatorName: "someone"},
+            }, nil
+        },
+    }
+    svc := NewLinkService(repo, WithAdminName("Ops"))
+
+    if _, err := svc.CreateLink("foo", "https://example.com", Actor{UserID: &alice, Name: "alice@example.com"}); err != nil { t.Fatalf("unexpected error: %v", err) }
+    if created.CreatedBy == nil || *created.CreatedBy != alice || created.UpdatedBy != nil {
+        t.Fatalf("expected creator reference only, got %+v", created)
+    }
+
+    links, _ := svc.ListLinks()
+    if links[0].CreatedByName != "alice@example.com" || links[0].UpdatedByName != "Ops" {
+        t.Fatalf("unexpected author names: %q / %q", links[0].CreatedByName, links[0].UpdatedByName)
+    }
+    if links[1].CreatedByName != "someone" || links[1].UpdatedByName != "" {
+        t.Fatalf("expected legacy fallback to CreatorName, got %q / %q", links[1].CreatedByName, links[1].UpdatedByName)
+    }
+}
+
+func TestLinkAuthors_AdminsRecordedByIdentity(t *testing.T) {
+    var created *models.Link
+    root, ops := uint(1), uint(2)
+    revs := &fakeRevisionRepo{}
+    repo := &fakeRepo{
+        ExistsByAliasFunc: func(alias string) (bool, error) { return false, nil },
+        CreateFunc:        func(link *models.Link) error { created = link; return nil },
+    }
+    users := &fakeUserRepo{users: []models.User{{ID: root, Email: "root@example.com", Role: "admin"}, {ID: ops, Email: "ops@example.com", Role: "admin"}}}
+    svc := NewLinkService(repo, WithAdminName("Ops"), WithRevisions(revs), WithUsers(users))
+
+    // two admins share the display name but not the identity on record
+    for _, admin := range []Actor{{UserID: &root, Name: "root@example.com", Admin: true}, {UserID: &ops, Name: "ops@example.com", Admin: true}} {
+        link, err := svc.CreateLink("foo", "https://example.com", admin)
+        if err != nil { t.Fatalf("unexpected error: %v", err) }
+        if created.CreatorName != admin.Name || link.CreatedByName != "Ops" { t.Fatalf("expected %s on record shown as Ops, got %q / %q", admin.Name, created.CreatorName, link.CreatedByName) }
+    }
+    if revs.revs[0].Actor != "root@example.com" || revs.revs[1].Actor != "ops@example.com" { t.Fatalf("expected each admin recorded in history, got %+v", revs.revs) }
+    history, _ := svc.ListRevisions("0")
+    if len(history) != 2 || history[0].Actor != "Ops" || *history[0].ActorID != ops { t.Fatalf("expected admins shown as Ops in history, got %+v", history) }
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+    "time"
+    "unicode/utf8"
+
+    "quickr/domain/suggest"
+)
+
+const (
+    // maxSuggestions caps the aliases offered for a mistyped one.
+    maxSuggestions = 5
+    // maxSuggestQuery is the longest alias worth looking for near matches of;
+    // the edit distance grows with the product of the two lengths.
+    maxSuggestQuery = 64
+)
+
+// Suggestion is an existing alias offered on the not-found page.
+type Suggestion struct {
+    Alias  string
+    URL    string
+    Clicks uint
+}
+
+// SuggestAliases lists the live aliases closest to one that does not exist,
+// secondary aliases included. Expired links are left out, and so are queries
+// longer than maxSuggestQuery runes.
+func (s *LinkService) SuggestAliases(alias string) ([]Suggestion, error) {
+    out := []Suggestion{}
+    if utf8.RuneCountInString(alias) > maxSuggestQuery { return out, nil }
+    aliases, err := s.repo.ListAliasCandidates(time.Now())
+    if err != nil { return nil, err }
+    candidates := make([]suggest.Candidate, len(aliases))
+    targets := map[string]string{}
+    for i, a := range aliases {
+        candidates[i] = suggest.Candidate{Alias: a.Alias, Clicks: a.Clicks}
+        targets[a.Alias] = a.URL
+    }
+    for _, c := range suggest.Closest(alias, candidates, maxSuggestions) {
+        out = append(out, Suggestion{Alias: c.Alias, URL: targets[c.Alias], Clicks: c.Clicks})
+    }
+    return out, nil
+}
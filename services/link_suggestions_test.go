@@ -0,0 +1,34 @@
+package services
+
+import (
+    "strings"
+    "testing"
+    "time"
+
+    "quickr/repositories"
+)
+
+func TestSuggestAliases(t *testing.T) {
+    calls := 0
+    repo := &fakeRepo{ ListAliasCandidatesFunc: func(now time.Time) ([]repositories.AliasCandidate, error) {
+        calls++
+        return []repositories.AliasCandidate{
+            {Alias: "vpn", URL: "https://vpn.example.com", Clicks: 3},
+            {Alias: "wiki", URL: "https://wiki.example.com"},
+            {Alias: "wireguard", URL: "https://vpn.example.com", Clicks: 3},
+        }, nil
+    } }
+    svc := NewLinkService(repo)
+
+    got, err := svc.SuggestAliases("vnp")
+    if err != nil || len(got) != 1 || got[0].Alias != "vpn" || got[0].URL != "https://vpn.example.com" {
+        t.Fatalf("expected only the vpn link, got %+v err=%v", got, err)
+    }
+    got, _ = svc.SuggestAliases("wirguard")
+    if len(got) != 1 || got[0].Alias != "wireguard" || got[0].URL != "https://vpn.example.com" || got[0].Clicks != 3 {
+        t.Fatalf("expected the secondary alias with its link's target, got %+v", got)
+    }
+    if got, err = svc.SuggestAliases(strings.Repeat("v", maxSuggestQuery+1)); err != nil || len(got) != 0 || calls != 2 {
+        t.Fatalf("expected an overlong alias answered without a lookup, got %+v err=%v calls=%d", got, err, calls)
+    }
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+    "context"
+    "log"
+    "time"
+
+    "quickr/models"
+)
+
+// SweepExpired stamps links whose expiry has passed. With freeAlias the link
+// is also soft-deleted so its alias can be claimed again. Each link is swept
+// in its own transaction, so it is either stamped (and trashed) or untouched.
+func (s *LinkService) SweepExpired(now time.Time, freeAlias bool) (int, error) {
+    links, err := s.repo.ListExpiredUnmarked(now)
+    if err != nil { return 0, err }
+    for i := range links {
+        if err := s.inTx(func(tx *LinkService) error { return tx.sweep(&links[i], now, freeAlias) }); err != nil { return i, err }
+    }
+    return len(links), nil
+}
+
+func (s *LinkService) sweep(link *models.Link, now time.Time, freeAlias bool) error {
+    if err := s.repo.MarkExpired(link.ID, now); err != nil { return err }
+    link.ExpiredAt = &now
+    s.forget(link.ID)
+    if !freeAlias { return nil }
+    if err := s.repo.Delete(link); err != nil { return err }
+    s.recordRevision(RevisionDelete, link, nil, sweeperActor)
+    return nil
+}
+
+// RunExpirySweeper calls SweepExpired every interval until ctx is cancelled.
+func (s *LinkService) RunExpirySweeper(ctx context.Context, interval time.Duration, freeAlias bool) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case now := <-ticker.C:
+            n, err := s.SweepExpired(now, freeAlias)
+            if err != nil {
+                log.Printf("[SWEEPER] expiry sweep failed: %v", err)
+            } else if n > 0 {
+                log.Printf("[SWEEPER] marked %d link(s) expired (free alias: %t)", n, freeAlias)
+            }
+        }
+    }
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+    "errors"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "quickr/models"
+    "quickr/repositories"
+)
+
+// clicksDuringSweep flushes a click on every link the sweep touches, between
+// the sweep's read and its write, and fails trashing link failID.
+type clicksDuringSweep struct {
+    repositories.LinkTransactor
+    failID uint
+}
+
+type failingDeleteRepo struct {
+    repositories.LinkRepository
+    failID uint
+}
+
+func (r failingDeleteRepo) Delete(link *models.Link) error {
+    if link.ID == r.failID { return errors.New("disk full") }
+    return r.LinkRepository.Delete(link)
+}
+
+func (c clicksDuringSweep) InLinkTx(fn func(repositories.LinkStores) error) error {
+    return c.LinkTransactor.InLinkTx(func(st repositories.LinkStores) error {
+        st.Links = failingDeleteRepo{st.Links, c.failID}
+        for _, id := range []uint{1, 2} { st.Links.IncrementClicks(id) }
+        return fn(st)
+    })
+}
+
+func TestSweepExpired_OneTransactionPerLink(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open(repositories.SQLiteDSN(filepath.Join(t.TempDir(), "links.db"))), &gorm.Config{Logger: logger.Discard})
+    if err != nil { t.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}, &models.LinkRevision{}); err != nil { t.Fatalf("migrate: %v", err) }
+    now := time.Now()
+    past := now.Add(-time.Hour)
+    for _, alias := range []string{"a", "b"} {
+        if err := db.Create(&models.Link{Alias: alias, URL: "https://example.com", CreatorName: "alice", ExpiresAt: &past}).Error; err != nil { t.Fatalf("seed: %v", err) }
+    }
+    svc := NewLinkService(repositories.NewGormLinkRepository(db),
+        WithRevisions(repositories.NewGormLinkRevisionRepository(db)),
+        WithTransactions(clicksDuringSweep{repositories.NewGormLinkTransactor(db), 2}))
+
+    if n, err := svc.SweepExpired(now, true); err == nil || n != 1 { t.Fatalf("expected the sweep to stop at b, got n=%d err=%v", n, err) }
+    var links []models.Link
+    db.Unscoped().Order("id").Find(&links)
+    if a := links[0]; a.ExpiredAt == nil || !a.DeletedAt.Valid || a.Clicks != 1 || a.Version != 1 {
+        t.Fatalf("expected a stamped and trashed with its flushed click kept, got %+v", a)
+    }
+    if b := links[1]; b.ExpiredAt != nil || b.DeletedAt.Valid || b.Clicks != 1 {
+        t.Fatalf("expected b's sweep rolled back after its trash failed, got %+v", b)
+    }
+}
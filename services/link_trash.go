@@ -0,0 +1,78 @@
+package services
+
+import (
+    "context"
+    "log"
+    "strconv"
+    "time"
+
+    "quickr/models"
+)
+
+// trashDeleterFallback names the deleter of links trashed without a user, i.e. by the expiry sweeper
+const trashDeleterFallback = "system"
+
+// ListTrash returns soft-deleted links, most recently deleted first.
+func (s *LinkService) ListTrash() ([]models.Link, error) {
+    links, err := s.repo.ListDeleted()
+    return s.annotateAll(links), err
+}
+
+// RestoreLink takes a link out of the trash. It fails with ErrAliasExists
+// when a live link has claimed the alias in the meantime; secondary aliases
+// claimed meanwhile are dropped instead.
+func (s *LinkService) RestoreLink(id string, actor Actor) (*models.Link, error) {
+    return withTx(s, func(tx *LinkService) (*models.Link, error) { return tx.restore(id, actor) })
+}
+
+func (s *LinkService) restore(id string, actor Actor) (*models.Link, error) {
+    link, err := s.repo.FindDeletedByID(id)
+    if err != nil { return nil, ErrLinkNotFound }
+    if err := s.authorize(link, actor); err != nil { return nil, err }
+    if exists, err := s.repo.ExistsByAlias(link.Alias); err != nil { return nil, err } else if exists { return nil, ErrAliasExists }
+    if err := s.repo.Restore(link); err != nil { return nil, err }
+    if err := s.dropReusedAliases(link); err != nil { return nil, err }
+    s.forget(link.ID, append(aliasNames(link.Aliases), link.Alias)...)
+    link.Deleter, link.DeletedByName = nil, ""
+    s.recordRevision(RevisionRestore, nil, link, actor)
+    return s.annotate(link), nil
+}
+
+// dropReusedAliases removes the secondary aliases that another link took
+// while link was in the trash.
+func (s *LinkService) dropReusedAliases(link *models.Link) error {
+    if s.linkAliases == nil { return nil }
+    kept := []models.LinkAlias{}
+    for _, a := range link.Aliases {
+        taken, err := s.repo.ExistsByAliasExceptID(a.Alias, strconv.FormatUint(uint64(link.ID), 10))
+        if err != nil { return err }
+        if !taken { kept = append(kept, a); continue }
+        if err := s.linkAliases.Remove(link.ID, a.Alias); err != nil { return err }
+    }
+    link.Aliases = kept
+    return nil
+}
+
+// PurgeTrash hard-deletes links that have been in the trash longer than retention.
+func (s *LinkService) PurgeTrash(now time.Time, retention time.Duration) (int64, error) {
+    return s.repo.PurgeDeletedBefore(now.Add(-retention))
+}
+
+// RunTrashPurger calls PurgeTrash every interval until ctx is cancelled.
+func (s *LinkService) RunTrashPurger(ctx context.Context, interval, retention time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case now := <-ticker.C:
+            n, err := s.PurgeTrash(now, retention)
+            if err != nil {
+                log.Printf("[TRASH] purge failed: %v", err)
+            } else if n > 0 {
+                log.Printf("[TRASH] purged %d link(s) deleted more than %s ago", n, retention)
+            }
+        }
+    }
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "gorm.io/gorm"
+    "quickr/models"
+)
+
+func TestDeleteLink_RecordsDeleter(t *testing.T) {
+    ownerID := uint(1)
+    var deleted *models.Link
+    repo := &fakeRepo{
+        FindByIDFunc: func(id string) (*models.Link, error) { return &models.Link{ID: 4, CreatedBy: &ownerID}, nil },
+        DeleteFunc:   func(link *models.Link) error { deleted = link; return nil },
+    }
+    if _, err := NewLinkService(repo).DeleteLink("4", Actor{UserID: &ownerID}); err != nil { t.Fatalf("unexpected error: %v", err) }
+    if deleted.DeletedBy == nil || *deleted.DeletedBy != ownerID { t.Fatalf("expected DeletedBy to be recorded, got %v", deleted.DeletedBy) }
+}
+
+func TestListTrash(t *testing.T) {
+    deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+    repo := &fakeRepo{ ListDeletedFunc: func() ([]models.Link, error) {
+        return []models.Link{
+            {Alias: "a", DeletedAt: deletedAt, DeletedBy: new(uint), Deleter: &models.User{Email: "bob@example.com"}},
+            {Alias: "b", DeletedAt: deletedAt},
+        }, nil
+    } }
+    links, err := NewLinkService(repo).ListTrash()
+    if err != nil || links[0].DeletedByName != "bob@example.com" || links[1].DeletedByName != "system" {
+        t.Fatalf("unexpected trash: %+v err=%v", links, err)
+    }
+}
+
+func TestRestoreLink(t *testing.T) {
+    ownerID, strangerID := uint(1), uint(2)
+    aliasTaken := false
+    restored := false
+    revs := &fakeRevisionRepo{}
+    repo := &fakeRepo{
+        FindDeletedByIDFunc: func(id string) (*models.Link, error) {
+            if id != "4" { return nil, errors.New("not in trash") }
+            return &models.Link{ID: 4, Alias: "standup", CreatedBy: &ownerID, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}, nil
+        },
+        ExistsByAliasFunc: func(alias string) (bool, error) { return aliasTaken, nil },
+        RestoreFunc:       func(link *models.Link) error { restored = true; link.DeletedAt = gorm.DeletedAt{}; return nil },
+    }
+    svc := NewLinkService(repo, WithRevisions(revs))
+    owner := Actor{UserID: &ownerID, Name: "owner"}
+
+    if _, err := svc.RestoreLink("5", owner); !errors.Is(err, ErrLinkNotFound) { t.Fatalf("expected ErrLinkNotFound, got %v", err) }
+    if _, err := svc.RestoreLink("4", Actor{UserID: &strangerID}); !errors.Is(err, ErrForbidden) { t.Fatalf("expected ErrForbidden, got %v", err) }
+    aliasTaken = true
+    if _, err := svc.RestoreLink("4", owner); !errors.Is(err, ErrAliasExists) || restored { t.Fatalf("expected ErrAliasExists without restoring, got %v", err) }
+    aliasTaken = false
+    link, err := svc.RestoreLink("4", owner)
+    if err != nil || !restored || link.DeletedByName != "" { t.Fatalf("unexpected restore: %+v err=%v", link, err) }
+    if len(revs.revs) != 1 || revs.revs[0].Action != RevisionRestore { t.Fatalf("expected a restore revision, got %+v", revs.revs) }
+}
+
+func TestPurgeTrash(t *testing.T) {
+    now := time.Now()
+    var cutoff time.Time
+    repo := &fakeRepo{ PurgeDeletedBeforeFunc: func(c time.Time) (int64, error) { cutoff = c; return 3, nil } }
+    n, err := NewLinkService(repo).PurgeTrash(now, 30*24*time.Hour)
+    if err != nil || n != 3 || !cutoff.Equal(now.Add(-30*24*time.Hour)) { t.Fatalf("unexpected purge n=%d cutoff=%v err=%v", n, cutoff, err) }
+}
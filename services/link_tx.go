@@ -0,0 +1,60 @@
+package services
+
+import "quickr/repositories"
+
+// WithTransactions applies each multi-step change (create, edit, rename,
+// restore, alias changes) in a single transaction, so the alias checks and
+// the writes they guard cannot interleave with another request's.
+func WithTransactions(tx repositories.LinkTransactor) LinkServiceOption {
+    return func(s *LinkService) { s.tx = tx }
+}
+
+// inTx runs fn on a copy of s whose link stores share one transaction.
+// Collaborators s was built without stay off. Cache invalidations are held
+// back until the commit so no reader caches a state that is rolled back.
+// Without a transactor fn runs on s itself.
+func (s *LinkService) inTx(fn func(tx *LinkService) error) error {
+    if s.tx == nil { return fn(s) }
+    var pending *pendingInvalidations
+    err := s.tx.InLinkTx(func(st repositories.LinkStores) error {
+        tx := *s
+        tx.tx = nil
+        tx.repo = st.Links
+        if s.revisions != nil { tx.revisions = st.Revisions }
+        if s.linkAliases != nil { tx.linkAliases = st.Aliases }
+        if s.aliasHistory != nil { tx.aliasHistory = st.AliasHistory }
+        if s.aliasCache != nil {
+            pending = &pendingInvalidations{AliasCache: s.aliasCache}
+            tx.aliasCache = pending
+        }
+        return fn(&tx)
+    })
+    if err == nil && pending != nil { pending.apply() }
+    return err
+}
+
+// withTx is inTx for changes that return a result.
+func withTx[T any](s *LinkService, fn func(tx *LinkService) (T, error)) (T, error) {
+    var out T
+    err := s.inTx(func(tx *LinkService) error {
+        var err error
+        out, err = fn(tx)
+        return err
+    })
+    return out, err
+}
+
+// pendingInvalidations records what a transaction would invalidate.
+type pendingInvalidations struct {
+    AliasCache
+    ids     []uint
+    aliases []string
+}
+
+func (p *pendingInvalidations) InvalidateLink(id uint)        { p.ids = append(p.ids, id) }
+func (p *pendingInvalidations) Invalidate(aliases ...string) { p.aliases = append(p.aliases, aliases...) }
+
+func (p *pendingInvalidations) apply() {
+    for _, id := range p.ids { p.AliasCache.InvalidateLink(id) }
+    p.AliasCache.Invalidate(p.aliases...)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+    "crypto/rand"
+    "encoding/base64"
+    "errors"
+    "log"
+    "time"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+const (
+    // sessionTouchInterval limits LastSeenAt writes to one a minute per session
+    sessionTouchInterval = time.Minute
+    // maxUserAgent caps the user agent kept for the devices list
+    maxUserAgent = 512
+)
+
+// SessionService keeps signed-in browsers server side. It is the store
+// behind session.Manager and lists and revokes a user's devices.
+type SessionService struct {
+    sessions repositories.SessionRepository
+    now      func() time.Time
+}
+
+func NewSessionService(sessions repositories.SessionRepository) *SessionService {
+    return &SessionService{sessions: sessions, now: time.Now}
+}
+
+// Create stores a session for userID and returns its ID. Expired sessions of
+// all users are dropped on the way.
+func (s *SessionService) Create(userID uint, userAgent, ip string, expiresAt time.Time) (string, error) {
+    now := s.now()
+    if err := s.sessions.DeleteExpired(now); err != nil { log.Printf("[SESSIONS] failed to drop expired sessions: %v", err) }
+    b := make([]byte, 32)
+    if _, err := rand.Read(b); err != nil { return "", err }
+    if len(userAgent) > maxUserAgent { userAgent = userAgent[:maxUserAgent] }
+    sess := &models.Session{ID: base64.RawURLEncoding.EncodeToString(b), UserID: userID, UserAgent: userAgent, IP: ip, LastSeenAt: now, ExpiresAt: expiresAt}
+    if err := s.sessions.Create(sess); err != nil { return "", err }
+    return sess.ID, nil
+}
+
+// Validate reports whether session id is still stored and unexpired, and
+// records that it was seen.
+func (s *SessionService) Validate(id string) error {
+    if id == "" { return ErrSessionNotFound }
+    sess, err := s.sessions.FindByID(id)
+    if err != nil { return ErrSessionNotFound }
+    now := s.now()
+    if !sess.ExpiresAt.After(now) { return ErrSessionNotFound }
+    if now.Sub(sess.LastSeenAt) >= sessionTouchInterval {
+        if err := s.sessions.TouchLastSeen(id, now); err != nil { log.Printf("[SESSIONS] failed to record use of a session: %v", err) }
+    }
+    return nil
+}
+
+// Revoke deletes session id whoever it belongs to; used on logout.
+func (s *SessionService) Revoke(id string) error { return s.sessions.DeleteByID(id) }
+
+// ListSessions returns userID's unexpired sessions, most recently seen first.
+func (s *SessionService) ListSessions(userID uint) ([]models.Session, error) {
+    return s.sessions.ListByUser(userID, s.now())
+}
+
+// RevokeSession signs one of userID's devices out.
+func (s *SessionService) RevokeSession(userID uint, id string) error {
+    if err := s.sessions.Delete(userID, id); err != nil {
+        if errors.Is(err, repositories.ErrNotFound) { return ErrSessionNotFound }
+        return err
+    }
+    return nil
+}
+
+// RevokeAllSessions signs every device of userID out, this one included.
+func (s *SessionService) RevokeAllSessions(userID uint) error { return s.sessions.DeleteByUser(userID) }
@@ -0,0 +1,103 @@
+package services
+
+import (
+    "testing"
+    "time"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+// fakeSessionRepo keeps sessions in memory.
+type fakeSessionRepo struct {
+    sessions []models.Session
+    touches  int
+}
+
+func (f *fakeSessionRepo) Create(s *models.Session) error { f.sessions = append(f.sessions, *s); return nil }
+
+func (f *fakeSessionRepo) FindByID(id string) (*models.Session, error) {
+    for _, s := range f.sessions {
+        if s.ID == id { return &s, nil }
+    }
+    return nil, repositories.ErrNotFound
+}
+
+func (f *fakeSessionRepo) ListByUser(userID uint, now time.Time) ([]models.Session, error) {
+    out := []models.Session{}
+    for _, s := range f.sessions {
+        if s.UserID == userID && s.ExpiresAt.After(now) { out = append(out, s) }
+    }
+    return out, nil
+}
+
+func (f *fakeSessionRepo) Delete(userID uint, id string) error {
+    for i, s := range f.sessions {
+        if s.ID == id && s.UserID == userID {
+            f.sessions = append(f.sessions[:i], f.sessions[i+1:]...)
+            return nil
+        }
+    }
+    return repositories.ErrNotFound
+}
+
+func (f *fakeSessionRepo) DeleteByID(id string) error {
+    f.keep(func(s models.Session) bool { return s.ID != id })
+    return nil
+}
+
+func (f *fakeSessionRepo) DeleteByUser(userID uint) error {
+    f.keep(func(s models.Session) bool { return s.UserID != userID })
+    return nil
+}
+
+func (f *fakeSessionRepo) DeleteExpired(now time.Time) error {
+    f.keep(func(s models.Session) bool { return s.ExpiresAt.After(now) })
+    return nil
+}
+
+func (f *fakeSessionRepo) TouchLastSeen(id string, at time.Time) error {
+    f.touches++
+    for i := range f.sessions {
+        if f.sessions[i].ID == id { f.sessions[i].LastSeenAt = at }
+    }
+    return nil
+}
+
+func (f *fakeSessionRepo) keep(ok func(models.Session) bool) {
+    out := f.sessions[:0]
+    for _, s := range f.sessions {
+        if ok(s) { out = append(out, s) }
+    }
+    f.sessions = out
+}
+
+func TestSessionService(t *testing.T) {
+    repo := &fakeSessionRepo{}
+    svc := NewSessionService(repo)
+    now := time.Now()
+    svc.now = func() time.Time { return now }
+
+    laptop, err := svc.Create(1, "Firefox", "10.0.0.1", now.Add(time.Hour))
+    if err != nil || laptop == "" { t.Fatalf("create: %q %v", laptop, err) }
+    phone, _ := svc.Create(1, "Safari", "10.0.0.2", now.Add(2*time.Hour))
+    other, _ := svc.Create(2, "Chrome", "10.0.0.3", now.Add(time.Hour))
+    if laptop == phone { t.Fatalf("expected distinct session IDs") }
+
+    // last seen is written at most once a minute
+    if err := svc.Validate(laptop); err != nil || repo.touches != 0 { t.Fatalf("expected a fresh session valid without a write, got %v %d", err, repo.touches) }
+    now = now.Add(2 * time.Minute)
+    if err := svc.Validate(laptop); err != nil || repo.touches != 1 { t.Fatalf("expected last seen recorded, got %v %d", err, repo.touches) }
+    if err := svc.Validate("nope"); err != ErrSessionNotFound { t.Fatalf("expected ErrSessionNotFound, got %v", err) }
+
+    if err := svc.RevokeSession(1, other); err != ErrSessionNotFound { t.Fatalf("expected another user's session to be out of reach, got %v", err) }
+    if err := svc.RevokeSession(1, phone); err != nil { t.Fatalf("revoke: %v", err) }
+    if err := svc.Validate(phone); err != ErrSessionNotFound { t.Fatalf("expected the revoked session refused, got %v", err) }
+
+    // expired sessions are refused, unlisted and dropped on the next sign-in
+    now = now.Add(time.Hour)
+    if err := svc.Validate(laptop); err != ErrSessionNotFound { t.Fatalf("expected the expired session refused, got %v", err) }
+    if list, _ := svc.ListSessions(1); len(list) != 0 { t.Fatalf("expected no live sessions, got %+v", list) }
+    svc.Create(1, "Firefox", "10.0.0.1", now.Add(time.Hour))
+    if len(repo.sessions) != 1 { t.Fatalf("expected expired sessions dropped, got %+v", repo.sessions) }
+}
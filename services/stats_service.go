@@ -1,18 +1,27 @@
 package services
 
 import (
-    "sort"
     "time"
 
+    "quickr/domain/trending"
     "quickr/models"
+    "quickr/repositories"
 )
 
 type StatsService struct {
-    links *LinkService
+    links  *LinkService
+    clicks repositories.ClickEventRepository
+    ipSalt string
+    clickBuffer *ClickBuffer
 }
 
-func NewStatsService(linkService *LinkService) *StatsService {
-    return &StatsService{links: linkService}
+// StatsServiceOption plugs an optional collaborator into a StatsService.
+type StatsServiceOption func(*StatsService)
+
+func NewStatsService(linkService *LinkService, opts ...StatsServiceOption) *StatsService {
+    s := &StatsService{links: linkService}
+    for _, opt := range opts { opt(s) }
+    return s
 }
 
 type StatsOverview struct {
@@ -23,66 +32,115 @@ type StatsOverview struct {
     RecentLinks []models.Link
 }
 
+// ComputeOverview leaves the counting and ranking to the repository, so it
+// never loads more than the ten links it shows.
 func (s *StatsService) ComputeOverview() (StatsOverview, error) {
-    links, err := s.links.ListLinks()
-    if err != nil {
-        return StatsOverview{}, err
-    }
-    var totalClicks int64
-    creators := map[string]struct{}{}
-    for _, l := range links {
-        totalClicks += int64(l.Clicks)
-        creators[l.CreatorName] = struct{}{}
-    }
-
-    topLinks := append([]models.Link(nil), links...)
-    sort.Slice(topLinks, func(i, j int) bool { return topLinks[i].Clicks > topLinks[j].Clicks })
-    if len(topLinks) > 5 { topLinks = topLinks[:5] }
-
-    recentLinks := append([]models.Link(nil), links...)
-    sort.Slice(recentLinks, func(i, j int) bool { return recentLinks[i].CreatedAt.After(recentLinks[j].CreatedAt) })
-    if len(recentLinks) > 5 { recentLinks = recentLinks[:5] }
+    repo := s.links.repo
+    var ov StatsOverview
+    var err error
+    if ov.TotalLinks, err = repo.Count(); err != nil { return StatsOverview{}, err }
+    if ov.TotalClicks, err = repo.SumClicks(); err != nil { return StatsOverview{}, err }
+    if ov.ActiveUsers, err = repo.CountCreators(); err != nil { return StatsOverview{}, err }
+    top, err := repo.TopByClicks(5)
+    if err != nil { return StatsOverview{}, err }
+    recent, err := repo.ListCreatedSince(time.Time{}, 5)
+    if err != nil { return StatsOverview{}, err }
+    ov.TopLinks, ov.RecentLinks = s.links.annotateAll(top), s.links.annotateAll(recent)
+    return ov, nil
+}
 
-    return StatsOverview{
-        TotalLinks:  int64(len(links)),
-        TotalClicks: totalClicks,
-        ActiveUsers: int64(len(creators)),
-        TopLinks:    topLinks,
-        RecentLinks: recentLinks,
-    }, nil
+// HotLink is a link ranked by its activity inside a window: WindowClicks
+// counts its clicks there and Score is its trending score, if ranked by one.
+type HotLink struct {
+    models.Link
+    WindowClicks int64
+    Score        float64
 }
 
 type HotStats struct {
-    Recent  []models.Link
-    Top7d   []models.Link
-    Top30d  []models.Link
-    TopAll  []models.Link
+    Recent   []models.Link
+    Trending []HotLink
+    Top7d    []HotLink
+    Top30d   []HotLink
+    TopAll   []models.Link
 }
 
+const (
+    hotListSize = 20
+    // trendingWindow is the history a trending score compares recent clicks
+    // against; trendingHalfLife is how fast a burst of clicks stops counting.
+    trendingWindow   = 7 * 24 * time.Hour
+    trendingHalfLife = 6 * time.Hour
+    // trendingMinScore hides links that are barely ahead of their usual pace
+    trendingMinScore = 3
+)
+
+// ComputeHot lists links created in the last 24 hours, the links trending
+// right now, the most clicked links of the last 7 and 30 days, and the most
+// clicked links of all time.
 func (s *StatsService) ComputeHot() (HotStats, error) {
-    links, err := s.links.ListLinks()
-    if err != nil {
-        return HotStats{}, err
-    }
-    var recent []models.Link
-    var top7d []models.Link
-    var top30d []models.Link
-    cut24 := time.Now().Add(-24 * time.Hour)
-    cut7d := time.Now().Add(-7 * 24 * time.Hour)
-    cut30d := time.Now().Add(-30 * 24 * time.Hour)
-    for _, l := range links {
-        if l.CreatedAt.After(cut24) { recent = append(recent, l) }
-        if l.CreatedAt.After(cut7d) { top7d = append(top7d, l) }
-        if l.CreatedAt.After(cut30d) { top30d = append(top30d, l) }
+    return s.computeHot(time.Now())
+}
+
+func (s *StatsService) computeHot(now time.Time) (HotStats, error) {
+    repo := s.links.repo
+    recent, err := repo.ListCreatedSince(now.Add(-24*time.Hour), 0)
+    if err != nil { return HotStats{}, err }
+    topAll, err := repo.TopByClicks(hotListSize)
+    if err != nil { return HotStats{}, err }
+    hot := HotStats{Recent: s.links.annotateAll(recent), Trending: []HotLink{}, Top7d: []HotLink{}, Top30d: []HotLink{}, TopAll: s.links.annotateAll(topAll)}
+    if s.clicks != nil {
+        if hot.Top7d, err = s.topSince(now.Add(-7*24*time.Hour)); err != nil { return HotStats{}, err }
+        if hot.Top30d, err = s.topSince(now.Add(-30*24*time.Hour)); err != nil { return HotStats{}, err }
+        if hot.Trending, err = s.trending(now); err != nil { return HotStats{}, err }
     }
-    sort.Slice(top7d, func(i, j int) bool { return top7d[i].Clicks > top7d[j].Clicks })
-    if len(top7d) > 20 { top7d = top7d[:20] }
-    sort.Slice(top30d, func(i, j int) bool { return top30d[i].Clicks > top30d[j].Clicks })
-    if len(top30d) > 20 { top30d = top30d[:20] }
-    topAll := append([]models.Link(nil), links...)
-    sort.Slice(topAll, func(i, j int) bool { return topAll[i].Clicks > topAll[j].Clicks })
-    if len(topAll) > 20 { topAll = topAll[:20] }
-    return HotStats{Recent: recent, Top7d: top7d, Top30d: top30d, TopAll: topAll}, nil
+    return hot, nil
 }
 
+// linksByID loads the live links among ids, keyed by id.
+func (s *StatsService) linksByID(ids []uint) (map[uint]models.Link, error) {
+    links, err := s.links.repo.FindByIDs(ids)
+    if err != nil { return nil, err }
+    byID := make(map[uint]models.Link, len(links))
+    for _, l := range s.links.annotateAll(links) { byID[l.ID] = l }
+    return byID, nil
+}
 
+// topSince ranks links by their clicks since the given time.
+func (s *StatsService) topSince(since time.Time) ([]HotLink, error) {
+    counts, err := s.clicks.TopLinksSince(since, hotListSize)
+    if err != nil { return nil, err }
+    ids := make([]uint, len(counts))
+    for i, c := range counts { ids[i] = c.LinkID }
+    byID, err := s.linksByID(ids)
+    if err != nil { return nil, err }
+    top := []HotLink{}
+    for _, c := range counts {
+        if l, ok := byID[c.LinkID]; ok { top = append(top, HotLink{Link: l, WindowClicks: c.Clicks}) }
+    }
+    return top, nil
+}
+
+// trending ranks links by trending score; WindowClicks holds their clicks of the last 24 hours.
+func (s *StatsService) trending(now time.Time) ([]HotLink, error) {
+    rows, err := s.clicks.CountByLinkAndHour(now.Add(-trendingWindow))
+    if err != nil { return nil, err }
+    histories := map[uint][]trending.Bucket{}
+    last24 := map[uint]int64{}
+    for _, r := range rows {
+        start, err := time.Parse("2006-01-02 15:00", r.Bucket)
+        if err != nil { continue }
+        histories[r.LinkID] = append(histories[r.LinkID], trending.Bucket{Start: start, Clicks: r.Clicks})
+        if now.Sub(start) < 24*time.Hour { last24[r.LinkID] += r.Clicks }
+    }
+    ranked := trending.Rank(histories, now, trendingWindow, trendingHalfLife, trendingMinScore, hotListSize)
+    ids := make([]uint, len(ranked))
+    for i, r := range ranked { ids[i] = r.LinkID }
+    byID, err := s.linksByID(ids)
+    if err != nil { return nil, err }
+    out := []HotLink{}
+    for _, r := range ranked {
+        if l, ok := byID[r.LinkID]; ok { out = append(out, HotLink{Link: l, WindowClicks: last24[r.LinkID], Score: r.Score}) }
+    }
+    return out, nil
+}
@@ -1,23 +1,64 @@
 package services
 
 import (
+    "sort"
     "testing"
     "time"
 
     "quickr/models"
 )
 
-func TestStatsService_ComputeOverview(t *testing.T) {
-    now := time.Now()
-    repo := &fakeRepo{
-        ListAllFunc: func() ([]models.Link, error) {
-            return []models.Link{
-                {Alias: "a", Clicks: 10, CreatorName: "u1", CreatedAt: now.Add(-time.Hour)},
-                {Alias: "b", Clicks: 5, CreatorName: "u2", CreatedAt: now.Add(-2 * time.Hour)},
-                {Alias: "c", Clicks: 20, CreatorName: "u1", CreatedAt: now.Add(-30 * time.Minute)},
-            }, nil
+// aggregateRepo answers the stats aggregates from links the way the SQL
+// queries do. ListAll panics, so a test fails if stats loads every link.
+func aggregateRepo(links []models.Link) *fakeRepo {
+    sorted := func(less func(a, b models.Link) bool) []models.Link {
+        out := append([]models.Link(nil), links...)
+        sort.SliceStable(out, func(i, j int) bool { return less(out[i], out[j]) })
+        return out
+    }
+    limited := func(l []models.Link, limit int) []models.Link {
+        if limit > 0 && len(l) > limit { return l[:limit] }
+        return l
+    }
+    return &fakeRepo{
+        CountFunc: func() (int64, error) { return int64(len(links)), nil },
+        SumClicksFunc: func() (int64, error) {
+            var sum int64
+            for _, l := range links { sum += int64(l.Clicks) }
+            return sum, nil
+        },
+        CountCreatorsFunc: func() (int64, error) {
+            names := map[string]bool{}
+            for _, l := range links { names[l.CreatorName] = true }
+            return int64(len(names)), nil
+        },
+        TopByClicksFunc: func(limit int) ([]models.Link, error) {
+            return limited(sorted(func(a, b models.Link) bool { return a.Clicks > b.Clicks }), limit), nil
+        },
+        ListCreatedSinceFunc: func(since time.Time, limit int) ([]models.Link, error) {
+            out := []models.Link{}
+            for _, l := range sorted(func(a, b models.Link) bool { return a.CreatedAt.After(b.CreatedAt) }) {
+                if l.CreatedAt.After(since) { out = append(out, l) }
+            }
+            return limited(out, limit), nil
+        },
+        FindByIDsFunc: func(ids []uint) ([]models.Link, error) {
+            out := []models.Link{}
+            for _, l := range links {
+                for _, id := range ids { if l.ID == id { out = append(out, l) } }
+            }
+            return out, nil
         },
     }
+}
+
+func TestStatsService_ComputeOverview(t *testing.T) {
+    now := time.Now()
+    repo := aggregateRepo([]models.Link{
+        {Alias: "a", Clicks: 10, CreatorName: "u1", CreatedAt: now.Add(-time.Hour)},
+        {Alias: "b", Clicks: 5, CreatorName: "u2", CreatedAt: now.Add(-2 * time.Hour)},
+        {Alias: "c", Clicks: 20, CreatorName: "u1", CreatedAt: now.Add(-30 * time.Minute)},
+    })
     ls := NewLinkService(repo)
     ss := NewStatsService(ls)
 
@@ -34,37 +75,70 @@ func TestStatsService_ComputeOverview(t *testing.T) {
     }
 }
 
-func TestStatsService_ComputeHot(t *testing.T) {
-    now := time.Now()
-    repo := &fakeRepo{
-        ListAllFunc: func() ([]models.Link, error) {
-            return []models.Link{
-                {Alias: "old", Clicks: 100, CreatedAt: now.Add(-40 * 24 * time.Hour)},
-                {Alias: "recent", Clicks: 1, CreatedAt: now.Add(-2 * time.Hour)},
-                {Alias: "d7a", Clicks: 10, CreatedAt: now.Add(-2 * 24 * time.Hour)},
-                {Alias: "d30a", Clicks: 5, CreatedAt: now.Add(-20 * 24 * time.Hour)},
-                {Alias: "topall", Clicks: 200, CreatedAt: now.Add(-100 * time.Hour)},
-            }, nil
-        },
+// clicksAt adds n clicks on linkID at each given time.
+func clicksAt(repo *fakeClickEventRepo, linkID uint, n int, times ...time.Time) {
+    for _, at := range times {
+        for i := 0; i < n; i++ { repo.events = append(repo.events, models.ClickEvent{LinkID: linkID, CreatedAt: at}) }
     }
-    ls := NewLinkService(repo)
-    ss := NewStatsService(ls)
+}
+
+// hourly returns one time per hour from fromHoursAgo down to toHoursAgo (exclusive) before now.
+func hourly(now time.Time, fromHoursAgo, toHoursAgo int) []time.Time {
+    var out []time.Time
+    for h := fromHoursAgo; h > toHoursAgo; h-- { out = append(out, now.Add(-time.Duration(h)*time.Hour)) }
+    return out
+}
 
-    hot, err := ss.ComputeHot()
+func TestStatsService_ComputeHot(t *testing.T) {
+    now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+    repo := aggregateRepo([]models.Link{
+        {ID: 1, Alias: "old-but-busy", Clicks: 900, CreatedAt: now.Add(-400 * 24 * time.Hour)},
+        {ID: 2, Alias: "recent", Clicks: 1, CreatedAt: now.Add(-2 * time.Hour)},
+        {ID: 3, Alias: "last-month", Clicks: 300, CreatedAt: now.Add(-60 * 24 * time.Hour)},
+        {ID: 4, Alias: "spiking", Clicks: 60, CreatedAt: now.Add(-90 * 24 * time.Hour)},
+        {ID: 5, Alias: "topall", Clicks: 5000, CreatedAt: now.Add(-900 * 24 * time.Hour)},
+    })
+    clicks := &fakeClickEventRepo{}
+    // old-but-busy: steady 2 clicks an hour all week
+    clicksAt(clicks, 1, 2, hourly(now, 7*24-1, -1)...)
+    // last-month: a heavy week three weeks ago, quiet since
+    clicksAt(clicks, 3, 5, hourly(now, 21*24, 14*24)...)
+    // spiking: nothing, then 20 clicks an hour for the last three hours
+    clicksAt(clicks, 4, 20, hourly(now, 2, -1)...)
+    // topall: lifetime leader with no recent click events
+    ss := NewStatsService(NewLinkService(repo), WithClickEvents(clicks, ""))
+
+    hot, err := ss.computeHot(now)
     if err != nil { t.Fatalf("unexpected err: %v", err) }
 
-    if len(hot.Recent) == 0 || hot.Recent[0].Alias != "recent" {
+    if len(hot.Recent) != 1 || hot.Recent[0].Alias != "recent" {
         t.Fatalf("unexpected recent: %+v", hot.Recent)
     }
-    if len(hot.Top7d) == 0 || hot.Top7d[0].Alias != "topall" {
-        t.Fatalf("unexpected top7d: %+v", hot.Top7d)
+    if aliases := hotAliases(hot.Top7d); len(aliases) != 2 || aliases[0] != "old-but-busy" || aliases[1] != "spiking" {
+        t.Fatalf("expected 7-day ranking by clicks in the window, got %v", aliases)
+    }
+    if hot.Top7d[0].WindowClicks != 2*7*24 || hot.Top7d[0].Clicks != 900 {
+        t.Fatalf("expected window and lifetime clicks side by side, got %+v", hot.Top7d[0])
     }
-    if len(hot.Top30d) == 0 || hot.Top30d[0].Alias != "topall" {
-        t.Fatalf("unexpected top30d order: %+v", hot.Top30d)
+    if aliases := hotAliases(hot.Top30d); len(aliases) != 3 || aliases[0] != "last-month" {
+        t.Fatalf("expected last-month to lead the 30-day ranking, got %v", aliases)
+    }
+    if aliases := hotAliases(hot.Trending); len(aliases) != 1 || aliases[0] != "spiking" || hot.Trending[0].WindowClicks != 60 {
+        t.Fatalf("expected only the spiking link to trend, got %+v", hot.Trending)
     }
     if len(hot.TopAll) == 0 || hot.TopAll[0].Alias != "topall" {
         t.Fatalf("unexpected topall: %+v", hot.TopAll)
     }
 }
 
+func TestStatsService_ComputeHot_WithoutClickLog(t *testing.T) {
+    repo := aggregateRepo([]models.Link{{ID: 1, Alias: "a", Clicks: 3}})
+    hot, err := NewStatsService(NewLinkService(repo)).ComputeHot()
+    if err != nil || len(hot.Top7d) != 0 || len(hot.Trending) != 0 || len(hot.TopAll) != 1 { t.Fatalf("unexpected hot stats %+v err=%v", hot, err) }
+}
 
+func hotAliases(links []HotLink) []string {
+    out := []string{}
+    for _, l := range links { out = append(out, l.Alias) }
+    return out
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+    "fmt"
+    "path/filepath"
+    "sort"
+    "testing"
+    "time"
+
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "quickr/models"
+    "quickr/repositories"
+)
+
+// openStatsDB opens a fresh SQLite database holding n links, one in ten of
+// them trashed, spread over 100 creators and the last n hours.
+func openStatsDB(tb testing.TB, n int) *gorm.DB {
+    tb.Helper()
+    db, err := gorm.Open(sqlite.Open(filepath.Join(tb.TempDir(), "stats.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil { tb.Fatalf("open db: %v", err) }
+    if err := db.AutoMigrate(&models.User{}, &models.Link{}, &models.LinkAlias{}); err != nil { tb.Fatalf("migrate: %v", err) }
+    now := time.Now()
+    links := make([]models.Link, n)
+    for i := range links {
+        links[i] = models.Link{
+            Alias:       fmt.Sprintf("link-%d", i),
+            URL:         "https://example.com",
+            Clicks:      uint((i * 7919) % 10007),
+            CreatorName: fmt.Sprintf("user-%d", i%100),
+            CreatedAt:   now.Add(-time.Duration(i) * time.Hour),
+        }
+    }
+    if err := db.CreateInBatches(links, 500).Error; err != nil { tb.Fatalf("seed: %v", err) }
+    if err := db.Where("id % 10 = 0").Delete(&models.Link{}).Error; err != nil { tb.Fatalf("trash: %v", err) }
+    return db
+}
+
+func TestStatsService_ComputeOverview_SQLite(t *testing.T) {
+    db := openStatsDB(t, 200)
+    ss := NewStatsService(NewLinkService(repositories.NewGormLinkRepository(db)))
+
+    ov, err := ss.ComputeOverview()
+    if err != nil { t.Fatalf("unexpected err: %v", err) }
+    want, err := legacyOverview(ss)
+    if err != nil { t.Fatalf("unexpected err: %v", err) }
+    if ov.TotalLinks != 180 || ov.TotalLinks != want.TotalLinks { t.Fatalf("expected 180 live links, got %d", ov.TotalLinks) }
+    if ov.TotalClicks != want.TotalClicks { t.Fatalf("expected %d clicks, got %d", want.TotalClicks, ov.TotalClicks) }
+    if ov.ActiveUsers != 90 || ov.ActiveUsers != want.ActiveUsers { t.Fatalf("expected 90 creators, got %d", ov.ActiveUsers) }
+    for i := range want.TopLinks {
+        if ov.TopLinks[i].Clicks != want.TopLinks[i].Clicks { t.Fatalf("top links differ: %v vs %v", ov.TopLinks, want.TopLinks) }
+        if ov.RecentLinks[i].ID != want.RecentLinks[i].ID { t.Fatalf("recent links differ: %v vs %v", ov.RecentLinks, want.RecentLinks) }
+    }
+}
+
+// legacyOverview computes the overview the way ComputeOverview used to, from
+// every link loaded into memory. It is the baseline for the benchmarks.
+func legacyOverview(s *StatsService) (StatsOverview, error) {
+    links, err := s.links.ListLinks()
+    if err != nil { return StatsOverview{}, err }
+    var totalClicks int64
+    creators := map[string]struct{}{}
+    for _, l := range links {
+        totalClicks += int64(l.Clicks)
+        creators[l.CreatorName] = struct{}{}
+    }
+    top := append([]models.Link(nil), links...)
+    sort.SliceStable(top, func(i, j int) bool { return top[i].Clicks > top[j].Clicks })
+    if len(top) > 5 { top = top[:5] }
+    recent := links
+    if len(recent) > 5 { recent = recent[:5] }
+    return StatsOverview{TotalLinks: int64(len(links)), TotalClicks: totalClicks, ActiveUsers: int64(len(creators)), TopLinks: top, RecentLinks: recent}, nil
+}
+
+// Compare with: go test ./services -run '^$' -bench Overview
+func BenchmarkComputeOverview(b *testing.B) {
+    ss := NewStatsService(NewLinkService(repositories.NewGormLinkRepository(openStatsDB(b, 20000))))
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := ss.ComputeOverview(); err != nil { b.Fatal(err) }
+    }
+}
+
+func BenchmarkComputeOverview_LoadAll(b *testing.B) {
+    ss := NewStatsService(NewLinkService(repositories.NewGormLinkRepository(openStatsDB(b, 20000))))
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := legacyOverview(ss); err != nil { b.Fatal(err) }
+    }
+}
+
+func BenchmarkComputeHot(b *testing.B) {
+    ss := NewStatsService(NewLinkService(repositories.NewGormLinkRepository(openStatsDB(b, 20000))))
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := ss.ComputeHot(); err != nil { b.Fatal(err) }
+    }
+}
@@ -1,9 +1,18 @@
 package services
 
 import (
+    "errors"
+    "fmt"
+    "sort"
+    "time"
+
     "quickr/models"
+    "quickr/repositories"
 )
 
+// testAdmin may change any link
+var testAdmin = Actor{Name: "admin", Admin: true}
+
 // fakeRepo is a function-backed test double for repositories.LinkRepository.
 // Any method that is called without its corresponding Func set will panic,
 // which helps catch unexpected interactions in tests.
@@ -16,8 +25,22 @@ type fakeRepo struct {
     DeleteFunc                 func(link *models.Link) error
     SaveFunc                   func(link *models.Link) error
     ListAllFunc                func() ([]models.Link, error)
+    ListPageFunc               func(q repositories.LinkQuery) ([]models.Link, error)
     SearchFunc                 func(query string) ([]models.Link, error)
     IncrementClicksFunc        func(id uint) error
+    ListExpiredUnmarkedFunc    func(now time.Time) ([]models.Link, error)
+    ListDeletedFunc            func() ([]models.Link, error)
+    FindDeletedByIDFunc        func(id string) (*models.Link, error)
+    RestoreFunc                func(link *models.Link) error
+    PurgeDeletedBeforeFunc     func(cutoff time.Time) (int64, error)
+    CountFunc                  func() (int64, error)
+    SumClicksFunc              func() (int64, error)
+    CountCreatorsFunc          func() (int64, error)
+    TopByClicksFunc            func(limit int) ([]models.Link, error)
+    ListCreatedSinceFunc       func(since time.Time, limit int) ([]models.Link, error)
+    FindByIDsFunc              func(ids []uint) ([]models.Link, error)
+    MarkExpiredFunc            func(id uint, at time.Time) error
+    ListAliasCandidatesFunc    func(now time.Time) ([]repositories.AliasCandidate, error)
 }
 
 func (f *fakeRepo) Create(link *models.Link) error {
@@ -60,6 +83,11 @@ func (f *fakeRepo) ListAll() ([]models.Link, error) {
     return f.ListAllFunc()
 }
 
+func (f *fakeRepo) ListPage(q repositories.LinkQuery) ([]models.Link, error) {
+    if f.ListPageFunc == nil { panic("unexpected call to ListPage") }
+    return f.ListPageFunc(q)
+}
+
 func (f *fakeRepo) Search(query string) ([]models.Link, error) {
     if f.SearchFunc == nil { panic("unexpected call to Search") }
     return f.SearchFunc(query)
@@ -70,4 +98,273 @@ func (f *fakeRepo) IncrementClicks(id uint) error {
     return f.IncrementClicksFunc(id)
 }
 
+func (f *fakeRepo) ListExpiredUnmarked(now time.Time) ([]models.Link, error) {
+    if f.ListExpiredUnmarkedFunc == nil { panic("unexpected call to ListExpiredUnmarked") }
+    return f.ListExpiredUnmarkedFunc(now)
+}
+
+func (f *fakeRepo) MarkExpired(id uint, at time.Time) error {
+    if f.MarkExpiredFunc == nil { panic("unexpected call to MarkExpired") }
+    return f.MarkExpiredFunc(id, at)
+}
+
+func (f *fakeRepo) ListDeleted() ([]models.Link, error) {
+    if f.ListDeletedFunc == nil { panic("unexpected call to ListDeleted") }
+    return f.ListDeletedFunc()
+}
+
+func (f *fakeRepo) FindDeletedByID(id string) (*models.Link, error) {
+    if f.FindDeletedByIDFunc == nil { panic("unexpected call to FindDeletedByID") }
+    return f.FindDeletedByIDFunc(id)
+}
+
+func (f *fakeRepo) Restore(link *models.Link) error {
+    if f.RestoreFunc == nil { panic("unexpected call to Restore") }
+    return f.RestoreFunc(link)
+}
+
+func (f *fakeRepo) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+    if f.PurgeDeletedBeforeFunc == nil { panic("unexpected call to PurgeDeletedBefore") }
+    return f.PurgeDeletedBeforeFunc(cutoff)
+}
+
+func (f *fakeRepo) Count() (int64, error) {
+    if f.CountFunc == nil { panic("unexpected call to Count") }
+    return f.CountFunc()
+}
+
+func (f *fakeRepo) SumClicks() (int64, error) {
+    if f.SumClicksFunc == nil { panic("unexpected call to SumClicks") }
+    return f.SumClicksFunc()
+}
+
+func (f *fakeRepo) CountCreators() (int64, error) {
+    if f.CountCreatorsFunc == nil { panic("unexpected call to CountCreators") }
+    return f.CountCreatorsFunc()
+}
+
+func (f *fakeRepo) TopByClicks(limit int) ([]models.Link, error) {
+    if f.TopByClicksFunc == nil { panic("unexpected call to TopByClicks") }
+    return f.TopByClicksFunc(limit)
+}
+
+func (f *fakeRepo) ListCreatedSince(since time.Time, limit int) ([]models.Link, error) {
+    if f.ListCreatedSinceFunc == nil { panic("unexpected call to ListCreatedSince") }
+    return f.ListCreatedSinceFunc(since, limit)
+}
+
+func (f *fakeRepo) FindByIDs(ids []uint) ([]models.Link, error) {
+    if f.FindByIDsFunc == nil { panic("unexpected call to FindByIDs") }
+    return f.FindByIDsFunc(ids)
+}
+
+func (f *fakeRepo) ListAliasCandidates(now time.Time) ([]repositories.AliasCandidate, error) {
+    if f.ListAliasCandidatesFunc == nil { panic("unexpected call to ListAliasCandidates") }
+    return f.ListAliasCandidatesFunc(now)
+}
+
+// fakeRevisionRepo keeps revisions in memory, newest last, and hands out IDs.
+type fakeRevisionRepo struct {
+    revs      []models.LinkRevision
+    CreateErr error
+}
+
+func (f *fakeRevisionRepo) Create(rev *models.LinkRevision) error {
+    if f.CreateErr != nil { return f.CreateErr }
+    rev.ID = uint(len(f.revs) + 1)
+    rev.CreatedAt = time.Now()
+    f.revs = append(f.revs, *rev)
+    return nil
+}
+
+func (f *fakeRevisionRepo) ListByLinkID(linkID string) ([]models.LinkRevision, error) {
+    var out []models.LinkRevision
+    for i := len(f.revs) - 1; i >= 0; i-- {
+        if fmt.Sprint(f.revs[i].LinkID) == linkID { out = append(out, f.revs[i]) }
+    }
+    return out, nil
+}
+
+func (f *fakeRevisionRepo) FindByID(id string) (*models.LinkRevision, error) {
+    for i := range f.revs {
+        if fmt.Sprint(f.revs[i].ID) == id { return &f.revs[i], nil }
+    }
+    return nil, errors.New("not found")
+}
+
+// fakeCoOwnerRepo stores co-owner pairs in memory.
+type fakeCoOwnerRepo struct{ pairs map[[2]uint]bool }
+
+func (f *fakeCoOwnerRepo) Add(linkID, userID uint) error {
+    if f.pairs == nil { f.pairs = map[[2]uint]bool{} }
+    f.pairs[[2]uint{linkID, userID}] = true
+    return nil
+}
+
+func (f *fakeCoOwnerRepo) Remove(linkID, userID uint) error {
+    delete(f.pairs, [2]uint{linkID, userID})
+    return nil
+}
+
+func (f *fakeCoOwnerRepo) ListUsers(linkID uint) ([]models.User, error) {
+    users := []models.User{}
+    for p := range f.pairs {
+        if p[0] == linkID { users = append(users, models.User{ID: p[1]}) }
+    }
+    return users, nil
+}
+
+func (f *fakeCoOwnerRepo) IsCoOwner(linkID, userID uint) (bool, error) { return f.pairs[[2]uint{linkID, userID}], nil }
+
+// fakeUserRepo looks users up by email from a fixed list.
+type fakeUserRepo struct{ users []models.User }
+
+func (f *fakeUserRepo) FindByEmail(email string) (*models.User, error) {
+    for i := range f.users {
+        if f.users[i].Email == email { return &f.users[i], nil }
+    }
+    return nil, errors.New("not found")
+}
+func (f *fakeUserRepo) Save(user *models.User) error   { return nil }
+func (f *fakeUserRepo) Create(user *models.User) error { return nil }
+func (f *fakeUserRepo) ListByEmails(emails []string) ([]models.User, error) { return nil, nil }
+func (f *fakeUserRepo) FindByID(id uint) (*models.User, error) {
+    for i := range f.users {
+        if f.users[i].ID == id { return &f.users[i], nil }
+    }
+    return nil, repositories.ErrNotFound
+}
+func (f *fakeUserRepo) List() ([]models.User, error) { return f.users, nil }
+func (f *fakeUserRepo) SetRole(id uint, role string) error {
+    u, err := f.FindByID(id)
+    if err != nil { return err }
+    u.Role = role
+    return nil
+}
+func (f *fakeUserRepo) SetDisabled(id uint, disabled bool) error {
+    u, err := f.FindByID(id)
+    if err != nil { return err }
+    u.Disabled = disabled
+    return nil
+}
+func (f *fakeUserRepo) BumpSessionGeneration(id uint) error {
+    u, err := f.FindByID(id)
+    if err != nil { return err }
+    u.SessionGeneration++
+    return nil
+}
+func (f *fakeUserRepo) Delete(id uint, reassignTo *uint) error {
+    for i := range f.users {
+        if f.users[i].ID == id { f.users = append(f.users[:i], f.users[i+1:]...); return nil }
+    }
+    return repositories.ErrNotFound
+}
+func (f *fakeUserRepo) HasAdmin() (bool, error) {
+    for _, u := range f.users {
+        if u.Role == "admin" { return true, nil }
+    }
+    return false, nil
+}
+
+// fakeAliasHistoryRepo keeps retired aliases in memory, newest last.
+type fakeAliasHistoryRepo struct{ entries []models.AliasHistory }
+
+func (f *fakeAliasHistoryRepo) Create(entry *models.AliasHistory) error {
+    entry.ID = uint(len(f.entries) + 1)
+    f.entries = append(f.entries, *entry)
+    return nil
+}
+
+func (f *fakeAliasHistoryRepo) FindActive(alias string, now time.Time) (*models.AliasHistory, error) {
+    for i := len(f.entries) - 1; i >= 0; i-- {
+        e := f.entries[i]
+        if e.Alias == alias && (e.ExpiresAt == nil || e.ExpiresAt.After(now)) { return &e, nil }
+    }
+    return nil, errors.New("not found")
+}
+
+func (f *fakeAliasHistoryRepo) DeleteByAlias(alias string) error {
+    kept := f.entries[:0]
+    for _, e := range f.entries {
+        if e.Alias != alias { kept = append(kept, e) }
+    }
+    f.entries = kept
+    return nil
+}
+
+// fakeLinkAliasRepo keeps secondary aliases in memory, oldest first.
+type fakeLinkAliasRepo struct{ aliases []models.LinkAlias }
+
+func (f *fakeLinkAliasRepo) Add(linkID uint, alias string) error {
+    f.aliases = append(f.aliases, models.LinkAlias{ID: uint(len(f.aliases) + 1), LinkID: linkID, Alias: alias})
+    return nil
+}
+
+func (f *fakeLinkAliasRepo) Remove(linkID uint, alias string) error {
+    kept := f.aliases[:0]
+    for _, a := range f.aliases {
+        if a.LinkID != linkID || a.Alias != alias { kept = append(kept, a) }
+    }
+    f.aliases = kept
+    return nil
+}
+
+func (f *fakeLinkAliasRepo) ListByLinkID(linkID uint) ([]models.LinkAlias, error) {
+    out := []models.LinkAlias{}
+    for _, a := range f.aliases {
+        if a.LinkID == linkID { out = append(out, a) }
+    }
+    return out, nil
+}
+
+// fakeClickEventRepo keeps click events in memory and buckets them like the SQL queries do.
+type fakeClickEventRepo struct{ events []models.ClickEvent }
+
+func (f *fakeClickEventRepo) Create(event *models.ClickEvent) error {
+    f.events = append(f.events, *event)
+    return nil
+}
+
+func (f *fakeClickEventRepo) CountByDay(linkID uint, since time.Time) ([]repositories.ClickBucket, error) {
+    return f.countBy("2006-01-02", linkID, since), nil
+}
+
+func (f *fakeClickEventRepo) CountByHour(linkID uint, since time.Time) ([]repositories.ClickBucket, error) {
+    return f.countBy("2006-01-02 15:00", linkID, since), nil
+}
 
+func (f *fakeClickEventRepo) countBy(layout string, linkID uint, since time.Time) []repositories.ClickBucket {
+    counts := map[string]int64{}
+    for _, e := range f.events {
+        if e.LinkID == linkID && !e.CreatedAt.Before(since) { counts[e.CreatedAt.UTC().Format(layout)]++ }
+    }
+    buckets := []repositories.ClickBucket{}
+    for b, n := range counts { buckets = append(buckets, repositories.ClickBucket{Bucket: b, Clicks: n}) }
+    sort.Slice(buckets, func(i, j int) bool { return buckets[i].Bucket < buckets[j].Bucket })
+    return buckets
+}
+
+func (f *fakeClickEventRepo) TopLinksSince(since time.Time, limit int) ([]repositories.LinkClicks, error) {
+    counts := map[uint]int64{}
+    for _, e := range f.events {
+        if !e.CreatedAt.Before(since) { counts[e.LinkID]++ }
+    }
+    top := []repositories.LinkClicks{}
+    for id, n := range counts { top = append(top, repositories.LinkClicks{LinkID: id, Clicks: n}) }
+    sort.Slice(top, func(i, j int) bool {
+        if top[i].Clicks != top[j].Clicks { return top[i].Clicks > top[j].Clicks }
+        return top[i].LinkID < top[j].LinkID
+    })
+    if len(top) > limit { top = top[:limit] }
+    return top, nil
+}
+
+func (f *fakeClickEventRepo) CountByLinkAndHour(since time.Time) ([]repositories.LinkClickBucket, error) {
+    counts := map[repositories.LinkClickBucket]int64{}
+    for _, e := range f.events {
+        if !e.CreatedAt.Before(since) { counts[repositories.LinkClickBucket{LinkID: e.LinkID, Bucket: e.CreatedAt.UTC().Format("2006-01-02 15:00")}]++ }
+    }
+    buckets := []repositories.LinkClickBucket{}
+    for b, n := range counts { b.Clicks = n; buckets = append(buckets, b) }
+    return buckets, nil
+}
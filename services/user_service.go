@@ -0,0 +1,143 @@
+package services
+
+import (
+    "errors"
+    "strings"
+
+    "quickr/models"
+    "quickr/repositories"
+)
+
+// User roles.
+const (
+    RoleAdmin = "admin"
+    RoleUser  = "user"
+)
+
+// User event actions, see models.UserEvent.
+const (
+    UserEventDisabled  = "disabled"
+    UserEventEnabled   = "enabled"
+    UserEventDeleted   = "deleted"
+    UserEventPromoted  = "promoted"
+    UserEventDemoted   = "demoted"
+    UserEventSignedOut = "signed out everywhere"
+)
+
+var (
+    ErrInvalidRole         = errors.New("role must be admin or user")
+    ErrLastAdmin           = repositories.ErrLastAdmin
+    ErrUserAlreadyDisabled = errors.New("user already revoked")
+    ErrUserNotDisabled     = errors.New("user is not disabled")
+    ErrReassignToSelf      = errors.New("links cannot be reassigned to the user being deleted")
+)
+
+// UserService manages accounts from the admin dashboard. Every change is
+// recorded as a models.UserEvent attributed to the acting admin.
+type UserService struct {
+    users   repositories.UserRepository
+    invites repositories.InvitationRepository
+    events  repositories.UserEventRepository
+}
+
+func NewUserService(users repositories.UserRepository, invites repositories.InvitationRepository, events repositories.UserEventRepository) *UserService {
+    return &UserService{users: users, invites: invites, events: events}
+}
+
+// ListUsers returns every account, admins first.
+func (s *UserService) ListUsers() ([]models.User, error) { return s.users.List() }
+
+// ListEvents returns the most recent user events first.
+func (s *UserService) ListEvents(limit int) ([]models.UserEvent, error) { return s.events.List(limit) }
+
+// SetRole promotes a user to admin or demotes an admin to user. Demoting the
+// last enabled admin fails with ErrLastAdmin.
+func (s *UserService) SetRole(id uint, role, actor string) (*models.User, error) {
+    if role != RoleAdmin && role != RoleUser { return nil, ErrInvalidRole }
+    u, err := s.findUser(id)
+    if err != nil || u.Role == role { return u, err }
+    if err := s.users.SetRole(id, role); err != nil { return nil, err }
+    u.Role = role
+    action := UserEventDemoted
+    if role == RoleAdmin { action = UserEventPromoted }
+    return u, s.record(u.Email, action, actor, "")
+}
+
+// DisableUser blocks a user from signing in and using their API tokens.
+// Disabling the last enabled admin fails with ErrLastAdmin.
+func (s *UserService) DisableUser(id uint, actor string) (*models.User, error) {
+    u, err := s.findUser(id)
+    if err != nil { return nil, err }
+    if u.Disabled { return nil, ErrUserAlreadyDisabled }
+    if err := s.users.SetDisabled(id, true); err != nil { return nil, err }
+    u.Disabled = true
+    return u, s.record(u.Email, UserEventDisabled, actor, "")
+}
+
+// EnableUser reinstates a disabled user, who can then request a magic link
+// again without a new invitation.
+func (s *UserService) EnableUser(id uint, actor string) (*models.User, error) {
+    u, err := s.findUser(id)
+    if err != nil { return nil, err }
+    if !u.Disabled { return nil, ErrUserNotDisabled }
+    if err := s.users.SetDisabled(id, false); err != nil { return nil, err }
+    u.Disabled = false
+    return u, s.record(u.Email, UserEventEnabled, actor, "")
+}
+
+// SignOutEverywhere ends every session the user has open; they stay enabled
+// and can sign back in with a new magic link.
+func (s *UserService) SignOutEverywhere(id uint, actor string) (*models.User, error) {
+    u, err := s.findUser(id)
+    if err != nil { return nil, err }
+    if err := s.users.BumpSessionGeneration(id); err != nil { return nil, err }
+    u.SessionGeneration++
+    return u, s.record(u.Email, UserEventSignedOut, actor, "")
+}
+
+// RevokeEmail disables the account registered under email, if there is one,
+// and revokes every invitation sent to it. Unknown emails get no account.
+func (s *UserService) RevokeEmail(email, actor string) error {
+    e := strings.ToLower(strings.TrimSpace(email))
+    u, err := s.users.FindByEmail(e)
+    if err == nil {
+        if u.Disabled { return ErrUserAlreadyDisabled }
+        if err := s.users.SetDisabled(u.ID, true); err != nil { return err }
+    }
+    if err := s.invites.RevokeAllByEmail(e); err != nil { return err }
+    detail := "invitations revoked"
+    if u == nil { detail = "invitations revoked; no account" }
+    return s.record(e, UserEventDisabled, actor, detail)
+}
+
+// DeleteUser removes a user and revokes their invitations so they cannot sign
+// back in. Their links go to the user registered under reassignTo or, when
+// it is empty, stay without an owner account for admins to manage.
+func (s *UserService) DeleteUser(id uint, reassignTo, actor string) error {
+    u, err := s.findUser(id)
+    if err != nil { return err }
+    var target *uint
+    detail := "links kept"
+    if reassignTo = strings.TrimSpace(reassignTo); reassignTo != "" {
+        t, err := s.users.FindByEmail(reassignTo)
+        if err != nil { return ErrUserNotFound }
+        if t.ID == u.ID { return ErrReassignToSelf }
+        target, detail = &t.ID, "links reassigned to "+t.Email
+    }
+    if err := s.users.Delete(id, target); err != nil {
+        if errors.Is(err, repositories.ErrNotFound) { return ErrUserNotFound }
+        return err
+    }
+    if err := s.invites.RevokeAllByEmail(u.Email); err != nil { return err }
+    return s.record(u.Email, UserEventDeleted, actor, detail)
+}
+
+func (s *UserService) findUser(id uint) (*models.User, error) {
+    u, err := s.users.FindByID(id)
+    if err != nil { return nil, ErrUserNotFound }
+    return u, nil
+}
+
+func (s *UserService) record(email, action, actor, detail string) error {
+    return s.events.Create(&models.UserEvent{Email: email, Action: action, Actor: actor, Detail: detail})
+}